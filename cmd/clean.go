@@ -2,13 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -16,6 +14,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/fetcher"
+	cmdrmanager "github.com/mrlyc/cmdr/core/manager"
 	"github.com/mrlyc/cmdr/core/utils"
 )
 
@@ -72,42 +72,28 @@ func uniquePath(dir, base string) (string, error) {
 	}
 }
 
-func moveFile(src, dst string) error {
-	err := os.Rename(src, dst)
-	if err == nil {
-		return nil
-	}
-	if linkErr, ok := err.(*os.LinkError); !ok || linkErr.Err != syscall.EXDEV {
-		return errors.Wrapf(err, "rename %s to %s failed", src, dst)
+// trashShim puts the shim into the trash, the blob which the shim links to is copied rather than the link,
+// since the store collects the blobs which are not referred by the shims. It reports whether the shim is moved.
+func trashShim(store *cmdrmanager.BinaryStore, location, dst string) (bool, error) {
+	target, err := os.Readlink(location)
+	if store == nil || err != nil {
+		return true, utils.MoveFile(location, dst)
 	}
 
-	// Cross-device rename. Copy then remove.
-	in, err := os.Open(src)
-	if err != nil {
-		return errors.Wrapf(err, "open %s failed", src)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(location), target)
 	}
-	defer in.Close()
 
-	info, err := in.Stat()
-	if err != nil {
-		return errors.Wrapf(err, "stat %s failed", src)
+	if _, ok := store.DigestOf(target); !ok {
+		return true, utils.MoveFile(location, dst)
 	}
 
-	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	err = utils.NewPathHelper(filepath.Dir(dst)).CopyFile(filepath.Base(dst), target, 0755)
 	if err != nil {
-		return errors.Wrapf(err, "create %s failed", dst)
-	}
-	defer out.Close()
-
-	if _, err := io.Copy(out, in); err != nil {
-		return errors.Wrapf(err, "copy %s to %s failed", src, dst)
-	}
-
-	if err := os.Remove(src); err != nil {
-		return errors.Wrapf(err, "remove %s failed", src)
+		return false, err
 	}
 
-	return nil
+	return false, nil
 }
 
 // cleanCmd represents the clean command
@@ -135,6 +121,7 @@ var cleanCmd = &cobra.Command{
 			return err
 		}
 
+		store := cmdrmanager.NewBinaryStoreByConfiguration(cfg)
 		binDir := cfg.GetString(core.CfgKeyCmdrBinDir)
 		binHelper := utils.NewPathHelper(binDir)
 
@@ -237,6 +224,11 @@ var cleanCmd = &cobra.Command{
 			})
 		}
 
+		recorder, _ := manager.(core.CommandHistoryRecorder)
+		if recorder != nil {
+			recorder.SetHistoryCause(cmdrmanager.HistoryCauseClean)
+		}
+
 		var resultErr error
 		cleaned := 0
 		for name, candidates := range inactiveByName {
@@ -255,6 +247,11 @@ var cleanCmd = &cobra.Command{
 					continue
 				}
 
+				if err := core.PublishCommandEvent(core.EventPreClean, name, c.version, c.location); err != nil {
+					resultErr = multierror.Append(resultErr, errors.WithMessagef(err, "%s of %s:%s aborted", core.EventPreClean, name, c.version))
+					continue
+				}
+
 				dstDir := filepath.Join(trashRoot, name)
 				if err := ensureDir(dstDir); err != nil {
 					resultErr = multierror.Append(resultErr, err)
@@ -267,14 +264,19 @@ var cleanCmd = &cobra.Command{
 					continue
 				}
 
-				if err := moveFile(c.location, dst); err != nil {
+				moved, err := trashShim(store, c.location, dst)
+				if err != nil {
 					resultErr = multierror.Append(resultErr, err)
 					continue
 				}
 
 				if err := manager.Undefine(name, c.version); err != nil {
-					// Best-effort rollback: restore shim back to original place.
-					if rbErr := moveFile(dst, c.location); rbErr != nil {
+					// Best-effort rollback: restore shim back to original place, or drop the copied blob.
+					rbErr := os.Remove(dst)
+					if moved {
+						rbErr = utils.MoveFile(dst, c.location)
+					}
+					if rbErr != nil {
 						logger.Warn("failed to rollback shim after undefine failure", map[string]interface{}{
 							"name":    name,
 							"version": c.version,
@@ -286,6 +288,16 @@ var cleanCmd = &cobra.Command{
 				}
 
 				cleaned++
+				if recorder != nil {
+					if err := recorder.RecordHistory(core.HistoryOperationClean, name, c.version, dst, c.location); err != nil {
+						logger.Warn("failed to record cleaned version", map[string]interface{}{
+							"name":    name,
+							"version": c.version,
+							"error":   err,
+						})
+					}
+				}
+				_ = core.PublishCommandEvent(core.EventPostClean, name, c.version, dst)
 				logger.Info("cleaned inactive version", map[string]interface{}{
 					"name":       name,
 					"version":    c.version,
@@ -295,8 +307,16 @@ var cleanCmd = &cobra.Command{
 			}
 		}
 
+		// the abandoned partial downloads are removed as well, a zero ttl removes them all
+		stagingDir := cfg.GetString(core.CfgKeyCmdrStagingDir)
+		staged, err := fetcher.CleanStaging(stagingDir, cfg.GetDuration(core.CfgKeyCmdrStagingTTL))
+		if err != nil {
+			resultErr = multierror.Append(resultErr, err)
+		}
+
 		logger.Info("clean finished", map[string]interface{}{
 			"cleaned":   cleaned,
+			"staged":    len(staged),
 			"age_days":  ageDays,
 			"keep":      keep,
 			"trash_dir": trashRoot,
@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
 
 	"github.com/mrlyc/cmdr/cmd/internal/testutils"
 	"github.com/mrlyc/cmdr/core"
+	cmdrmanager "github.com/mrlyc/cmdr/core/manager"
 )
 
 var _ = Describe("Clean", func() {
@@ -13,4 +18,55 @@ var _ = Describe("Clean", func() {
 		testutils.CheckCommandFlag(cleanCmd, "keep", "", core.CfgKeyXCleanKeep, "3", false)
 		testutils.CheckCommandFlag(cleanCmd, "name", "n", core.CfgKeyXCleanName, "", false)
 	})
+
+	Context("trashShim", func() {
+		var (
+			rootDir string
+			store   *cmdrmanager.BinaryStore
+			shim    string
+			dst     string
+		)
+
+		BeforeEach(func() {
+			var err error
+			rootDir, err = os.MkdirTemp("", "")
+			Expect(err).To(BeNil())
+
+			store = cmdrmanager.NewBinaryStore(filepath.Join(rootDir, "store"), 0755)
+			Expect(store.Init()).To(Succeed())
+
+			shim = filepath.Join(rootDir, "shims", "cmdr_1.0.0")
+			Expect(os.MkdirAll(filepath.Dir(shim), 0755)).To(Succeed())
+			dst = filepath.Join(rootDir, "trash")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(rootDir)).To(Succeed())
+		})
+
+		It("should copy the blob which the shim links to", func() {
+			blob := store.Path("digest")
+			Expect(os.WriteFile(blob, []byte("cmdr"), 0755)).To(Succeed())
+			Expect(os.Symlink(blob, shim)).To(Succeed())
+
+			moved, err := trashShim(store, shim, dst)
+			Expect(err).To(BeNil())
+			Expect(moved).To(BeFalse())
+
+			info, err := os.Lstat(dst)
+			Expect(err).To(BeNil())
+			Expect(info.Mode().IsRegular()).To(BeTrue())
+			Expect(os.ReadFile(dst)).To(Equal([]byte("cmdr")))
+		})
+
+		It("should move the shim which is not in the store", func() {
+			Expect(os.WriteFile(shim, []byte("cmdr"), 0755)).To(Succeed())
+
+			moved, err := trashShim(store, shim, dst)
+			Expect(err).To(BeNil())
+			Expect(moved).To(BeTrue())
+			Expect(shim).NotTo(BeAnExistingFile())
+			Expect(os.ReadFile(dst)).To(Equal([]byte("cmdr")))
+		})
+	})
 })
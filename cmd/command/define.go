@@ -42,6 +42,8 @@ func init() {
 	flags.StringP("version", "v", "", "command version")
 	flags.StringP("location", "l", "", "command location")
 	flags.BoolP("activate", "a", false, "activate command")
+	flags.StringArray("env", nil, "KEY=VALUE set by a wrapper shim, the values are templates")
+	flags.StringArray("arg", nil, "argument prepended by a wrapper shim, the values are templates")
 
 	helper := utils.NewDefaultCobraCommandCompleteHelper(DefineCmd)
 	cfg := core.GetConfiguration()
@@ -57,6 +59,8 @@ func init() {
 		DefineCmd.MarkFlagRequired("location"),
 
 		cfg.BindPFlag(core.CfgKeyXCommandDefineActivate, flags.Lookup("activate")),
+		cfg.BindPFlag(core.CfgKeyXCommandDefineEnv, flags.Lookup("env")),
+		cfg.BindPFlag(core.CfgKeyXCommandDefineArgs, flags.Lookup("arg")),
 
 		helper.RegisterNameFunc(),
 		helper.RegisterVersionFunc(),
@@ -17,6 +17,8 @@ var _ = Describe("Define", func() {
 		testutils.CheckCommandFlag(DefineCmd, "version", "v", core.CfgKeyXCommandDefineVersion, "", true)
 		testutils.CheckCommandFlag(DefineCmd, "location", "l", core.CfgKeyXCommandDefineLocation, "", true)
 		testutils.CheckCommandFlag(DefineCmd, "activate", "a", core.CfgKeyXCommandDefineActivate, "false", false)
+		testutils.CheckCommandFlag(DefineCmd, "env", "", core.CfgKeyXCommandDefineEnv, "[]", false)
+		testutils.CheckCommandFlag(DefineCmd, "arg", "", core.CfgKeyXCommandDefineArgs, "[]", false)
 	})
 
 	Context("command", func() {
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// writeWrapping writes the env like the env command, then the args and the wrapped binary as comments
+func writeWrapping(output io.Writer, wrapping *manager.Wrapping) error {
+	environ := wrapping.Environ()
+	keys := make([]string, 0, len(environ))
+	for key := range environ {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&builder, "%s=%s\n", key, environ[key])
+	}
+
+	if len(wrapping.Args) > 0 {
+		fmt.Fprintf(&builder, "# args: %s\n", strings.Join(wrapping.Args, " "))
+	}
+
+	fmt.Fprintf(&builder, "# exec: %s\n", wrapping.Location)
+
+	_, err := io.WriteString(output, builder.String())
+	return err
+}
+
+// EnvCmd represents the env command
+var EnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Show the environment which the wrapper shim of a command sets",
+	Run: runCommand(func(cfg core.Configuration, mgr core.CommandManager) error {
+		name := cfg.GetString(core.CfgKeyXCommandEnvName)
+		version := cfg.GetString(core.CfgKeyXCommandEnvVersion)
+
+		commands, err := queryCommands(mgr, version == "", name, version, "")
+		if err != nil {
+			return err
+		}
+
+		if len(commands) == 0 {
+			return errors.Wrapf(core.ErrBinaryNotFound, "command %s(%s) not found", name, version)
+		}
+
+		wrapping, err := manager.LoadWrapping(commands[0].GetLocation())
+		if err != nil {
+			return err
+		}
+
+		return writeWrapping(os.Stdout, wrapping)
+	}),
+}
+
+func init() {
+	Cmd.AddCommand(EnvCmd)
+	flags := EnvCmd.Flags()
+	flags.StringP("name", "n", "", "command name")
+	flags.StringP("version", "v", "", "command version, the activated one is shown when empty")
+
+	cfg := core.GetConfiguration()
+
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXCommandEnvName, flags.Lookup("name")),
+		EnvCmd.MarkFlagRequired("name"),
+
+		cfg.BindPFlag(core.CfgKeyXCommandEnvVersion, flags.Lookup("version")),
+
+		utils.NewDefaultCobraCommandCompleteHelper(EnvCmd).RegisterAll(),
+	)
+}
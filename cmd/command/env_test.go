@@ -0,0 +1,34 @@
+package command
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+var _ = Describe("Env", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(EnvCmd, "name", "n", core.CfgKeyXCommandEnvName, "", true)
+		testutils.CheckCommandFlag(EnvCmd, "version", "v", core.CfgKeyXCommandEnvVersion, "", false)
+	})
+
+	It("should write the wrapping", func() {
+		var output bytes.Buffer
+		Expect(writeWrapping(&output, &manager.Wrapping{
+			Location: "/shims/java/.wrapped/java_17.0.0",
+			Env:      []string{"JAVA_OPTS=-Xmx1g", "JAVA_HOME=/opt/java"},
+			Args:     []string{"-server"},
+		})).To(Succeed())
+
+		Expect(output.String()).To(Equal(`JAVA_HOME=/opt/java
+JAVA_OPTS=-Xmx1g
+# args: -server
+# exec: /shims/java/.wrapped/java_17.0.0
+`))
+	})
+})
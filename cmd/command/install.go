@@ -5,6 +5,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/lockfile"
+	"github.com/mrlyc/cmdr/core/manager"
 	"github.com/mrlyc/cmdr/core/utils"
 )
 
@@ -14,7 +16,7 @@ var InstallCmd = &cobra.Command{
 	Short: "Install command into cmdr",
 	PreRun: func(cmd *cobra.Command, args []string) {
 		cfg := core.GetConfiguration()
-		cfg.Set(core.CfgKeyCmdrLinkMode, "default")
+		cfg.Set(core.CfgKeyCmdrLinkMode, manager.InstallLinkMode(cfg.GetString(core.CfgKeyCmdrLinkMode)))
 	},
 	Run: utils.RunCobraCommandWith(core.CommandProviderDownload, func(cfg core.Configuration, manager core.CommandManager) error {
 		logger := core.GetLogger()
@@ -41,8 +43,27 @@ func init() {
 	flags := InstallCmd.Flags()
 	flags.StringP("name", "n", "", "command name")
 	flags.StringP("version", "v", "", "command version")
-	flags.StringP("location", "l", "", "command location")
+	flags.StringP("location", "l", "", "command location, resolved by registries when empty")
 	flags.BoolP("activate", "a", false, "activate command")
+	flags.StringArray("env", nil, "KEY=VALUE set by a wrapper shim, the values are templates")
+	flags.StringArray("arg", nil, "argument prepended by a wrapper shim, the values are templates")
+	flags.Bool("frozen", false, "install from the lockfile only, fail on any digest drift")
+	flags.String("lockfile", lockfile.DefaultPath, "lockfile used by frozen installs")
+	flags.String("signature", "", "url or path of the detached signature, instead of the sibling of the download")
+	flags.String("signature-key", "", "public key of minisign, gpg or cosign which must sign the download")
+	flags.String("recipe", "", "recipe file to build git+ locations")
+	flags.StringArray("build", nil, "build command of git+ locations, overrides the recipe")
+	flags.StringToString("build-env", nil, "build environment of git+ locations, merged into the recipe")
+	flags.String("build-output", "", "binary path relative to the source dir, overrides the recipe")
+	flags.Int("build-timeout", 0, "build timeout in seconds, overrides the recipe")
+	flags.String("go-version", "", "version of the go command managed by cmdr to install go:// locations")
+	flags.String("go-flags", "", "GOFLAGS of go:// locations")
+	flags.String("go-cgo-enabled", "", "CGO_ENABLED of go:// locations")
+	flags.StringSlice("go-tags", nil, "build tags of go:// locations")
+	flags.String("go-ldflags", "", "ldflags of go:// locations")
+	flags.String("go-private", "", "GOPRIVATE of go:// locations")
+	flags.Bool("go-isolated", false, "install go:// locations with isolated GOPATH and GOCACHE")
+	flags.Bool("go-allow-latest", false, "install the latest go:// module when the version does not exist")
 
 	helper := utils.NewDefaultCobraCommandCompleteHelper(InstallCmd)
 	cfg := core.GetConfiguration()
@@ -55,9 +76,27 @@ func init() {
 		InstallCmd.MarkFlagRequired("version"),
 
 		cfg.BindPFlag(core.CfgKeyXCommandInstallLocation, flags.Lookup("location")),
-		InstallCmd.MarkFlagRequired("location"),
 
 		cfg.BindPFlag(core.CfgKeyXCommandInstallActivate, flags.Lookup("activate")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallEnv, flags.Lookup("env")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallArgs, flags.Lookup("arg")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallFrozen, flags.Lookup("frozen")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallLockfile, flags.Lookup("lockfile")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallSignature, flags.Lookup("signature")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallSignatureKey, flags.Lookup("signature-key")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallRecipe, flags.Lookup("recipe")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallBuildCommands, flags.Lookup("build")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallBuildEnv, flags.Lookup("build-env")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallBuildOutput, flags.Lookup("build-output")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallBuildTimeout, flags.Lookup("build-timeout")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallGoVersion, flags.Lookup("go-version")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallGoFlags, flags.Lookup("go-flags")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallGoCGOEnabled, flags.Lookup("go-cgo-enabled")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallGoTags, flags.Lookup("go-tags")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallGoLDFlags, flags.Lookup("go-ldflags")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallGoPrivate, flags.Lookup("go-private")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallGoIsolated, flags.Lookup("go-isolated")),
+		cfg.BindPFlag(core.CfgKeyXCommandInstallGoAllowLatest, flags.Lookup("go-allow-latest")),
 
 		helper.RegisterNameFunc(),
 		helper.RegisterVersionFunc(),
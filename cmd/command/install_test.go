@@ -15,8 +15,27 @@ var _ = Describe("Install", func() {
 	It("should check flags", func() {
 		testutils.CheckCommandFlag(InstallCmd, "name", "n", core.CfgKeyXCommandInstallName, "", true)
 		testutils.CheckCommandFlag(InstallCmd, "version", "v", core.CfgKeyXCommandInstallVersion, "", true)
-		testutils.CheckCommandFlag(InstallCmd, "location", "l", core.CfgKeyXCommandInstallLocation, "", true)
+		testutils.CheckCommandFlag(InstallCmd, "location", "l", core.CfgKeyXCommandInstallLocation, "", false)
 		testutils.CheckCommandFlag(InstallCmd, "activate", "a", core.CfgKeyXCommandInstallActivate, "false", false)
+		testutils.CheckCommandFlag(InstallCmd, "frozen", "", core.CfgKeyXCommandInstallFrozen, "false", false)
+		testutils.CheckCommandFlag(InstallCmd, "lockfile", "", core.CfgKeyXCommandInstallLockfile, "cmdr.lock", false)
+		testutils.CheckCommandFlag(InstallCmd, "signature", "", core.CfgKeyXCommandInstallSignature, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "signature-key", "", core.CfgKeyXCommandInstallSignatureKey, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "recipe", "", core.CfgKeyXCommandInstallRecipe, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "build", "", core.CfgKeyXCommandInstallBuildCommands, "[]", false)
+		testutils.CheckCommandFlag(InstallCmd, "build-env", "", core.CfgKeyXCommandInstallBuildEnv, "[]", false)
+		testutils.CheckCommandFlag(InstallCmd, "build-output", "", core.CfgKeyXCommandInstallBuildOutput, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "build-timeout", "", core.CfgKeyXCommandInstallBuildTimeout, "0", false)
+		testutils.CheckCommandFlag(InstallCmd, "go-version", "", core.CfgKeyXCommandInstallGoVersion, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "go-flags", "", core.CfgKeyXCommandInstallGoFlags, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "go-cgo-enabled", "", core.CfgKeyXCommandInstallGoCGOEnabled, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "go-tags", "", core.CfgKeyXCommandInstallGoTags, "[]", false)
+		testutils.CheckCommandFlag(InstallCmd, "go-ldflags", "", core.CfgKeyXCommandInstallGoLDFlags, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "go-private", "", core.CfgKeyXCommandInstallGoPrivate, "", false)
+		testutils.CheckCommandFlag(InstallCmd, "go-isolated", "", core.CfgKeyXCommandInstallGoIsolated, "false", false)
+		testutils.CheckCommandFlag(InstallCmd, "go-allow-latest", "", core.CfgKeyXCommandInstallGoAllowLatest, "false", false)
+		testutils.CheckCommandFlag(InstallCmd, "env", "", core.CfgKeyXCommandInstallEnv, "[]", false)
+		testutils.CheckCommandFlag(InstallCmd, "arg", "", core.CfgKeyXCommandInstallArgs, "[]", false)
 	})
 
 	Context("command", func() {
@@ -76,5 +95,19 @@ var _ = Describe("Install", func() {
 
 			Expect(cfg.GetString(core.CfgKeyCmdrLinkMode)).To(Equal("default"))
 		})
+
+		It("should not symlink the downloaded files", func() {
+			cfg.Set(core.CfgKeyCmdrLinkMode, "link")
+			InstallCmd.PreRun(InstallCmd, []string{})
+
+			Expect(cfg.GetString(core.CfgKeyCmdrLinkMode)).To(Equal("default"))
+		})
+
+		It("should keep the hardlink mode", func() {
+			cfg.Set(core.CfgKeyCmdrLinkMode, "hardlink")
+			InstallCmd.PreRun(InstallCmd, []string{})
+
+			Expect(cfg.GetString(core.CfgKeyCmdrLinkMode)).To(Equal("hardlink"))
+		})
 	})
 })
@@ -1,13 +1,58 @@
 package command
 
 import (
+	"os"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/mrlyc/cmdr/core"
 	"github.com/mrlyc/cmdr/core/utils"
+	"github.com/mrlyc/cmdr/core/versionfile"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
+// resolveRequestedVersion returns the installed version which the version files of the work dir request
+func resolveRequestedVersion(cfg core.Configuration, manager core.CommandManager, name string) (string, error) {
+	resolver, err := versionfile.NewResolverByConfiguration(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", errors.Wrapf(err, "get work dir failed")
+	}
+
+	resolutions, err := resolver.Resolve(workDir)
+	if err != nil {
+		return "", err
+	}
+
+	resolution, ok := resolutions[name]
+	if !ok {
+		return "", errors.Wrapf(versionfile.ErrVersionNotRequested, "%s", name)
+	}
+
+	commands, err := queryCommands(manager, false, name, "", "")
+	if err != nil {
+		return "", err
+	}
+
+	versions := make([]string, 0, len(commands))
+	for _, command := range commands {
+		versions = append(versions, command.GetVersion())
+	}
+
+	version, ok := versionfile.MatchVersion(resolution.Version, versions)
+	if !ok {
+		return "", errors.Wrapf(core.ErrBinaryNotFound, "%s(%s) requested by %s is not installed", name, resolution.Version, resolution.Source)
+	}
+
+	// the versions of the commands are short, the full one is kept by the database
+	return versioning.Normalize(version), nil
+}
+
 // UseCmd represents the use command
 var UseCmd = &cobra.Command{
 	Use:   "use",
@@ -17,7 +62,15 @@ var UseCmd = &cobra.Command{
 		name := cfg.GetString(core.CfgKeyXCommandUseName)
 		version := cfg.GetString(core.CfgKeyXCommandUseVersion)
 
-		err := manager.Activate(name, version)
+		var err error
+		if version == "" {
+			version, err = resolveRequestedVersion(cfg, manager, name)
+			if err != nil {
+				return errors.WithMessagef(err, "failed to resolve version of command %s", name)
+			}
+		}
+
+		err = manager.Activate(name, version)
 		if err != nil {
 			return errors.WithMessagef(err, "failed to activate command %s", name)
 		}
@@ -45,7 +98,6 @@ func init() {
 		UseCmd.MarkFlagRequired("name"),
 
 		cfg.BindPFlag(core.CfgKeyXCommandUseVersion, flags.Lookup("version")),
-		UseCmd.MarkFlagRequired("version"),
 
 		utils.NewDefaultCobraCommandCompleteHelper(UseCmd).RegisterAll(),
 	)
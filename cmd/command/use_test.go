@@ -1,19 +1,24 @@
 package command
 
 import (
+	"os"
+	"path/filepath"
+
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
 	"github.com/spf13/viper"
 
 	"github.com/mrlyc/cmdr/cmd/internal/testutils"
 	"github.com/mrlyc/cmdr/core"
+	coremanager "github.com/mrlyc/cmdr/core/manager"
 	"github.com/mrlyc/cmdr/core/mock"
 )
 
 var _ = Describe("Use", func() {
 	It("should check flags", func() {
 		testutils.CheckCommandFlag(UseCmd, "name", "n", core.CfgKeyXCommandUseName, "", true)
-		testutils.CheckCommandFlag(UseCmd, "version", "v", core.CfgKeyXCommandUseVersion, "", true)
+		testutils.CheckCommandFlag(UseCmd, "version", "v", core.CfgKeyXCommandUseVersion, "", false)
 	})
 
 	Context("command", func() {
@@ -54,5 +59,48 @@ var _ = Describe("Use", func() {
 
 			UseCmd.Run(UnsetCmd, []string{})
 		})
+
+		Context("without version", func() {
+			var (
+				workDir string
+				tempDir string
+				query   *mock.MockCommandQuery
+			)
+
+			BeforeEach(func() {
+				var err error
+				workDir, err = os.Getwd()
+				Expect(err).To(BeNil())
+
+				tempDir, err = os.MkdirTemp("", "")
+				Expect(err).To(BeNil())
+				Expect(os.WriteFile(filepath.Join(tempDir, ".nvmrc"), []byte("v18\n"), 0644)).To(Succeed())
+				Expect(os.Chdir(tempDir)).To(Succeed())
+
+				cfg.Set(core.CfgKeyXCommandUseName, "node")
+				cfg.Set(core.CfgKeyXCommandUseVersion, "")
+
+				query = mock.NewMockCommandQuery(ctrl)
+				manager.EXPECT().Query().Return(query, nil)
+				query.EXPECT().WithName("node").Return(query)
+				query.EXPECT().All().Return([]core.Command{
+					&coremanager.Command{Name: "node", Version: "16.20.0"},
+					&coremanager.Command{Name: "node", Version: "18.17.0"},
+					&coremanager.Command{Name: "node", Version: "18.9.1"},
+				}, nil)
+			})
+
+			AfterEach(func() {
+				Expect(os.Chdir(workDir)).To(Succeed())
+				Expect(os.RemoveAll(tempDir)).To(Succeed())
+			})
+
+			It("should activate the version requested by the version file", func() {
+				manager.EXPECT().Activate("node", "18.17.0").Return(nil)
+				manager.EXPECT().Close().Return(nil)
+
+				UseCmd.Run(UseCmd, []string{})
+			})
+		})
 	})
 })
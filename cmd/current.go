@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"os"
+	"sort"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/utils"
+	"github.com/mrlyc/cmdr/core/versionfile"
+)
+
+// currentSourceDatabase means the version is decided by the activated record
+const currentSourceDatabase = "database"
+
+// currentCommand is the version requested for a command, which is activated by `cmdr use` only
+type currentCommand struct {
+	name      string
+	version   string
+	activated string
+	source    string
+	installed bool
+}
+
+// resolveCurrentCommands returns the versions requested by version files first, then the activated records
+func resolveCurrentCommands(commands []core.Command, resolutions map[string]*versionfile.Resolution) []*currentCommand {
+	versionsByName := make(map[string][]string)
+	results := make(map[string]*currentCommand)
+	for _, command := range commands {
+		name := command.GetName()
+		versionsByName[name] = append(versionsByName[name], command.GetVersion())
+
+		if command.GetActivated() {
+			results[name] = &currentCommand{
+				name:      name,
+				version:   command.GetVersion(),
+				activated: command.GetVersion(),
+				source:    currentSourceDatabase,
+				installed: true,
+			}
+		}
+	}
+
+	for name, resolution := range resolutions {
+		current := &currentCommand{
+			name:    name,
+			version: resolution.Version,
+			source:  resolution.Source,
+		}
+
+		activated, ok := results[name]
+		if ok {
+			current.activated = activated.activated
+		}
+
+		version, ok := versionfile.MatchVersion(resolution.Version, versionsByName[name])
+		if ok {
+			current.version = version
+			current.installed = true
+		}
+
+		results[name] = current
+	}
+
+	currents := make([]*currentCommand, 0, len(results))
+	for _, current := range results {
+		currents = append(currents, current)
+	}
+
+	sort.Slice(currents, func(i, j int) bool {
+		return currents[i].name < currents[j].name
+	})
+
+	return currents
+}
+
+// currentCmd represents the current command
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the requested version of commands, which file requested it and the activated one",
+	Run: utils.RunCobraCommandWith(core.CommandProviderDefault, func(cfg core.Configuration, manager core.CommandManager) error {
+		resolver, err := versionfile.NewResolverByConfiguration(cfg)
+		if err != nil {
+			return err
+		}
+
+		workDir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		resolutions, err := resolver.Resolve(workDir)
+		if err != nil {
+			return err
+		}
+
+		query, err := manager.Query()
+		if err != nil {
+			return err
+		}
+
+		name := cfg.GetString(core.CfgKeyXCurrentName)
+		if name != "" {
+			query.WithName(name)
+
+			resolution, ok := resolutions[name]
+			resolutions = map[string]*versionfile.Resolution{}
+			if ok {
+				resolutions[name] = resolution
+			}
+		}
+
+		commands, err := query.All()
+		if err != nil {
+			return err
+		}
+
+		tab := table.Table{
+			Headers: []string{"Name", "Requested", "Activated", "Installed", "Source"},
+		}
+
+		for _, current := range resolveCurrentCommands(commands, resolutions) {
+			installed := "no"
+			if current.installed {
+				installed = "yes"
+			}
+
+			tab.Rows = append(tab.Rows, []string{
+				current.name, current.version, current.activated, installed, current.source,
+			})
+		}
+
+		return tab.WriteTable(os.Stdout, &table.Config{
+			Color:           true,
+			AlternateColors: true,
+			TitleColorCode:  ansi.ColorCode("white+buf"),
+		})
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(currentCmd)
+
+	flags := currentCmd.Flags()
+	flags.StringP("name", "n", "", "command name")
+
+	cfg := core.GetConfiguration()
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXCurrentName, flags.Lookup("name")),
+	)
+}
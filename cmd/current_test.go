@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/versionfile"
+)
+
+var _ = Describe("Current", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(currentCmd, "name", "n", core.CfgKeyXCurrentName, "", false)
+	})
+
+	It("should prefer version files", func() {
+		commands := []core.Command{
+			&manager.Command{Name: "node", Version: "16.20.0", Activated: true},
+			&manager.Command{Name: "node", Version: "18.17.0"},
+			&manager.Command{Name: "go", Version: "1.21.0", Activated: true},
+			&manager.Command{Name: "kubectl", Version: "1.28.0"},
+		}
+
+		currents := resolveCurrentCommands(commands, map[string]*versionfile.Resolution{
+			"node":      {Command: "node", Version: "18", Source: ".nvmrc"},
+			"terraform": {Command: "terraform", Version: "1.5.0", Source: ".tool-versions"},
+		})
+
+		Expect(currents).To(Equal([]*currentCommand{
+			{name: "go", version: "1.21", activated: "1.21", source: currentSourceDatabase, installed: true},
+			{name: "node", version: "18.17", activated: "16.20", source: ".nvmrc", installed: true},
+			{name: "terraform", version: "1.5.0", source: ".tool-versions"},
+		}))
+	})
+})
@@ -0,0 +1,7 @@
+package cmd
+
+import "github.com/mrlyc/cmdr/cmd/download"
+
+func init() {
+	rootCmd.AddCommand(download.Cmd)
+}
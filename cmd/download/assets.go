@@ -0,0 +1,93 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/fetcher"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+func writeAssetsTable(output io.Writer, candidates []*utils.AssetCandidate) error {
+	tab := table.Table{
+		Headers: []string{"Asset", "Score", "Excluded", "Reasons"},
+	}
+
+	for _, candidate := range candidates {
+		tab.Rows = append(tab.Rows, []string{
+			candidate.Name,
+			fmt.Sprintf("%.2f", candidate.Score),
+			fmt.Sprintf("%v", candidate.Excluded),
+			strings.Join(candidate.Reasons, ", "),
+		})
+	}
+
+	return tab.WriteTable(output, &table.Config{
+		Color:           true,
+		AlternateColors: true,
+		TitleColorCode:  ansi.ColorCode("white+buf"),
+	})
+}
+
+// assetsCmd represents the assets command
+var assetsCmd = &cobra.Command{
+	Use:   "assets <github://owner/repo>",
+	Short: "Explain how the release asset of a platform is chosen",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := core.GetConfiguration()
+
+		platform := utils.CurrentAssetPlatform()
+		for key, value := range map[string]*string{
+			core.CfgKeyXDownloadAssetsOS:   &platform.OS,
+			core.CfgKeyXDownloadAssetsArch: &platform.Arch,
+			core.CfgKeyXDownloadAssetsLibc: &platform.Libc,
+		} {
+			if cfg.GetString(key) != "" {
+				*value = cfg.GetString(key)
+			}
+		}
+
+		gitHubFetcher := fetcher.NewDefaultGitHubReleaseFetcher(fetcher.NewDefaultGoGetter(os.Stderr))
+		gitHubFetcher.SetOffline(cfg.GetBool(core.CfgKeyCmdrOffline))
+		gitHubFetcher.SetPlatform(platform)
+
+		candidates, err := gitHubFetcher.Explain(
+			cfg.GetString(core.CfgKeyXDownloadAssetsName),
+			cfg.GetString(core.CfgKeyXDownloadAssetsVersion),
+			args[0],
+		)
+		utils.ExitOnError("Failed to explain release assets", err)
+
+		fmt.Printf("platform: %s\n\n", platform)
+		utils.ExitOnError("Failed to write assets", writeAssetsTable(os.Stdout, candidates))
+	},
+}
+
+func init() {
+	Cmd.AddCommand(assetsCmd)
+
+	flags := assetsCmd.Flags()
+	flags.StringP("name", "n", "", "command name, the assets which contain it are preferred")
+	flags.StringP("version", "v", "", "release version, the latest release when empty")
+	flags.String("os", "", "os to match, the current one when empty")
+	flags.String("arch", "", "arch to match, the current one when empty")
+	flags.String("libc", "", "libc to match on linux, gnu or musl, detected when empty")
+
+	cfg := core.GetConfiguration()
+
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXDownloadAssetsName, flags.Lookup("name")),
+		cfg.BindPFlag(core.CfgKeyXDownloadAssetsVersion, flags.Lookup("version")),
+		cfg.BindPFlag(core.CfgKeyXDownloadAssetsOS, flags.Lookup("os")),
+		cfg.BindPFlag(core.CfgKeyXDownloadAssetsArch, flags.Lookup("arch")),
+		cfg.BindPFlag(core.CfgKeyXDownloadAssetsLibc, flags.Lookup("libc")),
+	)
+}
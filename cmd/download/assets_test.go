@@ -0,0 +1,33 @@
+package download
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var _ = Describe("Assets", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(assetsCmd, "name", "n", core.CfgKeyXDownloadAssetsName, "", false)
+		testutils.CheckCommandFlag(assetsCmd, "version", "v", core.CfgKeyXDownloadAssetsVersion, "", false)
+		testutils.CheckCommandFlag(assetsCmd, "os", "", core.CfgKeyXDownloadAssetsOS, "", false)
+		testutils.CheckCommandFlag(assetsCmd, "arch", "", core.CfgKeyXDownloadAssetsArch, "", false)
+		testutils.CheckCommandFlag(assetsCmd, "libc", "", core.CfgKeyXDownloadAssetsLibc, "", false)
+	})
+
+	It("should write table", func() {
+		matcher := utils.NewAssetMatcher(utils.AssetPlatform{OS: "linux", Arch: "amd64"}, "jq")
+
+		var output bytes.Buffer
+		Expect(writeAssetsTable(&output, matcher.Explain([]string{"jq-linux-amd64", "sha256sum.txt"}))).To(Succeed())
+
+		content := output.String()
+		Expect(content).To(ContainSubstring("jq-linux-amd64"))
+		Expect(content).To(ContainSubstring(".txt file"))
+	})
+})
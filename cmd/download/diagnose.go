@@ -0,0 +1,169 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/rewrite"
+	"github.com/mrlyc/cmdr/core/strategy"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+type diagnoseReport struct {
+	Location     string                        `json:"location"`
+	RewrittenURI string                        `json:"rewritten_uri"`
+	Proxies      map[string]string             `json:"proxies"`
+	Strategies   []*strategy.StrategyDiagnosis `json:"strategies"`
+}
+
+func getProxySettings(cfg core.Configuration) map[string]string {
+	settings := make(map[string]string)
+
+	// the environment variables are populated by the proxy configurations when initializing
+	for _, key := range []string{
+		core.CfgKeyProxyGo, core.CfgKeyProxyHTTP, core.CfgKeyProxyHTTPS,
+	} {
+		settings[key] = cfg.GetString(key)
+	}
+
+	for _, key := range []string{
+		"GOPROXY", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	} {
+		settings[key] = os.Getenv(key)
+	}
+
+	return settings
+}
+
+func writeDiagnoseTable(output io.Writer, report *diagnoseReport) error {
+	tableConfig := &table.Config{
+		Color:           true,
+		AlternateColors: true,
+		TitleColorCode:  ansi.ColorCode("white+buf"),
+	}
+
+	summary := table.Table{
+		Headers: []string{"Item", "Value"},
+		Rows: [][]string{
+			{"location", report.Location},
+			{"rewritten", report.RewrittenURI},
+		},
+	}
+
+	for _, key := range []string{
+		core.CfgKeyProxyGo, core.CfgKeyProxyHTTP, core.CfgKeyProxyHTTPS,
+		"GOPROXY", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+	} {
+		summary.Rows = append(summary.Rows, []string{key, report.Proxies[key]})
+	}
+
+	err := summary.WriteTable(output, tableConfig)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(output)
+
+	probes := table.Table{
+		Headers: []string{"Strategy", "Enabled", "Active", "URI", "Probe", "Target", "Duration", "Result"},
+	}
+
+	for _, diagnosis := range report.Strategies {
+		row := []string{
+			diagnosis.Strategy,
+			fmt.Sprintf("%v", diagnosis.Enabled),
+			fmt.Sprintf("%v", diagnosis.Active),
+			diagnosis.PreparedURI,
+		}
+
+		if diagnosis.Error != "" {
+			probes.Rows = append(probes.Rows, append(row, "", "", "", diagnosis.Error))
+			continue
+		}
+
+		if len(diagnosis.Probes) == 0 {
+			probes.Rows = append(probes.Rows, append(row, "", "", "", ""))
+			continue
+		}
+
+		for _, probe := range diagnosis.Probes {
+			result := probe.Detail
+			switch {
+			case probe.Skipped:
+				result = fmt.Sprintf("skipped: %s", probe.Detail)
+			case probe.Error != "":
+				result = fmt.Sprintf("%s: %s", probe.Class, probe.Error)
+			}
+
+			probes.Rows = append(probes.Rows, append(
+				append([]string{}, row...),
+				probe.Probe,
+				probe.Target,
+				probe.Duration.Round(time.Millisecond).String(),
+				result,
+			))
+		}
+	}
+
+	return probes.WriteTable(output, tableConfig)
+}
+
+// diagnoseCmd represents the diagnose command
+var diagnoseCmd = &cobra.Command{
+	Use:   "diagnose <url>",
+	Short: "Diagnose the network of downloading url",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := core.GetConfiguration()
+		location := args[0]
+
+		rewriter, err := rewrite.NewEngineByConfiguration(cfg)
+		utils.ExitOnError("Failed to parse download rewrite rules", err)
+
+		chain, err := strategy.NewStrategyChainByConfiguration(cfg)
+		utils.ExitOnError("Failed to configure download strategies", err)
+
+		rewrittenURI := rewriter.Rewrite("", "", location)
+		timeout := time.Duration(cfg.GetInt(core.CfgKeyXDownloadDiagnoseTimeout)) * time.Second
+
+		report := &diagnoseReport{
+			Location:     location,
+			RewrittenURI: rewrittenURI,
+			Proxies:      getProxySettings(cfg),
+			Strategies:   strategy.NewDiagnoser(chain, timeout).Diagnose(context.Background(), rewrittenURI),
+		}
+
+		switch cfg.GetString(core.CfgKeyXDownloadDiagnoseOutput) {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			utils.ExitOnError("Failed to write report", encoder.Encode(report))
+		default:
+			utils.ExitOnError("Failed to write report", writeDiagnoseTable(os.Stdout, report))
+		}
+	},
+}
+
+func init() {
+	Cmd.AddCommand(diagnoseCmd)
+
+	flags := diagnoseCmd.Flags()
+	flags.StringP("output", "o", "table", "output format, table or json")
+	flags.IntP("timeout", "t", 10, "timeout of each probe in seconds")
+
+	cfg := core.GetConfiguration()
+
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXDownloadDiagnoseOutput, flags.Lookup("output")),
+		cfg.BindPFlag(core.CfgKeyXDownloadDiagnoseTimeout, flags.Lookup("timeout")),
+	)
+}
@@ -0,0 +1,60 @@
+package download
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/strategy"
+)
+
+var _ = Describe("Diagnose", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(diagnoseCmd, "output", "o", core.CfgKeyXDownloadDiagnoseOutput, "table", false)
+		testutils.CheckCommandFlag(diagnoseCmd, "timeout", "t", core.CfgKeyXDownloadDiagnoseTimeout, "10", false)
+	})
+
+	Context("report", func() {
+		var report *diagnoseReport
+
+		BeforeEach(func() {
+			report = &diagnoseReport{
+				Location:     "https://example.com/cmdr",
+				RewrittenURI: "https://example.com/cmdr",
+				Proxies:      map[string]string{"HTTP_PROXY": "http://proxy:8080"},
+				Strategies: []*strategy.StrategyDiagnosis{
+					{
+						Strategy:    "direct",
+						Enabled:     true,
+						Active:      true,
+						PreparedURI: "https://example.com/cmdr",
+						Probes: []*strategy.ProbeResult{
+							{Probe: strategy.ProbeDNS, Target: "example.com", Error: "no such host", Class: strategy.ErrorClassDNS},
+							{Probe: strategy.ProbeTLS, Target: "example.com:443", Skipped: true, Detail: "tunneled by proxy"},
+						},
+					},
+					{Strategy: "proxy"},
+				},
+			}
+		})
+
+		It("should write table", func() {
+			var output bytes.Buffer
+			Expect(writeDiagnoseTable(&output, report)).To(Succeed())
+
+			Expect(output.String()).To(ContainSubstring("http://proxy:8080"))
+			Expect(output.String()).To(ContainSubstring("dns: no such host"))
+			Expect(output.String()).To(ContainSubstring("skipped: tunneled by proxy"))
+		})
+
+		It("should be marshaled to json", func() {
+			data, err := json.Marshal(report)
+			Expect(err).To(BeNil())
+			Expect(string(data)).To(ContainSubstring(`"class":"dns"`))
+		})
+	})
+})
@@ -0,0 +1,97 @@
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/rewrite"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+func writeRewriteTable(output io.Writer, trace *rewrite.Trace) error {
+	tab := table.Table{
+		Headers: []string{"Rule", "Matched", "Stop", "Input", "Output", "Reason"},
+	}
+
+	for _, step := range trace.Steps {
+		tab.Rows = append(tab.Rows, []string{
+			step.Rule,
+			fmt.Sprintf("%v", step.Matched),
+			fmt.Sprintf("%v", step.Stop),
+			step.Input,
+			step.Output,
+			step.Reason,
+		})
+	}
+
+	err := tab.WriteTable(output, &table.Config{
+		Color:           true,
+		AlternateColors: true,
+		TitleColorCode:  ansi.ColorCode("white+buf"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(output, "\n%s\n", trace.Output)
+	return err
+}
+
+// rewriteCmd represents the rewrite command
+var rewriteCmd = &cobra.Command{
+	Use:   "rewrite <url>",
+	Short: "Show the url rewritten by download rules",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := core.GetConfiguration()
+
+		engine, err := rewrite.NewEngineByConfiguration(cfg)
+		utils.ExitOnError("Failed to parse download rewrite rules", err)
+
+		trace := engine.Explain(
+			cfg.GetString(core.CfgKeyXDownloadRewriteName),
+			cfg.GetString(core.CfgKeyXDownloadRewriteVersion),
+			args[0],
+		)
+
+		if !cfg.GetBool(core.CfgKeyXDownloadRewriteExplain) {
+			fmt.Println(trace.Output)
+			return
+		}
+
+		switch cfg.GetString(core.CfgKeyXDownloadRewriteOutput) {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			utils.ExitOnError("Failed to write trace", encoder.Encode(trace))
+		default:
+			utils.ExitOnError("Failed to write trace", writeRewriteTable(os.Stdout, trace))
+		}
+	},
+}
+
+func init() {
+	Cmd.AddCommand(rewriteCmd)
+
+	flags := rewriteCmd.Flags()
+	flags.BoolP("explain", "e", false, "trace how each rule handled the url")
+	flags.StringP("name", "n", "", "command name used by templates")
+	flags.StringP("version", "v", "", "command version used by templates")
+	flags.StringP("output", "o", "table", "output format of explain, table or json")
+
+	cfg := core.GetConfiguration()
+
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXDownloadRewriteExplain, flags.Lookup("explain")),
+		cfg.BindPFlag(core.CfgKeyXDownloadRewriteName, flags.Lookup("name")),
+		cfg.BindPFlag(core.CfgKeyXDownloadRewriteVersion, flags.Lookup("version")),
+		cfg.BindPFlag(core.CfgKeyXDownloadRewriteOutput, flags.Lookup("output")),
+	)
+}
@@ -0,0 +1,35 @@
+package download
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/rewrite"
+)
+
+var _ = Describe("Rewrite", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(rewriteCmd, "explain", "e", core.CfgKeyXDownloadRewriteExplain, "false", false)
+		testutils.CheckCommandFlag(rewriteCmd, "name", "n", core.CfgKeyXDownloadRewriteName, "", false)
+		testutils.CheckCommandFlag(rewriteCmd, "version", "v", core.CfgKeyXDownloadRewriteVersion, "", false)
+		testutils.CheckCommandFlag(rewriteCmd, "output", "o", core.CfgKeyXDownloadRewriteOutput, "table", false)
+	})
+
+	It("should write table", func() {
+		engine, err := rewrite.NewEngine(
+			&rewrite.Rule{Name: "mirror", Hosts: []string{"github.com"}, Template: "https://mirror.com{{ .Path }}"},
+			&rewrite.Rule{Name: "other", Hosts: []string{"gitlab.com"}, Template: "never"},
+		)
+		Expect(err).To(BeNil())
+
+		var output bytes.Buffer
+		Expect(writeRewriteTable(&output, engine.Explain("", "", "https://github.com/file"))).To(Succeed())
+
+		Expect(output.String()).To(ContainSubstring("host not matched"))
+		Expect(output.String()).To(HaveSuffix("https://mirror.com/file\n"))
+	})
+})
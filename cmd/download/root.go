@@ -0,0 +1,8 @@
+package download
+
+import "github.com/spf13/cobra"
+
+var Cmd = &cobra.Command{
+	Use:   "download",
+	Short: "Troubleshoot downloads",
+}
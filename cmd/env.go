@@ -0,0 +1,7 @@
+package cmd
+
+import "github.com/mrlyc/cmdr/cmd/env"
+
+func init() {
+	rootCmd.AddCommand(env.Cmd)
+}
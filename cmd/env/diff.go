@@ -0,0 +1,58 @@
+package env
+
+import (
+	"io"
+	"os"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+func writeDifferencesTable(output io.Writer, environment, compared string, differences []*manager.EnvironmentDifference) error {
+	tab := table.Table{
+		Headers: []string{"Name", environment, compared},
+	}
+
+	for _, difference := range differences {
+		tab.Rows = append(tab.Rows, []string{difference.Name, difference.Version, difference.Compared})
+	}
+
+	return tab.WriteTable(output, &table.Config{
+		Color:           true,
+		AlternateColors: true,
+		TitleColorCode:  ansi.ColorCode("white+buf"),
+	})
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <name> <name>",
+	Short: "Show the commands whose versions are different in two environments",
+	Args:  cobra.ExactArgs(2),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		cfg := core.GetConfiguration()
+		cfg.Set(core.CfgKeyXEnvName, args[0])
+		cfg.Set(core.CfgKeyXEnvCompared, args[1])
+	},
+	Run: runWithStore(func(cfg core.Configuration, mgr core.CommandManager, store *manager.EnvironmentStore) error {
+		environment, err := store.Get(cfg.GetString(core.CfgKeyXEnvName))
+		if err != nil {
+			return err
+		}
+
+		compared, err := store.Get(cfg.GetString(core.CfgKeyXEnvCompared))
+		if err != nil {
+			return err
+		}
+
+		return writeDifferencesTable(os.Stdout, environment.Name, compared.Name, manager.DiffEnvironments(environment, compared))
+	}),
+}
+
+func init() {
+	Cmd.AddCommand(diffCmd)
+}
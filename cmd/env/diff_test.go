@@ -0,0 +1,24 @@
+package env
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+var _ = Describe("Diff", func() {
+	It("should write the environments as headers", func() {
+		var output bytes.Buffer
+		Expect(writeDifferencesTable(&output, "client-a", "client-b", []*manager.EnvironmentDifference{
+			{Name: "node", Version: "18.17.0", Compared: "16.20.0"},
+		})).To(Succeed())
+
+		content := output.String()
+		Expect(content).To(ContainSubstring("client-a"))
+		Expect(content).To(ContainSubstring("client-b"))
+		Expect(content).To(ContainSubstring("16.20.0"))
+	})
+})
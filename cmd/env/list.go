@@ -0,0 +1,63 @@
+package env
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+func writeEnvironmentsTable(output io.Writer, environments []*manager.Environment) error {
+	sort.Slice(environments, func(i, j int) bool {
+		return environments[i].Name < environments[j].Name
+	})
+
+	tab := table.Table{
+		Headers: []string{"Name", "Commands", "Updated"},
+	}
+
+	for _, environment := range environments {
+		commands := make([]string, 0, len(environment.Commands))
+		for _, name := range environment.Names() {
+			commands = append(commands, name+"@"+environment.Commands[name])
+		}
+
+		tab.Rows = append(tab.Rows, []string{
+			environment.Name,
+			strings.Join(commands, " "),
+			environment.UpdatedAt.Local().Format(time.RFC3339),
+		})
+	}
+
+	return tab.WriteTable(output, &table.Config{
+		Color:           true,
+		AlternateColors: true,
+		TitleColorCode:  ansi.ColorCode("white+buf"),
+	})
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the environments",
+	Run: runWithStore(func(cfg core.Configuration, mgr core.CommandManager, store *manager.EnvironmentStore) error {
+		environments, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		return writeEnvironmentsTable(os.Stdout, environments)
+	}),
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+}
@@ -0,0 +1,27 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage the named sets of activated versions",
+}
+
+// runWithStore runs with the database manager, which keeps the environments in the same database
+func runWithStore(fn func(cfg core.Configuration, mgr core.CommandManager, store *manager.EnvironmentStore) error) func(cmd *cobra.Command, args []string) {
+	return utils.RunCobraCommandWith(core.CommandProviderDatabase, func(cfg core.Configuration, mgr core.CommandManager) error {
+		databaseManager, ok := mgr.(*manager.DatabaseManager)
+		if !ok {
+			return errors.Errorf("unexpected command manager %T", mgr)
+		}
+
+		return fn(cfg, mgr, manager.NewEnvironmentStore(databaseManager.Client))
+	})
+}
@@ -0,0 +1,43 @@
+package env
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+// saveCmd represents the save command
+var saveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Save the activated versions as an environment",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		core.GetConfiguration().Set(core.CfgKeyXEnvName, args[0])
+	},
+	Run: runWithStore(func(cfg core.Configuration, mgr core.CommandManager, store *manager.EnvironmentStore) error {
+		versions, err := manager.ActivatedVersions(mgr)
+		if err != nil {
+			return err
+		}
+
+		// cmdr itself is upgraded, not switched
+		delete(versions, core.Name)
+
+		environment, err := store.Save(cfg.GetString(core.CfgKeyXEnvName), versions)
+		if err != nil {
+			return err
+		}
+
+		core.GetLogger().Info("environment saved", map[string]interface{}{
+			"name":     environment.Name,
+			"commands": len(environment.Commands),
+		})
+
+		return nil
+	}),
+}
+
+func init() {
+	Cmd.AddCommand(saveCmd)
+}
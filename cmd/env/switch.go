@@ -0,0 +1,40 @@
+package env
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+// switchCmd represents the switch command
+var switchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "Activate the versions of an environment, nothing changes when any of them failed",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		core.GetConfiguration().Set(core.CfgKeyXEnvName, args[0])
+	},
+	Run: runWithStore(func(cfg core.Configuration, mgr core.CommandManager, store *manager.EnvironmentStore) error {
+		environment, err := store.Get(cfg.GetString(core.CfgKeyXEnvName))
+		if err != nil {
+			return err
+		}
+
+		err = manager.SwitchEnvironment(mgr, environment)
+		if err != nil {
+			return err
+		}
+
+		core.GetLogger().Info("environment switched", map[string]interface{}{
+			"name":     environment.Name,
+			"commands": len(environment.Commands),
+		})
+
+		return nil
+	}),
+}
+
+func init() {
+	Cmd.AddCommand(switchCmd)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mgutz/ansi"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// historyStoreOf returns the history kept by the database manager
+func historyStoreOf(mgr core.CommandManager) (*manager.HistoryStore, error) {
+	databaseManager, ok := mgr.(*manager.DatabaseManager)
+	if !ok || databaseManager.History() == nil {
+		return nil, errors.Errorf("unexpected command manager %T", mgr)
+	}
+
+	return databaseManager.History(), nil
+}
+
+func writeHistoryTable(output io.Writer, histories []*manager.History) error {
+	tab := table.Table{
+		Headers: []string{"ID", "Time", "Operation", "Name", "Version", "Previous", "Location", "Cause", "Cmdr", "Undone"},
+	}
+
+	for _, history := range histories {
+		undone := ""
+		if history.Undone {
+			undone = "yes"
+		}
+
+		tab.Rows = append(tab.Rows, []string{
+			fmt.Sprintf("%d", history.ID),
+			history.Time.Local().Format(time.RFC3339),
+			history.Operation,
+			history.Name,
+			history.Version,
+			history.Previous,
+			history.Location,
+			history.Cause,
+			history.CmdrVersion,
+			undone,
+		})
+	}
+
+	return tab.WriteTable(output, &table.Config{
+		Color:           true,
+		AlternateColors: true,
+		TitleColorCode:  ansi.ColorCode("white+buf"),
+	})
+}
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the operations which changed the commands",
+	Run: utils.RunCobraCommandWith(core.CommandProviderDatabase, func(cfg core.Configuration, mgr core.CommandManager) error {
+		store, err := historyStoreOf(mgr)
+		if err != nil {
+			return err
+		}
+
+		histories, err := store.List(cfg.GetString(core.CfgKeyXHistoryName))
+		if err != nil {
+			return err
+		}
+
+		return writeHistoryTable(os.Stdout, histories)
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	flags := historyCmd.Flags()
+	flags.StringP("name", "n", "", "command name")
+
+	cfg := core.GetConfiguration()
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXHistoryName, flags.Lookup("name")),
+	)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+var _ = Describe("History", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(historyCmd, "name", "n", core.CfgKeyXHistoryName, "", false)
+	})
+
+	It("should write the histories", func() {
+		var output bytes.Buffer
+		Expect(writeHistoryTable(&output, []*manager.History{
+			{ID: 2, Operation: core.HistoryOperationActivate, Name: "node", Version: "18.17.0", Previous: "16.20.0", Undone: true},
+			{ID: 1, Operation: core.HistoryOperationClean, Name: "node", Version: "14", Cause: manager.HistoryCauseClean},
+		})).To(Succeed())
+
+		Expect(output.String()).To(ContainSubstring("16.20.0"))
+		Expect(output.String()).To(ContainSubstring(manager.HistoryCauseClean))
+	})
+
+	It("should require a database manager", func() {
+		_, err := historyStoreOf(manager.NewSimpleManager(nil, nil))
+		Expect(err).To(HaveOccurred())
+	})
+})
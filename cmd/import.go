@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/mgutz/ansi"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/importer"
+	"github.com/mrlyc/cmdr/core/utils"
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:       fmt.Sprintf("import {%s}", strings.Join(importer.Names(), "|")),
+	Short:     "Import commands installed by other version managers",
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: importer.Names(),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		cfg := core.GetConfiguration()
+		// the files are managed by the other version manager, nothing should be copied
+		cfg.Set(core.CfgKeyCmdrLinkMode, "link")
+		cfg.Set(core.CfgKeyXImportTool, args[0])
+	},
+	Run: utils.RunCobraCommandWith(core.CommandProviderDefault, func(cfg core.Configuration, manager core.CommandManager) error {
+		logger := core.GetLogger()
+
+		tool := cfg.GetString(core.CfgKeyXImportTool)
+		imp, err := importer.New(tool, cfg)
+		if err != nil {
+			return errors.Wrapf(err, "create importer %s failed", tool)
+		}
+
+		candidates, err := imp.Scan()
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].Name != candidates[j].Name {
+				return candidates[i].Name < candidates[j].Name
+			}
+
+			return versioning.Compare(candidates[i].Version, candidates[j].Version) < 0
+		})
+
+		dryRun := cfg.GetBool(core.CfgKeyXImportDryRun)
+		activate := cfg.GetBool(core.CfgKeyXImportActivate)
+		tab := table.Table{
+			Headers: []string{"Tool", "Name", "Version", "Activated", "Location", "Status"},
+		}
+
+		var resultErr error
+		for _, candidate := range candidates {
+			activated := activate && candidate.Activated
+			status := "new"
+
+			_, err := utils.GetCmdrCommand(manager, candidate.Name, candidate.Version)
+			switch {
+			case err == nil:
+				status = "exists"
+			case dryRun:
+			default:
+				_, err = utils.DefineCmdrCommand(manager, candidate.Name, candidate.Version, candidate.Location, activated)
+				if err != nil {
+					status = "failed"
+					resultErr = multierror.Append(resultErr, errors.WithMessagef(
+						err, "import %s(%s) failed", candidate.Name, candidate.Version,
+					))
+				} else {
+					status = "imported"
+					logger.Debug("command imported", map[string]interface{}{
+						"name":     candidate.Name,
+						"version":  candidate.Version,
+						"location": candidate.Location,
+					})
+				}
+			}
+
+			flag := ""
+			if activated {
+				flag = "*"
+			}
+
+			tab.Rows = append(tab.Rows, []string{
+				candidate.Tool, candidate.Name, candidate.Version, flag, candidate.Location, status,
+			})
+		}
+
+		err = tab.WriteTable(os.Stdout, &table.Config{
+			Color:           true,
+			AlternateColors: true,
+			TitleColorCode:  ansi.ColorCode("white+buf"),
+		})
+		if err != nil {
+			return err
+		}
+
+		return resultErr
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	flags := importCmd.Flags()
+	flags.Bool("dry-run", false, "only show what would be imported")
+	flags.String("dir", "", "data dir of the version manager, like ~/.asdf")
+	flags.Bool("activate", true, "activate the versions selected by version files, like .tool-versions")
+
+	cfg := core.GetConfiguration()
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXImportDryRun, flags.Lookup("dry-run")),
+		cfg.BindPFlag(core.CfgKeyXImportDir, flags.Lookup("dir")),
+		cfg.BindPFlag(core.CfgKeyXImportActivate, flags.Lookup("activate")),
+	)
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+)
+
+var _ = Describe("Import", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(importCmd, "dry-run", "", core.CfgKeyXImportDryRun, "false", false)
+		testutils.CheckCommandFlag(importCmd, "dir", "", core.CfgKeyXImportDir, "", false)
+		testutils.CheckCommandFlag(importCmd, "activate", "", core.CfgKeyXImportActivate, "true", false)
+	})
+})
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/lockfile"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// lockArtifactOf returns the artifact recorded when the command was installed
+func lockArtifactOf(metadata map[string]string) *lockfile.Artifact {
+	if metadata[manager.MetadataKeyDownloadSHA256] == "" ||
+		metadata[manager.MetadataKeyDownloadPlatform] != lockfile.CurrentPlatform() {
+		return nil
+	}
+
+	return &lockfile.Artifact{
+		URL:            metadata[manager.MetadataKeyDownloadURI],
+		Strategy:       metadata[manager.MetadataKeyDownloadStrategy],
+		Entry:          metadata[manager.MetadataKeyDownloadEntry],
+		ArtifactSHA256: metadata[manager.MetadataKeyDownloadArtifactSHA256],
+		SHA256:         metadata[manager.MetadataKeyDownloadSHA256],
+	}
+}
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Write the resolved urls and digests of installed commands into a lockfile",
+	Run: utils.RunCobraCommandWith(core.CommandProviderDownload, func(cfg core.Configuration, mgr core.CommandManager) error {
+		logger := core.GetLogger()
+
+		downloadManager, ok := mgr.(*manager.DownloadManager)
+		if !ok {
+			return errors.Errorf("unexpected command manager %T", mgr)
+		}
+
+		query, err := mgr.Query()
+		if err != nil {
+			return err
+		}
+
+		commands, err := query.All()
+		if err != nil {
+			return err
+		}
+
+		current := lockfile.CurrentPlatform()
+		platforms := []string{current}
+		for _, platform := range cfg.GetStringSlice(core.CfgKeyXLockPlatforms) {
+			if platform != current {
+				platforms = append(platforms, platform)
+			}
+		}
+
+		lock := lockfile.New()
+		for _, command := range commands {
+			name, version := command.GetName(), command.GetVersion()
+
+			getter, ok := command.(core.CommandMetadataGetter)
+			// the commands installed by registries have no location
+			if !ok || getter.GetMetadata()[manager.MetadataKeyDownloadURI] == "" {
+				logger.Warn("command was not installed by cmdr install, skipped", map[string]interface{}{
+					"name":    name,
+					"version": version,
+				})
+				continue
+			}
+
+			metadata := getter.GetMetadata()
+			location := metadata[manager.MetadataKeyDownloadLocation]
+			recorded := lockArtifactOf(metadata)
+
+			for _, platform := range platforms {
+				artifact := recorded
+				if platform != current || artifact == nil {
+					goos, goarch, found := strings.Cut(platform, "/")
+					if !found {
+						return errors.Errorf("invalid platform %s, expected os/arch", platform)
+					}
+
+					artifact, err = downloadManager.Resolve(name, version, location, goos, goarch)
+					if err != nil {
+						logger.Warn("failed to resolve command", map[string]interface{}{
+							"name":     name,
+							"version":  version,
+							"platform": platform,
+							"error":    err,
+						})
+						continue
+					}
+				}
+
+				lock.Set(name, version, location, platform, artifact)
+			}
+		}
+
+		output := cfg.GetString(core.CfgKeyXLockOutput)
+		err = lock.Save(output)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("lockfile written", map[string]interface{}{
+			"output":   output,
+			"commands": len(lock.Commands),
+		})
+
+		return nil
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+
+	flags := lockCmd.Flags()
+	flags.StringP("output", "o", lockfile.DefaultPath, "lockfile path")
+	flags.StringSliceP("platform", "p", nil, "platforms to resolve besides the current one, like darwin/arm64")
+
+	cfg := core.GetConfiguration()
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXLockOutput, flags.Lookup("output")),
+		cfg.BindPFlag(core.CfgKeyXLockPlatforms, flags.Lookup("platform")),
+	)
+}
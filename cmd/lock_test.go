@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+)
+
+var _ = Describe("Lock", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(lockCmd, "output", "o", core.CfgKeyXLockOutput, "cmdr.lock", false)
+		testutils.CheckCommandFlag(lockCmd, "platform", "p", core.CfgKeyXLockPlatforms, "[]", false)
+	})
+})
@@ -0,0 +1,7 @@
+package cmd
+
+import "github.com/mrlyc/cmdr/cmd/registry"
+
+func init() {
+	rootCmd.AddCommand(registry.Cmd)
+}
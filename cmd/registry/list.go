@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"io"
+	"os"
+	"sort"
+
+	"github.com/mgutz/ansi"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/registry"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+func writeToolsTable(output io.Writer, tools map[string]*registry.Found) error {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tab := table.Table{
+		Headers: []string{"Name", "Registry", "Description"},
+	}
+
+	for _, name := range names {
+		found := tools[name]
+		tab.Rows = append(tab.Rows, []string{name, found.Registry, found.Tool.Description})
+	}
+
+	return tab.WriteTable(output, &table.Config{
+		Color:           true,
+		AlternateColors: true,
+		TitleColorCode:  ansi.ColorCode("white+buf"),
+	})
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tools of registries",
+	Run: func(cmd *cobra.Command, args []string) {
+		reg, err := registry.NewRegistryByConfiguration(core.GetConfiguration())
+		utils.ExitOnError("Failed to parse registries", err)
+
+		utils.ExitOnError("Failed to write tools", writeToolsTable(os.Stdout, reg.Tools()))
+	},
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+}
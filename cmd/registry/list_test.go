@@ -0,0 +1,25 @@
+package registry
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core/registry"
+)
+
+var _ = Describe("List", func() {
+	It("should write tools sorted by name", func() {
+		var output bytes.Buffer
+		Expect(writeToolsTable(&output, map[string]*registry.Found{
+			"yq": {Registry: "local", Tool: &registry.Tool{Description: "yaml processor"}},
+			"jq": {Registry: "official", Tool: &registry.Tool{Description: "json processor"}},
+		})).To(Succeed())
+
+		content := output.String()
+		Expect(content).To(ContainSubstring("json processor"))
+		Expect(content).To(ContainSubstring("official"))
+		Expect(bytes.Index(output.Bytes(), []byte("jq"))).To(BeNumerically("<", bytes.Index(output.Bytes(), []byte("yq"))))
+	})
+})
@@ -0,0 +1,8 @@
+package registry
+
+import "github.com/spf13/cobra"
+
+var Cmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage the registries of tools",
+}
@@ -0,0 +1,29 @@
+package registry
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/registry"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Refresh the caches of remote registries",
+	Run: func(cmd *cobra.Command, args []string) {
+		reg, err := registry.NewRegistryByConfiguration(core.GetConfiguration())
+		utils.ExitOnError("Failed to parse registries", err)
+
+		utils.ExitOnError("Failed to update registries", reg.Update())
+
+		core.GetLogger().Info("registries updated", map[string]interface{}{
+			"registries": len(reg.Sources()),
+		})
+	},
+}
+
+func init() {
+	Cmd.AddCommand(updateCmd)
+}
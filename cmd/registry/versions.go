@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/registry"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// versionsCmd represents the versions command
+var versionsCmd = &cobra.Command{
+	Use:   "versions <name>",
+	Short: "List the available versions of a tool, the latest comes first",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reg, err := registry.NewRegistryByConfiguration(core.GetConfiguration())
+		utils.ExitOnError("Failed to parse registries", err)
+
+		versions, err := reg.Versions(context.Background(), args[0], github.NewClient(nil).Repositories)
+		utils.ExitOnError("Failed to list versions", err)
+
+		for _, version := range versions {
+			fmt.Println(version)
+		}
+	},
+}
+
+func init() {
+	Cmd.AddCommand(versionsCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// relinkCmd represents the relink command
+var relinkCmd = &cobra.Command{
+	Use:   "relink",
+	Short: "Convert the existing shims to another link mode in place",
+	Run: utils.RunCobraCommandWith(core.CommandProviderDatabase, func(cfg core.Configuration, mgr core.CommandManager) error {
+		logger := core.GetLogger()
+
+		binaryMgr, err := manager.NewBinaryManagerWithLinkMode(
+			cfg.GetString(core.CfgKeyCmdrBinDir),
+			cfg.GetString(core.CfgKeyCmdrShimsDir),
+			0755,
+			cfg.GetString(core.CfgKeyXRelinkMode),
+		)
+		if err != nil {
+			return err
+		}
+
+		binaryMgr.SetStore(manager.NewBinaryStoreByConfiguration(cfg))
+
+		relinked, err := manager.NewCommandRelinker(binaryMgr, mgr).Relink()
+		for _, command := range relinked {
+			logger.Info("relinked", map[string]interface{}{
+				"name":      command.GetName(),
+				"version":   command.GetVersion(),
+				"link_mode": binaryMgr.LinkMode(),
+			})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		linkMode := cfg.GetString(core.CfgKeyCmdrLinkMode)
+		if linkMode == "" {
+			linkMode = manager.LinkModeDefault
+		}
+
+		if linkMode != binaryMgr.LinkMode() {
+			logger.Warn("the new commands are still linked by core.link_mode", map[string]interface{}{
+				"link_mode": linkMode,
+			})
+		}
+
+		return nil
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(relinkCmd)
+
+	flags := relinkCmd.Flags()
+	flags.String("mode", "", "link mode, one of default, link, hardlink and reflink")
+
+	cfg := core.GetConfiguration()
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXRelinkMode, flags.Lookup("mode")),
+		relinkCmd.MarkFlagRequired("mode"),
+	)
+}
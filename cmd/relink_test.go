@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+)
+
+var _ = Describe("Relink", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(relinkCmd, "mode", "", core.CfgKeyXRelinkMode, "", true)
+	})
+})
@@ -13,6 +13,7 @@ import (
 	"logur.dev/logur"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/hook"
 	"github.com/mrlyc/cmdr/core/utils"
 )
 
@@ -34,7 +35,7 @@ func ExecuteContext(ctx context.Context) {
 }
 
 func init() {
-	cobra.OnInitialize(preInitConfig, initConfig, postInitConfig, initLogger, initDatabase, initProxy)
+	cobra.OnInitialize(preInitConfig, initConfig, postInitConfig, initLogger, initDatabase, initProxy, initHooks)
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
@@ -47,8 +48,12 @@ func init() {
 		filepath.Join(getDefaultCmdrRoot(), "config.yaml"),
 		"config file",
 	)
+	pFlags.Bool("offline", false, "never access the network, use local caches and paths only")
 
-	utils.PanicOnError("binding flags", cfg.BindPFlag(core.CfgKeyCmdrConfigPath, pFlags.Lookup("config")))
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyCmdrConfigPath, pFlags.Lookup("config")),
+		cfg.BindPFlag(core.CfgKeyCmdrOffline, pFlags.Lookup("offline")),
+	)
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -75,10 +80,16 @@ func preInitConfig() {
 	cfg.SetDefault(core.CfgKeyCmdrShimsDir, "shims")
 	cfg.SetDefault(core.CfgKeyCmdrProfileDir, "profile")
 	cfg.SetDefault(core.CfgKeyCmdrDatabasePath, "cmdr.db")
+	cfg.SetDefault(core.CfgKeyCmdrStagingDir, "staging")
+	cfg.SetDefault(core.CfgKeyCmdrStagingTTL, "168h")
+	cfg.SetDefault(core.CfgKeyCmdrLogDir, "logs")
+	cfg.SetDefault(core.CfgKeyCmdrRegistryDir, "registries")
 
 	cfg.SetDefault(core.CfgKeyLogLevel, "info")
 	cfg.SetDefault(core.CfgKeyLogOutput, "stderr")
 
+	cfg.SetDefault(core.CfgKeyUpgradeKeep, 3)
+
 	for _, key := range []string{
 		core.CfgKeyProxyGo,
 		core.CfgKeyProxyHTTP,
@@ -111,12 +122,21 @@ func postInitConfig() {
 		core.CfgKeyCmdrShimsDir,
 		core.CfgKeyCmdrProfileDir,
 		core.CfgKeyCmdrDatabasePath,
+		core.CfgKeyCmdrStagingDir,
+		core.CfgKeyCmdrLogDir,
+		core.CfgKeyCmdrRegistryDir,
+		core.CfgKeyCmdrStoreDir,
 	} {
 		path := cfg.GetString(key)
 		if filepath.IsAbs(path) {
 			continue
 		}
 
+		// the store is opt-in, an empty store dir disables it
+		if key == core.CfgKeyCmdrStoreDir && path == "" {
+			continue
+		}
+
 		value := filepath.Join(rootDir, path)
 
 		if cfg.IsSet(key) {
@@ -177,3 +197,12 @@ func initProxy() {
 		}
 	}
 }
+
+func initHooks() {
+	runner, err := hook.NewRunnerByConfiguration(core.GetConfiguration())
+	if err != nil {
+		utils.ExitOnError("Failed to parse hooks", err)
+	}
+
+	runner.Subscribe()
+}
@@ -0,0 +1,7 @@
+package cmd
+
+import "github.com/mrlyc/cmdr/cmd/store"
+
+func init() {
+	rootCmd.AddCommand(store.Cmd)
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// gcCmd represents the gc command
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove the blobs which are not referenced by any shim",
+	Run: runWithStore(func(cfg core.Configuration, mgr *manager.BinaryManager) error {
+		logger := core.GetLogger()
+		dryRun := cfg.GetBool(core.CfgKeyXStoreGCDryRun)
+
+		removed, err := mgr.CollectGarbage(dryRun)
+		for _, digest := range removed {
+			logger.Info("blob removed", map[string]interface{}{
+				"digest":  digest,
+				"dry_run": dryRun,
+			})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		logger.Info("store collected", map[string]interface{}{
+			"removed": len(removed),
+			"dry_run": dryRun,
+		})
+
+		return nil
+	}),
+}
+
+func init() {
+	Cmd.AddCommand(gcCmd)
+
+	flags := gcCmd.Flags()
+	flags.Bool("dry-run", false, "show the blobs to remove without removing them")
+
+	cfg := core.GetConfiguration()
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXStoreGCDryRun, flags.Lookup("dry-run")),
+	)
+}
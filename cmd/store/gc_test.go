@@ -0,0 +1,14 @@
+package store
+
+import (
+	. "github.com/onsi/ginkgo"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+)
+
+var _ = Describe("GC", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(gcCmd, "dry-run", "", core.CfgKeyXStoreGCDryRun, "false", false)
+	})
+})
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move the binaries of the shims which are files into the store",
+	Run: runWithStore(func(cfg core.Configuration, mgr *manager.BinaryManager) error {
+		err := mgr.GetStore().Init()
+		if err != nil {
+			return err
+		}
+
+		return mgr.MigrateToStore()
+	}),
+}
+
+func init() {
+	Cmd.AddCommand(migrateCmd)
+}
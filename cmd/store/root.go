@@ -0,0 +1,31 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "store",
+	Short: "Manage the content-addressed store of binaries",
+}
+
+// runWithStore runs fn with the binary manager which keeps the binaries in the store
+func runWithStore(fn func(cfg core.Configuration, mgr *manager.BinaryManager) error) func(cmd *cobra.Command, args []string) {
+	return utils.RunCobraCommandWith(core.CommandProviderBinary, func(cfg core.Configuration, mgr core.CommandManager) error {
+		binaryMgr, ok := mgr.(*manager.BinaryManager)
+		if !ok {
+			return errors.Errorf("unexpected command manager %T", mgr)
+		}
+
+		if binaryMgr.GetStore() == nil {
+			return errors.Wrapf(manager.ErrStoreDisabled, "%s is empty", core.CfgKeyCmdrStoreDir)
+		}
+
+		return fn(cfg, binaryMgr)
+	})
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo [N]",
+	Short: "Revert the last N activation changes and cleaned versions",
+	Args:  cobra.MaximumNArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		cfg := core.GetConfiguration()
+		cfg.Set(core.CfgKeyXUndoCount, 1)
+
+		if len(args) > 0 {
+			count, err := strconv.Atoi(args[0])
+			if err != nil || count <= 0 {
+				utils.ExitOnError("Failed to parse N", errors.Errorf("N must be a positive number, got %s", args[0]))
+			}
+
+			cfg.Set(core.CfgKeyXUndoCount, count)
+		}
+	},
+	Run: utils.RunCobraCommandWith(core.CommandProviderDatabase, func(cfg core.Configuration, mgr core.CommandManager) error {
+		logger := core.GetLogger()
+
+		store, err := historyStoreOf(mgr)
+		if err != nil {
+			return err
+		}
+
+		count := cfg.GetInt(core.CfgKeyXUndoCount)
+		undone, err := manager.NewCommandUndoer(mgr, store).Undo(count)
+		for _, history := range undone {
+			logger.Info("undone", map[string]interface{}{
+				"id":        history.ID,
+				"operation": history.Operation,
+				"name":      history.Name,
+				"version":   history.Version,
+				"previous":  history.Previous,
+			})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(undone) < count {
+			logger.Warn("nothing more to undo", map[string]interface{}{
+				"undone": len(undone),
+			})
+		}
+
+		return nil
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
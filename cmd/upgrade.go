@@ -10,7 +10,7 @@ import (
 
 // upgradeCmd represents the upgrade command
 var upgradeCmd = &cobra.Command{
-	Use:   "upgrade",
+	Use:   "upgrade [--rollback [version]]",
 	Short: "upgrade cmdr",
 	PreRun: func(cmd *cobra.Command, args []string) {
 		cfg := core.GetConfiguration()
@@ -20,6 +20,20 @@ var upgradeCmd = &cobra.Command{
 		logger := core.GetLogger()
 		ctx := cmd.Context()
 		cfg := core.GetConfiguration()
+
+		if cfg.GetBool(core.CfgKeyXUpgradeRollback) {
+			version := ""
+			if len(args) > 0 {
+				version, args = args[0], args[1:]
+			}
+
+			err := utils.RollbackCmdr(ctx, cfg, version, append(cfg.GetStringSlice(core.CfgKeyXUpgradeArgs), args...))
+			utils.ExitOnError("rollback cmdr failed", err)
+			logger.Info("rollback cmdr success")
+
+			return
+		}
+
 		releaseName := cfg.GetString(core.CfgKeyXUpgradeRelease)
 		assetName := cfg.GetString(core.CfgKeyXUpgradeAsset)
 		upgradeArgs := append(cfg.GetStringSlice(core.CfgKeyXUpgradeArgs), args...)
@@ -33,7 +47,17 @@ var upgradeCmd = &cobra.Command{
 		info, err := searcher.GetReleaseAsset(ctx, releaseName, assetName)
 		utils.ExitOnError("get latest asset url failed", err)
 
-		err = utils.UpgradeCmdr(ctx, cfg, info.Url, info.Version, upgradeArgs)
+		var verifier *utils.CmdrReleaseVerifier
+		if cfg.GetBool(core.CfgKeyXUpgradeSkipVerify) {
+			logger.Warn("!!! SKIPPING RELEASE VERIFICATION, the downloaded cmdr will be executed without checking its checksum and signature !!!", map[string]interface{}{
+				"url": info.Url,
+			})
+		} else {
+			verifier, err = utils.NewCmdrReleaseVerifierByConfiguration(cfg)
+			utils.ExitOnError("load release verifier failed", err)
+		}
+
+		err = utils.UpgradeCmdr(ctx, cfg, verifier, info.Url, info.Version, upgradeArgs)
 		switch errors.Cause(err) {
 		case nil:
 			logger.Info("upgrade cmdr success")
@@ -60,9 +84,13 @@ func init() {
 	flags := upgradeCmd.Flags()
 	flags.StringP("release", "r", "latest", "cmdr release tag name")
 	flags.StringP("asset", "a", core.Asset, "cmdr release assert name")
+	flags.Bool("skip-verify", false, "skip verifying the checksums and the signature of the release, dangerous")
+	flags.Bool("rollback", false, "activate a kept version, the previous one by default")
 
 	utils.PanicOnError("binding flags",
 		cfg.BindPFlag(core.CfgKeyXUpgradeRelease, flags.Lookup("release")),
 		cfg.BindPFlag(core.CfgKeyXUpgradeAsset, flags.Lookup("asset")),
+		cfg.BindPFlag(core.CfgKeyXUpgradeSkipVerify, flags.Lookup("skip-verify")),
+		cfg.BindPFlag(core.CfgKeyXUpgradeRollback, flags.Lookup("rollback")),
 	)
 }
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/mgutz/ansi"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tomlazar/table"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+func writeVerifyTable(output io.Writer, results []*manager.VerifyResult) error {
+	tab := table.Table{
+		Headers: []string{"Name", "Version", "Status", "Expected", "Actual", "Source", "Recorded"},
+	}
+
+	for _, result := range results {
+		recorded := ""
+		if result.Recorded != result.Source {
+			recorded = result.Recorded
+		}
+
+		tab.Rows = append(tab.Rows, []string{
+			result.Name,
+			result.Version,
+			result.Status,
+			result.Expected,
+			result.Actual,
+			result.Source,
+			recorded,
+		})
+	}
+
+	return tab.WriteTable(output, &table.Config{
+		Color:           true,
+		AlternateColors: true,
+		TitleColorCode:  ansi.ColorCode("white+buf"),
+	})
+}
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the installed binaries by the digests recorded when they are installed",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		cfg := core.GetConfiguration()
+		cfg.Set(core.CfgKeyCmdrLinkMode, manager.InstallLinkMode(cfg.GetString(core.CfgKeyCmdrLinkMode)))
+	},
+	Run: utils.RunCobraCommandWith(core.CommandProviderDownload, func(cfg core.Configuration, mgr core.CommandManager) error {
+		mainMgr, err := core.NewCommandManager(core.CommandProviderBinary, cfg)
+		if err != nil {
+			return err
+		}
+
+		binaryMgr, ok := mainMgr.(*manager.BinaryManager)
+		if !ok {
+			return errors.Errorf("unexpected command manager %T", mainMgr)
+		}
+
+		verifier := manager.NewCommandVerifier(binaryMgr, mgr)
+		results, err := verifier.Verify()
+		if err != nil {
+			return err
+		}
+
+		err = writeVerifyTable(os.Stdout, results)
+		if err != nil {
+			return err
+		}
+
+		var broken error
+		for _, result := range results {
+			if result.Broken() {
+				broken = multierror.Append(broken, errors.Wrapf(manager.ErrBinaryBroken, "%s(%s) is %s", result.Name, result.Version, result.Status))
+			}
+		}
+
+		if broken == nil || !cfg.GetBool(core.CfgKeyXVerifyFix) {
+			return broken
+		}
+
+		fixed, err := verifier.Fix(results, mgr)
+		for _, result := range fixed {
+			core.GetLogger().Info("binary reinstalled", map[string]interface{}{
+				"name":    result.Name,
+				"version": result.Version,
+			})
+		}
+
+		return err
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	flags := verifyCmd.Flags()
+	flags.Bool("fix", false, "reinstall the broken versions from their recorded locations")
+
+	cfg := core.GetConfiguration()
+	utils.PanicOnError("binding flags",
+		cfg.BindPFlag(core.CfgKeyXVerifyFix, flags.Lookup("fix")),
+	)
+}
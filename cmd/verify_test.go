@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/cmd/internal/testutils"
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+var _ = Describe("Verify", func() {
+	It("should check flags", func() {
+		testutils.CheckCommandFlag(verifyCmd, "fix", "", core.CfgKeyXVerifyFix, "false", false)
+	})
+
+	It("should write the results", func() {
+		var output bytes.Buffer
+		Expect(writeVerifyTable(&output, []*manager.VerifyResult{
+			{Name: "node", Version: "18.17.0", Status: manager.VerifyStatusOK, Source: "/store/sha256/abc", Recorded: "/store/sha256/abc"},
+			{Name: "go", Version: "1.21.0", Status: manager.VerifyStatusReplaced, Source: "/usr/local/go/bin/go", Recorded: "/opt/go/bin/go"},
+		})).To(Succeed())
+
+		Expect(output.String()).To(ContainSubstring(manager.VerifyStatusReplaced))
+		Expect(output.String()).To(ContainSubstring("/opt/go/bin/go"))
+		Expect(bytes.Count(output.Bytes(), []byte("/store/sha256/abc"))).To(Equal(1))
+	})
+})
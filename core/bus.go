@@ -1,20 +1,83 @@
 package core
 
-import bus "github.com/asaskevich/EventBus"
+import (
+	"time"
+
+	bus "github.com/asaskevich/EventBus"
+)
 
 const (
 	EventExit = "exit"
+
+	// the events of commands, the payload is *CommandEvent, the pre ones are aborted by CommandEvent.Abort
+	EventPreInstall     = "pre-install"
+	EventPostInstall    = "post-install"
+	EventPreActivate    = "pre-activate"
+	EventPostActivate   = "post-activate"
+	EventPreDeactivate  = "pre-deactivate"
+	EventPostDeactivate = "post-deactivate"
+	EventPreRemove      = "pre-remove"
+	EventPostRemove     = "post-remove"
+	EventPreClean       = "pre-clean"
+	EventPostClean      = "post-clean"
 )
 
+// CommandEvents are the events published around the changes of commands
+var CommandEvents = []string{
+	EventPreInstall, EventPostInstall,
+	EventPreActivate, EventPostActivate,
+	EventPreDeactivate, EventPostDeactivate,
+	EventPreRemove, EventPostRemove,
+	EventPreClean, EventPostClean,
+}
+
 type EventBus = bus.Bus
 type BusSubscriber = bus.BusSubscriber
 
 var eventBus EventBus
 
+// CommandEvent is the payload of command events, the version is empty for deactivate events
+type CommandEvent struct {
+	Event    string    `json:"event"`
+	Name     string    `json:"name"`
+	Version  string    `json:"version,omitempty"`
+	Location string    `json:"location,omitempty"`
+	Time     time.Time `json:"time"`
+
+	err error
+}
+
+// Abort makes the publisher of a pre event give up, the first error wins
+func (e *CommandEvent) Abort(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *CommandEvent) Err() error {
+	return e.err
+}
+
 func PublishEvent(topic string, args ...interface{}) {
 	eventBus.Publish(topic, args...)
 }
 
+// PublishCommandEvent publishes a command event, the handlers are called synchronously
+// and the error which aborts the event is returned
+func PublishCommandEvent(topic, name, version, location string) error {
+	event := &CommandEvent{
+		Event:    topic,
+		Name:     name,
+		Version:  version,
+		Location: location,
+		Time:     time.Now(),
+	}
+
+	PublishEvent(topic, event)
+
+	return event.Err()
+}
+
 func SubscribeEvent(topic string, fn interface{}) {
 	err := eventBus.Subscribe(topic, fn)
 	if err != nil {
@@ -29,6 +92,11 @@ func SubscribeEventOnce(topic string, fn interface{}) {
 	}
 }
 
+func UnsubscribeEvent(topic string, fn interface{}) {
+	// the handler may not be subscribed, which is fine
+	_ = eventBus.Unsubscribe(topic, fn)
+}
+
 func init() {
 	eventBus = bus.New()
 }
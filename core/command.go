@@ -46,7 +46,34 @@ type CommandManager interface {
 	Deactivate(name string) error
 }
 
-//go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock Command,CommandQuery,CommandManager
+// CommandMetadataSetter is implemented by managers which keep records of commands
+type CommandMetadataSetter interface {
+	SetMetadata(name string, version string, metadata map[string]string) error
+}
+
+// CommandMetadataGetter is implemented by commands which keep the metadata
+type CommandMetadataGetter interface {
+	GetMetadata() map[string]string
+}
+
+// the operations recorded in the history
+const (
+	HistoryOperationDefine     = "define"
+	HistoryOperationUndefine   = "undefine"
+	HistoryOperationActivate   = "activate"
+	HistoryOperationDeactivate = "deactivate"
+	HistoryOperationClean      = "clean"
+)
+
+// CommandHistoryRecorder is implemented by managers which keep the history of commands
+type CommandHistoryRecorder interface {
+	// SetHistoryCause marks the following records with the cause, like clean, doctor or undo
+	SetHistoryCause(cause string)
+	// RecordHistory records the operations which the manager does not know, like moving a version to the trash
+	RecordHistory(operation, name, version, location, previous string) error
+}
+
+//go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock Command,CommandQuery,CommandManager,CommandMetadataSetter,CommandMetadataGetter,CommandHistoryRecorder
 
 var (
 	ErrCommandManagerFactoryeNotFound = fmt.Errorf("command manager factory not found")
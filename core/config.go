@@ -31,6 +31,12 @@ const (
 	CfgKeyCmdrShell        = "core.shell"
 	CfgKeyCmdrConfigPath   = "core.config_path"
 	CfgKeyCmdrLinkMode     = "core.link_mode"
+	CfgKeyCmdrStagingDir   = "core.staging_dir"
+	CfgKeyCmdrStagingTTL   = "core.staging_ttl"
+	CfgKeyCmdrOffline      = "core.offline"
+	CfgKeyCmdrLogDir       = "core.log_dir"
+	CfgKeyCmdrRegistryDir  = "core.registry_dir"
+	CfgKeyCmdrStoreDir     = "core.store_dir"
 
 	// proxy
 	CfgKeyProxyGo    = "proxy.go"
@@ -41,8 +47,27 @@ const (
 	CfgKeyLogLevel  = "log.level"
 	CfgKeyLogOutput = "log.output"
 
+	// version files
+	CfgKeyVersionFiles = "version_files"
+
+	// registries
+	CfgKeyRegistries = "registries"
+
+	// hooks
+	CfgKeyHooks = "hooks"
+
+	// wrappers
+	CfgKeyWrappers = "wrappers"
+
+	// signatures
+	CfgKeySignatures = "signatures"
+
+	// upgrade
+	CfgKeyUpgradeKeep = "upgrade.keep"
+
 	// download
 	CfgKeyDownloadReplace = "download.replace"
+	CfgKeyDownloadRules   = "download.rules"
 
 	// download.strategies
 	CfgKeyDownloadDirectTimeout    = "download.direct.timeout"
@@ -54,18 +79,52 @@ const (
 	CfgKeyDownloadProxyTimeout    = "download.proxy.timeout"
 	CfgKeyDownloadProxyMaxRetries = "download.proxy.max_retries"
 
-	CfgKeyDownloadRewriteRule = "download.rewrite.rule"
+	CfgKeyDownloadRewriteRule              = "download.rewrite.rule"
+	CfgKeyDownloadRewriteConditionSchemes  = "download.rewrite.condition.schemes"
+	CfgKeyDownloadRewriteConditionHosts    = "download.rewrite.condition.hosts"
+	CfgKeyDownloadRewriteConditionPatterns = "download.rewrite.condition.patterns"
+
+	CfgKeyDownloadOCIInsecureRegistries = "download.oci.insecure_registries"
+
+	CfgKeyDownloadRaceEnabled     = "download.race.enabled"
+	CfgKeyDownloadRaceConcurrency = "download.race.concurrency"
+	CfgKeyDownloadRaceProbeBytes  = "download.race.probe_bytes"
+	CfgKeyDownloadRaceTimeout     = "download.race.timeout"
 
 	// cmd.command.define
 	CfgKeyXCommandDefineName     = "_.command.define.name"
 	CfgKeyXCommandDefineVersion  = "_.command.define.version"
 	CfgKeyXCommandDefineLocation = "_.command.define.location"
 	CfgKeyXCommandDefineActivate = "_.command.define.activate"
+	CfgKeyXCommandDefineEnv      = "_.command.define.env"
+	CfgKeyXCommandDefineArgs     = "_.command.define.args"
 	// cmd.command.install
 	CfgKeyXCommandInstallName     = "_.command.install.name"
 	CfgKeyXCommandInstallVersion  = "_.command.install.version"
 	CfgKeyXCommandInstallLocation = "_.command.install.location"
 	CfgKeyXCommandInstallActivate = "_.command.install.activate"
+	CfgKeyXCommandInstallRecipe   = "_.command.install.recipe"
+	CfgKeyXCommandInstallFrozen   = "_.command.install.frozen"
+	CfgKeyXCommandInstallLockfile = "_.command.install.lockfile"
+	CfgKeyXCommandInstallEnv      = "_.command.install.env"
+	CfgKeyXCommandInstallArgs     = "_.command.install.args"
+
+	CfgKeyXCommandInstallSignature    = "_.command.install.signature"
+	CfgKeyXCommandInstallSignatureKey = "_.command.install.signature_key"
+
+	CfgKeyXCommandInstallBuildCommands = "_.command.install.build.commands"
+	CfgKeyXCommandInstallBuildEnv      = "_.command.install.build.env"
+	CfgKeyXCommandInstallBuildOutput   = "_.command.install.build.output"
+	CfgKeyXCommandInstallBuildTimeout  = "_.command.install.build.timeout"
+
+	CfgKeyXCommandInstallGoVersion     = "_.command.install.go.version"
+	CfgKeyXCommandInstallGoFlags       = "_.command.install.go.flags"
+	CfgKeyXCommandInstallGoCGOEnabled  = "_.command.install.go.cgo_enabled"
+	CfgKeyXCommandInstallGoTags        = "_.command.install.go.tags"
+	CfgKeyXCommandInstallGoLDFlags     = "_.command.install.go.ldflags"
+	CfgKeyXCommandInstallGoPrivate     = "_.command.install.go.private"
+	CfgKeyXCommandInstallGoIsolated    = "_.command.install.go.isolated"
+	CfgKeyXCommandInstallGoAllowLatest = "_.command.install.go.allow_latest"
 	// cmd.command.list
 	CfgKeyXCommandListName     = "_.command.list.name"
 	CfgKeyXCommandListVersion  = "_.command.list.version"
@@ -80,6 +139,9 @@ const (
 	// cmd.command.use
 	CfgKeyXCommandUseName    = "_.command.use.name"
 	CfgKeyXCommandUseVersion = "_.command.use.version"
+	// cmd.command.env
+	CfgKeyXCommandEnvName    = "_.command.env.name"
+	CfgKeyXCommandEnvVersion = "_.command.env.version"
 
 	// cmd.config.get
 	CfgKeyXConfigGetKey = "_.config.get.key"
@@ -87,18 +149,67 @@ const (
 	CfgKeyXConfigSetKey   = "_.config.set.key"
 	CfgKeyXConfigSetValue = "_.config.set.value"
 
+	// cmd.download.diagnose
+	CfgKeyXDownloadDiagnoseOutput  = "_.download.diagnose.output"
+	CfgKeyXDownloadDiagnoseTimeout = "_.download.diagnose.timeout"
+	// cmd.download.rewrite
+	CfgKeyXDownloadRewriteExplain = "_.download.rewrite.explain"
+	CfgKeyXDownloadRewriteName    = "_.download.rewrite.name"
+	CfgKeyXDownloadRewriteVersion = "_.download.rewrite.version"
+	CfgKeyXDownloadRewriteOutput  = "_.download.rewrite.output"
+	// cmd.download.assets
+	CfgKeyXDownloadAssetsName    = "_.download.assets.name"
+	CfgKeyXDownloadAssetsVersion = "_.download.assets.version"
+	CfgKeyXDownloadAssetsOS      = "_.download.assets.os"
+	CfgKeyXDownloadAssetsArch    = "_.download.assets.arch"
+	CfgKeyXDownloadAssetsLibc    = "_.download.assets.libc"
+
 	// cmd.init
 	CfgKeyXInitUpgrade = "_.init.upgrade"
 
 	// cmd.upgrade
-	CfgKeyXUpgradeRelease = "_.upgrade.release"
-	CfgKeyXUpgradeAsset   = "_.upgrade.asset"
-	CfgKeyXUpgradeArgs    = "_.upgrade.args"
+	CfgKeyXUpgradeRelease    = "_.upgrade.release"
+	CfgKeyXUpgradeAsset      = "_.upgrade.asset"
+	CfgKeyXUpgradeArgs       = "_.upgrade.args"
+	CfgKeyXUpgradeSkipVerify = "_.upgrade.skip_verify"
+	CfgKeyXUpgradeRollback   = "_.upgrade.rollback"
 
 	// cmd.clean
 	CfgKeyXCleanAgeDays = "_.clean.age_days"
 	CfgKeyXCleanKeep    = "_.clean.keep"
 	CfgKeyXCleanName    = "_.clean.name"
+
+	// cmd.current
+	CfgKeyXCurrentName = "_.current.name"
+
+	// cmd.history
+	CfgKeyXHistoryName = "_.history.name"
+
+	// cmd.undo
+	CfgKeyXUndoCount = "_.undo.count"
+
+	// cmd.env
+	CfgKeyXEnvName     = "_.env.name"
+	CfgKeyXEnvCompared = "_.env.compared"
+
+	// cmd.import
+	CfgKeyXImportTool     = "_.import.tool"
+	CfgKeyXImportDryRun   = "_.import.dry_run"
+	CfgKeyXImportDir      = "_.import.dir"
+	CfgKeyXImportActivate = "_.import.activate"
+
+	// cmd.lock
+	CfgKeyXLockOutput    = "_.lock.output"
+	CfgKeyXLockPlatforms = "_.lock.platforms"
+
+	// cmd.relink
+	CfgKeyXRelinkMode = "_.relink.mode"
+
+	// cmd.store.gc
+	CfgKeyXStoreGCDryRun = "_.store.gc.dry_run"
+
+	// cmd.verify
+	CfgKeyXVerifyFix = "_.verify.fix"
 )
 
 func init() {
@@ -20,6 +20,8 @@ type ModelType int
 const (
 	ModelTypeUnknown ModelType = iota
 	ModelTypeCommand
+	ModelTypeHistory
+	ModelTypeEnvironment
 )
 
 var databaseModels map[ModelType]interface{}
@@ -27,4 +27,6 @@ var (
 	ErrShellNotSupported       = fmt.Errorf("shell not supported")
 	ErrBinaryNotFound          = fmt.Errorf("binaries not found")
 	ErrReleaseAssetNotFound    = fmt.Errorf("release asset not found")
+	ErrOffline                 = fmt.Errorf("network access is disabled in offline mode")
+	ErrPlatformNotSupported    = fmt.Errorf("platform not supported")
 )
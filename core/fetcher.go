@@ -1,8 +1,14 @@
 package core
 
-//go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock Fetcher
+//go:generate mockgen -source=$GOFILE -destination=mock/$GOFILE -package=mock Fetcher,MetadataFetcher
 
 type Fetcher interface {
 	IsSupport(uri string) bool
 	Fetch(name, version, uri, dir string) error
 }
+
+// MetadataFetcher is implemented by fetchers which know more about what they fetched, like the module sum
+type MetadataFetcher interface {
+	Fetcher
+	GetMetadata(name, version string) map[string]string
+}
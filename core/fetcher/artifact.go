@@ -0,0 +1,99 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+type artifactRecorderKey struct{}
+
+// Artifact is a file downloaded by a getter, before it is extracted
+type Artifact struct {
+	URL    string
+	SHA256 string
+	// Path is the copy of the file kept by the recorder, it is empty when not kept
+	Path string
+}
+
+// ArtifactRecorder collects the artifacts downloaded with a context
+type ArtifactRecorder struct {
+	lock      sync.Mutex
+	artifacts []Artifact
+	keepDir   string
+}
+
+// SetKeepDir keeps the copies of the artifacts in dir, which outlive the extraction
+func (r *ArtifactRecorder) SetKeepDir(dir string) {
+	r.keepDir = dir
+}
+
+// keep copies the artifact into the keep dir, the file may be the binary which must keep its mode
+func (r *ArtifactRecorder) keep(path string) (string, error) {
+	r.lock.Lock()
+	name := fmt.Sprintf("artifact-%d", len(r.artifacts))
+	r.lock.Unlock()
+
+	helper := utils.NewPathHelper(r.keepDir)
+	err := helper.CopyFile(name, path, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	return helper.Child(name).Path(), nil
+}
+
+func (r *ArtifactRecorder) Add(artifact Artifact) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.artifacts = append(r.artifacts, artifact)
+}
+
+func (r *ArtifactRecorder) Artifacts() []Artifact {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]Artifact{}, r.artifacts...)
+}
+
+func NewArtifactRecorder() *ArtifactRecorder {
+	return &ArtifactRecorder{}
+}
+
+func WithArtifactRecorder(ctx context.Context, recorder *ArtifactRecorder) context.Context {
+	return context.WithValue(ctx, artifactRecorderKey{}, recorder)
+}
+
+// RecordArtifact records the digest of path when there is a recorder in the context
+func RecordArtifact(ctx context.Context, url, path string) {
+	recorder, ok := ctx.Value(artifactRecorderKey{}).(*ArtifactRecorder)
+	if !ok {
+		return
+	}
+
+	digest, err := utils.SHA256File(path)
+	if err != nil {
+		core.GetLogger().Warn("failed to digest artifact", map[string]interface{}{
+			"url":   url,
+			"error": err,
+		})
+		return
+	}
+
+	artifact := Artifact{URL: url, SHA256: digest}
+	if recorder.keepDir != "" {
+		artifact.Path, err = recorder.keep(path)
+		if err != nil {
+			core.GetLogger().Warn("failed to keep artifact", map[string]interface{}{
+				"url":   url,
+				"error": err,
+			})
+		}
+	}
+
+	recorder.Add(artifact)
+}
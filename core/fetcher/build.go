@@ -0,0 +1,257 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var (
+	ErrRecipeRequired   = errors.New("build recipe required")
+	ErrInvalidGitSource = errors.New("invalid git source")
+)
+
+// buildEnvAllowlist is the only part of the host environment visible to recipes
+var buildEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LANG", "LC_ALL", "TERM",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+}
+
+// Recipe describes how to build a command from source
+type Recipe struct {
+	// Commands run one by one by sh in the source dir
+	Commands []string `yaml:"commands"`
+	// Env is added to the build environment
+	Env map[string]string `yaml:"env"`
+	// Output is the path of the binary relative to the source dir
+	Output string `yaml:"output"`
+	// Timeout of the whole build in seconds, including the clone
+	Timeout int `yaml:"timeout"`
+}
+
+func (r *Recipe) Validate() error {
+	if r == nil || len(r.Commands) == 0 {
+		return errors.Wrapf(ErrRecipeRequired, "no build command")
+	}
+
+	if r.Output == "" {
+		return errors.Wrapf(ErrRecipeRequired, "no build output")
+	}
+
+	return nil
+}
+
+// LoadRecipe loads a recipe from a yaml file
+func LoadRecipe(path string) (*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read recipe %s failed", path)
+	}
+
+	recipe := &Recipe{}
+	err = yaml.Unmarshal(data, recipe)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse recipe %s failed", path)
+	}
+
+	return recipe, nil
+}
+
+// GitBuilder clones git+<url>#<ref> into the staging dir and builds it by the recipe
+type GitBuilder struct {
+	gitPath    string
+	scheme     string
+	stagingDir string
+	logDir     string
+	recipe     *Recipe
+	timeout    time.Duration
+	offline    bool
+}
+
+func (b *GitBuilder) SetRecipe(recipe *Recipe) {
+	b.recipe = recipe
+}
+
+// SetOffline only allows to clone local repositories
+func (b *GitBuilder) SetOffline(offline bool) {
+	b.offline = offline
+}
+
+func (b *GitBuilder) IsSupport(uri string) bool {
+	return strings.HasPrefix(uri, b.scheme)
+}
+
+func (b *GitBuilder) getEnv(name, version, sourceDir, outputDir string) []string {
+	envs := []string{
+		fmt.Sprintf("CMDR_BUILD_NAME=%s", name),
+		fmt.Sprintf("CMDR_BUILD_VERSION=%s", version),
+		fmt.Sprintf("CMDR_BUILD_SOURCE_DIR=%s", sourceDir),
+		fmt.Sprintf("CMDR_BUILD_OUTPUT_DIR=%s", outputDir),
+	}
+
+	for _, key := range buildEnvAllowlist {
+		value, ok := os.LookupEnv(key)
+		if ok {
+			envs = append(envs, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+
+	keys := make([]string, 0, len(b.recipe.Env))
+	for key := range b.recipe.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// the later ones take precedence
+	for _, key := range keys {
+		envs = append(envs, fmt.Sprintf("%s=%s", key, b.recipe.Env[key]))
+	}
+
+	return envs
+}
+
+func (b *GitBuilder) run(ctx context.Context, log io.Writer, dir string, envs []string, name string, args ...string) error {
+	_, _ = fmt.Fprintf(log, "$ %s %s\n", name, strings.Join(args, " "))
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = envs
+	cmd.Stdout = log
+	cmd.Stderr = log
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return errors.Wrapf(ctx.Err(), "run %s timeout", name)
+	}
+
+	return err
+}
+
+func (b *GitBuilder) clone(ctx context.Context, log io.Writer, uri, sourceDir string) error {
+	repository, ref, _ := strings.Cut(strings.TrimPrefix(uri, b.scheme), "#")
+
+	// the location may come from a remote registry, git must not take them as options
+	if strings.HasPrefix(repository, "-") || strings.HasPrefix(ref, "-") {
+		return errors.Wrapf(ErrInvalidGitSource, "%s", uri)
+	}
+
+	if b.offline && !strings.HasPrefix(repository, "file://") && !filepath.IsAbs(repository) {
+		return errors.Wrapf(core.ErrOffline, "clone %s", repository)
+	}
+
+	envs := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	err := b.run(ctx, log, "", envs, b.gitPath, "clone", "--quiet", "--", repository, sourceDir)
+	if err != nil {
+		return errors.Wrapf(err, "clone %s failed", repository)
+	}
+
+	if ref == "" {
+		return nil
+	}
+
+	// the trailing -- makes git take the ref as a revision rather than a path
+	err = b.run(ctx, log, sourceDir, envs, b.gitPath, "checkout", "--quiet", ref, "--")
+	if err != nil {
+		return errors.Wrapf(err, "checkout %s failed", ref)
+	}
+
+	return nil
+}
+
+func (b *GitBuilder) build(ctx context.Context, log io.Writer, name, version, uri, workDir, dst string) error {
+	sourceDir := filepath.Join(workDir, "source")
+	err := b.clone(ctx, log, uri, sourceDir)
+	if err != nil {
+		return err
+	}
+
+	envs := b.getEnv(name, version, sourceDir, dst)
+	for _, command := range b.recipe.Commands {
+		err = b.run(ctx, log, sourceDir, envs, "sh", "-c", command)
+		if err != nil {
+			return errors.Wrapf(err, "build command %q failed", command)
+		}
+	}
+
+	output := filepath.Join(sourceDir, filepath.FromSlash(b.recipe.Output))
+	info, err := os.Stat(output)
+	if err != nil {
+		return errors.Wrapf(err, "build output %s not found", b.recipe.Output)
+	}
+
+	// named after the command, so the download manager picks it
+	return utils.NewPathHelper(dst).CopyFile(name, output, info.Mode().Perm()|0755)
+}
+
+func (b *GitBuilder) Fetch(name, version, uri, dst string) error {
+	err := b.recipe.Validate()
+	if err != nil {
+		return err
+	}
+
+	workDir := filepath.Join(b.stagingDir, "build", fmt.Sprintf("%s_%s", name, version))
+	err = os.RemoveAll(workDir)
+	if err != nil {
+		return errors.Wrapf(err, "clean build dir %s failed", workDir)
+	}
+
+	logPath := filepath.Join(b.logDir, "build", fmt.Sprintf("%s_%s.log", name, version))
+	err = os.MkdirAll(filepath.Dir(logPath), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "create log dir failed")
+	}
+
+	log, err := os.Create(logPath)
+	if err != nil {
+		return errors.Wrapf(err, "create build log %s failed", logPath)
+	}
+	defer func() { _ = log.Close() }()
+
+	timeout := b.timeout
+	if b.recipe.Timeout > 0 {
+		timeout = time.Duration(b.recipe.Timeout) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	core.GetLogger().Info("building command", map[string]interface{}{
+		"uri": uri,
+		"log": logPath,
+	})
+
+	err = b.build(ctx, log, name, version, uri, workDir, dst)
+	if err != nil {
+		// the sources are kept for troubleshooting
+		return errors.WithMessagef(err, "build %s failed, see %s", uri, logPath)
+	}
+
+	return os.RemoveAll(workDir)
+}
+
+func NewGitBuilder(gitPath, scheme, stagingDir, logDir string, timeout time.Duration) *GitBuilder {
+	return &GitBuilder{
+		gitPath:    gitPath,
+		scheme:     scheme,
+		stagingDir: stagingDir,
+		logDir:     logDir,
+		timeout:    timeout,
+	}
+}
+
+func NewDefaultGitBuilder(stagingDir, logDir string) *GitBuilder {
+	return NewGitBuilder("git", "git+", stagingDir, logDir, 30*time.Minute)
+}
@@ -0,0 +1,165 @@
+package fetcher_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core"
+	. "github.com/mrlyc/cmdr/core/fetcher"
+)
+
+var _ = Describe("GitBuilder", func() {
+	var (
+		tempDir    string
+		repoDir    string
+		stagingDir string
+		logDir     string
+		outputDir  string
+		builder    *GitBuilder
+		recipe     *Recipe
+	)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=cmdr", "GIT_AUTHOR_EMAIL=cmdr@example.com",
+			"GIT_COMMITTER_NAME=cmdr", "GIT_COMMITTER_EMAIL=cmdr@example.com",
+		)
+		output, err := cmd.CombinedOutput()
+		Expect(err).To(BeNil(), string(output))
+	}
+
+	commit := func(content string) {
+		Expect(os.WriteFile(filepath.Join(repoDir, "main.sh"), []byte(content), 0644)).To(Succeed())
+		git("add", "-A")
+		git("commit", "--quiet", "-m", content)
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+
+		repoDir = filepath.Join(tempDir, "repo")
+		stagingDir = filepath.Join(tempDir, "staging")
+		logDir = filepath.Join(tempDir, "logs")
+		outputDir = filepath.Join(tempDir, "output")
+		Expect(os.MkdirAll(repoDir, 0755)).To(Succeed())
+		Expect(os.MkdirAll(outputDir, 0755)).To(Succeed())
+
+		git("init", "--quiet")
+		commit("echo v1")
+		git("tag", "v1")
+		commit("echo v2")
+
+		recipe = &Recipe{
+			Commands: []string{
+				"mkdir -p dist",
+				`printf '#!/bin/sh\n' > dist/tool && cat main.sh >> dist/tool`,
+				`echo "$CMDR_BUILD_NAME $CMDR_BUILD_VERSION $GREETING"`,
+			},
+			Env:    map[string]string{"GREETING": "hello"},
+			Output: "dist/tool",
+		}
+
+		builder = NewGitBuilder("git", "git+", stagingDir, logDir, time.Minute)
+		builder.SetRecipe(recipe)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("should support git+ scheme", func() {
+		Expect(builder.IsSupport("git+https://github.com/mrlyc/cmdr#v1")).To(BeTrue())
+		Expect(builder.IsSupport("git::https://github.com/mrlyc/cmdr")).To(BeFalse())
+	})
+
+	It("should build the ref", func() {
+		Expect(builder.Fetch("tool", "1.0.0", "git+file://"+repoDir+"#v1", outputDir)).To(Succeed())
+
+		output, err := exec.Command(filepath.Join(outputDir, "tool")).Output()
+		Expect(err).To(BeNil())
+		Expect(strings.TrimSpace(string(output))).To(Equal("v1"))
+
+		log, err := os.ReadFile(filepath.Join(logDir, "build", "tool_1.0.0.log"))
+		Expect(err).To(BeNil())
+		Expect(string(log)).To(ContainSubstring("tool 1.0.0 hello"))
+
+		Expect(filepath.Join(stagingDir, "build", "tool_1.0.0")).NotTo(BeADirectory())
+	})
+
+	It("should build the default branch", func() {
+		Expect(builder.Fetch("tool", "2.0.0", "git+"+repoDir, outputDir)).To(Succeed())
+
+		output, err := exec.Command(filepath.Join(outputDir, "tool")).Output()
+		Expect(err).To(BeNil())
+		Expect(strings.TrimSpace(string(output))).To(Equal("v2"))
+	})
+
+	It("should keep sources when build failed", func() {
+		recipe.Output = "dist/missing"
+
+		err := builder.Fetch("tool", "1.0.0", "git+"+repoDir, outputDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(filepath.Join(logDir, "build", "tool_1.0.0.log")))
+		Expect(filepath.Join(stagingDir, "build", "tool_1.0.0", "source", "dist", "tool")).To(BeARegularFile())
+	})
+
+	It("should stop build when timeout", func() {
+		recipe.Commands = []string{"sleep 10"}
+		recipe.Timeout = 1
+
+		start := time.Now()
+		Expect(builder.Fetch("tool", "1.0.0", "git+"+repoDir, outputDir)).NotTo(Succeed())
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+
+	It("should require a recipe", func() {
+		builder.SetRecipe(&Recipe{})
+
+		err := builder.Fetch("tool", "1.0.0", "git+"+repoDir, outputDir)
+		Expect(errors.Is(err, ErrRecipeRequired)).To(BeTrue())
+	})
+
+	It("should not clone remote repositories in offline mode", func() {
+		builder.SetOffline(true)
+
+		err := builder.Fetch("tool", "1.0.0", "git+https://github.com/mrlyc/cmdr", outputDir)
+		Expect(errors.Is(err, core.ErrOffline)).To(BeTrue())
+	})
+
+	It("should reject options as the repository or the ref", func() {
+		for _, uri := range []string{
+			"git+--upload-pack=touch " + filepath.Join(tempDir, "pwned"),
+			"git+" + repoDir + "#--orphan=main",
+		} {
+			err := builder.Fetch("tool", "1.0.0", uri, outputDir)
+			Expect(errors.Is(err, ErrInvalidGitSource)).To(BeTrue(), uri)
+		}
+
+		Expect(filepath.Join(tempDir, "pwned")).NotTo(BeAnExistingFile())
+	})
+
+	It("should load recipe file", func() {
+		path := filepath.Join(tempDir, "recipe.yaml")
+		Expect(os.WriteFile(path, []byte("commands:\n  - make\nenv:\n  CGO_ENABLED: \"0\"\noutput: bin/tool\ntimeout: 60\n"), 0644)).To(Succeed())
+
+		loaded, err := LoadRecipe(path)
+		Expect(err).To(BeNil())
+		Expect(*loaded).To(Equal(Recipe{
+			Commands: []string{"make"},
+			Env:      map[string]string{"CGO_ENABLED": "0"},
+			Output:   "bin/tool",
+			Timeout:  60,
+		}))
+	})
+})
@@ -0,0 +1,50 @@
+package fetcher
+
+import (
+	"strings"
+)
+
+// CargoInstaller installs rust crates by cargo://crate[@version]
+type CargoInstaller struct {
+	cargoPath string
+	scheme    string
+	offline   bool
+}
+
+// SetOffline makes cargo resolve crates from the local registry cache only
+func (c *CargoInstaller) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+func (c *CargoInstaller) IsSupport(uri string) bool {
+	return strings.HasPrefix(uri, c.scheme)
+}
+
+func (c *CargoInstaller) Fetch(name, version, uri, dst string) error {
+	crate, version, err := splitPackageVersion(strings.TrimPrefix(uri, c.scheme), version)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"install", "--root", dst}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+
+	if c.offline {
+		args = append(args, "--offline")
+	}
+
+	return runPackageInstaller(nil, c.cargoPath, append(args, crate)...)
+}
+
+func NewCargoInstaller(cargoPath, scheme string) *CargoInstaller {
+	return &CargoInstaller{
+		cargoPath: cargoPath,
+		scheme:    scheme,
+	}
+}
+
+func NewDefaultCargoInstaller() *CargoInstaller {
+	return NewCargoInstaller("cargo", "cargo://")
+}
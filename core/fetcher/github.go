@@ -0,0 +1,162 @@
+package fetcher
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/hashicorp/go-getter"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var (
+	ErrGitHubReleaseNotFound = errors.New("github release not found")
+)
+
+// GitHubReleaseFetcher downloads the release asset which matches the platform,
+// the locations are like github://jqlang/jq?tag_prefix=jq-, or github://jqlang/jq?asset=jq-linux64 to choose an asset
+type GitHubReleaseFetcher struct {
+	scheme   string
+	client   utils.GithubRepositoryClient
+	getter   *GoGetter
+	platform utils.AssetPlatform
+	offline  bool
+}
+
+func (f *GitHubReleaseFetcher) IsSupport(uri string) bool {
+	return strings.HasPrefix(uri, f.scheme)
+}
+
+// SetOptions sets the options of the go-getter which downloads the assets
+func (f *GitHubReleaseFetcher) SetOptions(options []getter.ClientOption) {
+	f.getter.SetOptions(options)
+}
+
+func (f *GitHubReleaseFetcher) SetPlatform(platform utils.AssetPlatform) {
+	f.platform = platform
+}
+
+func (f *GitHubReleaseFetcher) SetOffline(offline bool) {
+	f.offline = offline
+}
+
+// getTags returns the tags to try, the latest release is used when the version is empty
+func (f *GitHubReleaseFetcher) getTags(version, prefix string) []string {
+	if version == "" {
+		return nil
+	}
+
+	tags := []string{prefix + version}
+	if strings.HasPrefix(version, "v") {
+		tags = append(tags, prefix+strings.TrimPrefix(version, "v"))
+	} else {
+		tags = append(tags, prefix+"v"+version)
+	}
+
+	return tags
+}
+
+func (f *GitHubReleaseFetcher) getRelease(ctx context.Context, owner, repo string, tags []string) (*github.RepositoryRelease, error) {
+	if len(tags) == 0 {
+		release, _, err := f.client.GetLatestRelease(ctx, owner, repo)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get latest release of %s/%s failed", owner, repo)
+		}
+
+		return release, nil
+	}
+
+	var errs []string
+	for _, tag := range tags {
+		release, _, err := f.client.GetReleaseByTag(ctx, owner, repo, tag)
+		if err == nil {
+			return release, nil
+		}
+
+		errs = append(errs, err.Error())
+	}
+
+	return nil, errors.Wrapf(ErrGitHubReleaseNotFound, "%s/%s %s: %s", owner, repo, strings.Join(tags, ", "), strings.Join(errs, "; "))
+}
+
+// Explain scores the assets of a release for the platform
+func (f *GitHubReleaseFetcher) Explain(name, version, uri string) ([]*utils.AssetCandidate, error) {
+	release, matcher, _, err := f.search(name, version, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(release.Assets))
+	for _, asset := range release.Assets {
+		names = append(names, asset.GetName())
+	}
+
+	return matcher.Explain(names), nil
+}
+
+func (f *GitHubReleaseFetcher) search(name, version, uri string) (*github.RepositoryRelease, *utils.AssetMatcher, url.Values, error) {
+	if f.offline {
+		return nil, nil, nil, errors.Wrapf(core.ErrOffline, "search release of %s", uri)
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "parse %s failed", uri)
+	}
+
+	owner, repo := parsed.Host, strings.Trim(parsed.Path, "/")
+	if owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, nil, nil, errors.Errorf("invalid github location %s, expected github://owner/repo", uri)
+	}
+
+	query := parsed.Query()
+	release, err := f.getRelease(context.Background(), owner, repo, f.getTags(version, query.Get("tag_prefix")))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return release, utils.NewAssetMatcher(f.platform, name), query, nil
+}
+
+func (f *GitHubReleaseFetcher) Fetch(name, version, uri, dst string) error {
+	release, matcher, query, err := f.search(name, version, uri)
+	if err != nil {
+		return err
+	}
+
+	assets := make(map[string]*github.ReleaseAsset, len(release.Assets))
+	names := make([]string, 0, len(release.Assets))
+	for _, asset := range release.Assets {
+		assets[asset.GetName()] = asset
+		names = append(names, asset.GetName())
+	}
+
+	assetName, err := matcher.Match(names, query.Get("asset"))
+	if err != nil {
+		return errors.Wrapf(err, "choose asset of release %s", release.GetTagName())
+	}
+
+	core.GetLogger().Info("release asset found", map[string]interface{}{
+		"release": release.GetTagName(),
+		"asset":   assetName,
+	})
+
+	return f.getter.Fetch(name, version, assets[assetName].GetBrowserDownloadURL(), dst)
+}
+
+func NewGitHubReleaseFetcher(scheme string, client utils.GithubRepositoryClient, getter *GoGetter) *GitHubReleaseFetcher {
+	return &GitHubReleaseFetcher{
+		scheme:   scheme,
+		client:   client,
+		getter:   getter,
+		platform: utils.CurrentAssetPlatform(),
+	}
+}
+
+func NewDefaultGitHubReleaseFetcher(getter *GoGetter) *GitHubReleaseFetcher {
+	return NewGitHubReleaseFetcher("github://", github.NewClient(nil).Repositories, getter)
+}
@@ -0,0 +1,129 @@
+package fetcher_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v39/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core"
+	. "github.com/mrlyc/cmdr/core/fetcher"
+	"github.com/mrlyc/cmdr/core/utils"
+	"github.com/mrlyc/cmdr/core/utils/mock"
+)
+
+var _ = Describe("GitHubReleaseFetcher", func() {
+	var (
+		ctrl      *gomock.Controller
+		client    *mock.MockGithubRepositoryClient
+		server    *httptest.Server
+		fetcher   *GitHubReleaseFetcher
+		outputDir string
+		release   *github.RepositoryRelease
+	)
+
+	newRelease := func(tag string, names ...string) *github.RepositoryRelease {
+		release := &github.RepositoryRelease{TagName: github.String(tag)}
+		for _, name := range names {
+			release.Assets = append(release.Assets, &github.ReleaseAsset{
+				Name:               github.String(name),
+				BrowserDownloadURL: github.String(fmt.Sprintf("%s/%s", server.URL, name)),
+			})
+		}
+
+		return release
+	}
+
+	BeforeEach(func() {
+		var err error
+		outputDir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+
+		ctrl = gomock.NewController(GinkgoT())
+		client = mock.NewMockGithubRepositoryClient(ctrl)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, r.URL.Path)
+		}))
+
+		fetcher = NewGitHubReleaseFetcher("github://", client, NewGoGetter(nil, nil, nil))
+		fetcher.SetPlatform(utils.AssetPlatform{OS: "linux", Arch: "arm64", Libc: utils.LibcGNU})
+		release = newRelease("jq-1.7.1", "jq-linux-amd64", "jq-linux-arm64", "jq-macos-arm64", "sha256sum.txt")
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		server.Close()
+		Expect(os.RemoveAll(outputDir)).To(Succeed())
+	})
+
+	It("should support github scheme", func() {
+		Expect(fetcher.IsSupport("github://jqlang/jq")).To(BeTrue())
+		Expect(fetcher.IsSupport("https://github.com/jqlang/jq")).To(BeFalse())
+	})
+
+	It("should download the asset of the platform", func() {
+		client.EXPECT().GetReleaseByTag(gomock.Any(), "jqlang", "jq", "jq-1.7.1").Return(release, nil, nil)
+
+		Expect(fetcher.Fetch("jq", "1.7.1", "github://jqlang/jq?tag_prefix=jq-", outputDir)).To(Succeed())
+		Expect(os.ReadFile(filepath.Join(outputDir, "jq-linux-arm64"))).To(Equal([]byte("/jq-linux-arm64")))
+	})
+
+	It("should try the tag with v prefix", func() {
+		release = newRelease("v1.0.0", "tool_linux_aarch64.tar.gz.sha256", "tool_linux_aarch64")
+		client.EXPECT().GetReleaseByTag(gomock.Any(), "owner", "tool", "1.0.0").Return(nil, nil, errors.New("not found"))
+		client.EXPECT().GetReleaseByTag(gomock.Any(), "owner", "tool", "v1.0.0").Return(release, nil, nil)
+
+		Expect(fetcher.Fetch("tool", "1.0.0", "github://owner/tool", outputDir)).To(Succeed())
+		Expect(filepath.Join(outputDir, "tool_linux_aarch64")).To(BeAnExistingFile())
+	})
+
+	It("should use the latest release without version", func() {
+		client.EXPECT().GetLatestRelease(gomock.Any(), "jqlang", "jq").Return(release, nil, nil)
+
+		Expect(fetcher.Fetch("jq", "", "github://jqlang/jq?asset=jq-macos-arm64", outputDir)).To(Succeed())
+		Expect(filepath.Join(outputDir, "jq-macos-arm64")).To(BeAnExistingFile())
+	})
+
+	It("should fail when the release is not found", func() {
+		client.EXPECT().GetReleaseByTag(gomock.Any(), "owner", "tool", gomock.Any()).Return(nil, nil, errors.New("not found")).Times(2)
+
+		err := fetcher.Fetch("tool", "1.0.0", "github://owner/tool", outputDir)
+		Expect(errors.Is(err, ErrGitHubReleaseNotFound)).To(BeTrue())
+	})
+
+	It("should fail when no asset matches", func() {
+		fetcher.SetPlatform(utils.AssetPlatform{OS: "windows", Arch: "amd64"})
+		client.EXPECT().GetLatestRelease(gomock.Any(), "jqlang", "jq").Return(release, nil, nil)
+
+		err := fetcher.Fetch("jq", "", "github://jqlang/jq", outputDir)
+		Expect(errors.Is(err, utils.ErrAssetNotMatched)).To(BeTrue())
+	})
+
+	It("should explain", func() {
+		client.EXPECT().GetLatestRelease(gomock.Any(), "jqlang", "jq").Return(release, nil, nil)
+
+		candidates, err := fetcher.Explain("jq", "", "github://jqlang/jq")
+		Expect(err).To(BeNil())
+		Expect(candidates).To(HaveLen(4))
+		Expect(candidates[0].Name).To(Equal("jq-linux-arm64"))
+	})
+
+	It("should fail fast in offline mode", func() {
+		fetcher.SetOffline(true)
+
+		err := fetcher.Fetch("jq", "", "github://jqlang/jq", outputDir)
+		Expect(errors.Is(err, core.ErrOffline)).To(BeTrue())
+	})
+
+	It("should fail with invalid location", func() {
+		err := fetcher.Fetch("jq", "", "github://jqlang", outputDir)
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -1,33 +1,146 @@
 package fetcher
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 
-	"github.com/mrlyc/cmdr/core"
 	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+var (
+	ErrGoVersionRequired = errors.New("go module version required")
 )
 
+// GoInstallOptions are the per install options of GoInstaller
+type GoInstallOptions struct {
+	// GoFlags is passed by GOFLAGS
+	GoFlags string
+	// CGOEnabled is passed by CGO_ENABLED, the environment is inherited when empty
+	CGOEnabled string
+	Tags       []string
+	LDFlags    string
+	GoPrivate  string
+	// AllowLatest allows to install @latest when the version does not exist
+	AllowLatest bool
+}
+
 type GoInstaller struct {
-	goPath string
-	scheme string
+	goPath      string
+	pinned      bool
+	scheme      string
+	offline     bool
+	options     GoInstallOptions
+	isolatedDir string
+	output      io.Writer
+
+	lock     sync.Mutex
+	metadata map[string]map[string]string
+}
+
+// SetOffline makes go resolve modules from the local module cache only
+func (g *GoInstaller) SetOffline(offline bool) {
+	g.offline = offline
+}
+
+func (g *GoInstaller) SetOptions(options GoInstallOptions) {
+	g.options = options
+}
+
+// SetGoPath pins the go toolchain, go will not switch to another one by GOTOOLCHAIN
+func (g *GoInstaller) SetGoPath(goPath string) {
+	g.goPath = goPath
+	g.pinned = true
+}
+
+// SetIsolatedDir makes go use GOPATH and GOCACHE under the dir, only a minimal environment is inherited
+func (g *GoInstaller) SetIsolatedDir(dir string) {
+	g.isolatedDir = dir
+}
+
+func (g *GoInstaller) SetOutput(output io.Writer) {
+	g.output = output
 }
 
 func (g *GoInstaller) IsSupport(uri string) bool {
 	return strings.HasPrefix(uri, g.scheme)
 }
 
+func (g *GoInstaller) getEnv(dst string) []string {
+	var envs []string
+	if g.isolatedDir == "" {
+		envs = os.Environ()
+	} else {
+		for _, key := range buildEnvAllowlist {
+			value, ok := os.LookupEnv(key)
+			if ok {
+				envs = append(envs, fmt.Sprintf("%s=%s", key, value))
+			}
+		}
+
+		for _, key := range []string{"GOPROXY", "GONOPROXY", "GOSUMDB", "GONOSUMDB"} {
+			value, ok := os.LookupEnv(key)
+			if ok {
+				envs = append(envs, fmt.Sprintf("%s=%s", key, value))
+			}
+		}
+
+		envs = append(envs,
+			fmt.Sprintf("GOPATH=%s", filepath.Join(g.isolatedDir, "path")),
+			fmt.Sprintf("GOCACHE=%s", filepath.Join(g.isolatedDir, "cache")),
+		)
+	}
+
+	// the later ones take precedence
+	envs = append(envs, fmt.Sprintf("GOBIN=%s", dst))
+	if g.pinned {
+		envs = append(envs, "GOTOOLCHAIN=local")
+	}
+
+	goFlags := g.options.GoFlags
+	if g.offline {
+		goFlags = strings.TrimSpace(goFlags + " -mod=mod")
+		envs = append(envs, "GOPROXY=off")
+	}
+
+	if goFlags != "" {
+		envs = append(envs, fmt.Sprintf("GOFLAGS=%s", goFlags))
+	}
+
+	if g.options.CGOEnabled != "" {
+		envs = append(envs, fmt.Sprintf("CGO_ENABLED=%s", g.options.CGOEnabled))
+	}
+
+	if g.options.GoPrivate != "" {
+		envs = append(envs, fmt.Sprintf("GOPRIVATE=%s", g.options.GoPrivate))
+	}
+
+	return envs
+}
+
 func (g *GoInstaller) install(location, dst string) error {
-	cmd := exec.Command(g.goPath, "install", "-v", location)
-	cmd.Dir = dst
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	args := []string{"install", "-v"}
+	if len(g.options.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(g.options.Tags, ","))
+	}
+
+	if g.options.LDFlags != "" {
+		args = append(args, "-ldflags", g.options.LDFlags)
+	}
 
-	envs := []string{fmt.Sprintf("GOBIN=%s", dst)}
-	cmd.Env = append(envs, os.Environ()...)
+	cmd := exec.Command(g.goPath, append(args, location)...)
+	cmd.Dir = dst
+	cmd.Stdout = g.output
+	cmd.Stderr = g.output
+	cmd.Env = g.getEnv(dst)
 
 	err := cmd.Run()
 	if err != nil {
@@ -37,37 +150,124 @@ func (g *GoInstaller) install(location, dst string) error {
 	return nil
 }
 
+// getCandidates returns the module queries to try, @latest is never guessed unless allowed
+func (g *GoInstaller) getCandidates(location, version string) []string {
+	if strings.Contains(location, "@") {
+		return []string{location}
+	}
+
+	var candidates []string
+	if version != "" {
+		candidates = append(candidates, fmt.Sprintf("%s@%s", location, version))
+
+		if !strings.HasPrefix(version, "v") {
+			candidates = append(candidates, fmt.Sprintf("%s@v%s", location, version))
+		}
+	}
+
+	if g.options.AllowLatest {
+		candidates = append(candidates, fmt.Sprintf("%s@latest", location))
+	}
+
+	return candidates
+}
+
+// readBuildInfo reads the module information embedded in the installed binaries
+func (g *GoInstaller) readBuildInfo(dst string) map[string]string {
+	output, err := exec.Command(g.goPath, "version", "-m", dst).Output()
+	if err != nil {
+		core.GetLogger().Warn("read build info failed", map[string]interface{}{
+			"error": err,
+		})
+		return nil
+	}
+
+	metadata := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		if !strings.HasPrefix(line, "\t") {
+			// only the first binary is recorded
+			if len(metadata) > 0 {
+				break
+			}
+
+			if len(fields) == 2 {
+				metadata["go.toolchain"] = fields[1]
+			}
+
+			continue
+		}
+
+		switch {
+		case len(fields) >= 2 && fields[0] == "path":
+			metadata["go.package"] = fields[1]
+		case len(fields) >= 3 && fields[0] == "mod":
+			metadata["go.module"] = fields[1]
+			metadata["go.version"] = fields[2]
+
+			if len(fields) >= 4 {
+				metadata["go.sum"] = fields[3]
+			}
+		}
+	}
+
+	return metadata
+}
+
 func (g *GoInstaller) Fetch(name, version, uri, dst string) error {
 	logger := core.GetLogger()
 	location := strings.TrimPrefix(uri, g.scheme)
 
-	if strings.Contains(location, "@") {
-		return g.install(location, dst)
+	candidates := g.getCandidates(location, version)
+	if len(candidates) == 0 {
+		return errors.Wrapf(ErrGoVersionRequired, "install %s", location)
 	}
 
 	var err error
-	for _, detected := range []string{
-		fmt.Sprintf("%s@%s", location, version),
-		fmt.Sprintf("%s@v%s", location, version),
-		fmt.Sprintf("%s@latest", location),
-		location,
-	} {
-		logger.Warn("version suffix not set, detecting", map[string]interface{}{
-			"suffix": detected,
-		})
-		err := g.install(detected, dst)
+	for index, candidate := range candidates {
+		if index > 0 {
+			logger.Warn("install failed, trying another version", map[string]interface{}{
+				"module": candidate,
+				"error":  err,
+			})
+		}
+
+		err = g.install(candidate, dst)
 		if err == nil {
 			break
 		}
 	}
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	metadata := g.readBuildInfo(dst)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.metadata[fmt.Sprintf("%s@%s", name, version)] = metadata
+
+	return nil
+}
+
+// GetMetadata returns the module information of the last installed command
+func (g *GoInstaller) GetMetadata(name, version string) map[string]string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.metadata[fmt.Sprintf("%s@%s", name, version)]
 }
 
 func NewGoInstaller(goPath string, schema string) *GoInstaller {
 	return &GoInstaller{
-		goPath: goPath,
-		scheme: schema,
+		goPath:   goPath,
+		scheme:   schema,
+		output:   os.Stderr,
+		metadata: make(map[string]map[string]string),
 	}
 }
 
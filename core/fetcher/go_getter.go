@@ -2,10 +2,13 @@ package fetcher
 
 import (
 	"io"
+	"net/url"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/hashicorp/go-getter"
+	"github.com/mrlyc/cmdr/core"
 	"github.com/mrlyc/cmdr/core/utils"
 	"github.com/pkg/errors"
 )
@@ -17,6 +20,8 @@ type GoGetter struct {
 	detectors        []getter.Detector
 	options          []getter.ClientOption
 	optionsMutex     sync.RWMutex
+	getters          map[string]getter.Getter
+	offline          bool
 }
 
 func (d *GoGetter) IsSupport(uri string) bool {
@@ -24,7 +29,34 @@ func (d *GoGetter) IsSupport(uri string) bool {
 	return err == nil
 }
 
+// checkOffline returns core.ErrOffline when uri is not a local file
+func (d *GoGetter) checkOffline(uri string) error {
+	detected, err := getter.Detect(uri, os.TempDir(), d.detectors)
+	if err != nil {
+		return errors.Wrapf(err, "detect %s failed", uri)
+	}
+
+	// skip the forced getter, such as git::https://...
+	if idx := strings.Index(detected, "::"); idx >= 0 && !strings.Contains(detected[:idx], "/") {
+		detected = detected[idx+2:]
+	}
+
+	parsed, err := url.Parse(detected)
+	if err == nil && parsed.Scheme == "file" {
+		return nil
+	}
+
+	return errors.Wrapf(core.ErrOffline, "download %s", uri)
+}
+
 func (d *GoGetter) Fetch(name, version, uri, dst string) error {
+	if d.offline {
+		err := d.checkOffline(uri)
+		if err != nil {
+			return err
+		}
+	}
+
 	d.optionsMutex.RLock()
 	options := d.options
 	d.optionsMutex.RUnlock()
@@ -36,6 +68,7 @@ func (d *GoGetter) Fetch(name, version, uri, dst string) error {
 		Mode:             getter.ClientModeAny,
 		Detectors:        d.detectors,
 		Options:          options,
+		Getters:          d.getters,
 		ProgressListener: d.progressListener,
 	}
 
@@ -53,6 +86,15 @@ func (d *GoGetter) SetOptions(options []getter.ClientOption) {
 	d.options = options
 }
 
+// SetGetters overrides the getters by scheme, the defaults of go-getter are used when it is nil
+func (d *GoGetter) SetGetters(getters map[string]getter.Getter) {
+	d.getters = getters
+}
+
+func (d *GoGetter) SetOffline(offline bool) {
+	d.offline = offline
+}
+
 func NewGoGetter(progressListener getter.ProgressTracker, detectors []getter.Detector, options []getter.ClientOption) *GoGetter {
 	return &GoGetter{
 		progressListener: progressListener,
@@ -1,10 +1,14 @@
 package fetcher_test
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/golang/mock/gomock"
 	g "github.com/hashicorp/go-getter"
+	"github.com/mrlyc/cmdr/core"
 	. "github.com/mrlyc/cmdr/core/fetcher"
 	"github.com/mrlyc/cmdr/core/fetcher/mock"
 	. "github.com/onsi/ginkgo"
@@ -49,5 +53,27 @@ var _ = Describe("GoGetter", func() {
 
 			Expect(getter.Fetch("name", "version", "uri", "dst")).To(HaveOccurred())
 		})
+
+		It("should fail fast in offline mode", func() {
+			getter.SetOffline(true)
+			detector.EXPECT().Detect(gomock.Any(), gomock.Any()).Return("git::https://github.com/mrlyc/cmdr", true, nil)
+
+			err := getter.Fetch("name", "version", "github.com/mrlyc/cmdr", "dst")
+			Expect(errors.Is(err, core.ErrOffline)).To(BeTrue())
+		})
+
+		It("should fetch local file in offline mode", func() {
+			dir, err := os.MkdirTemp("", "")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			src := filepath.Join(dir, "cmdr")
+			Expect(os.WriteFile(src, []byte("cmdr"), 0755)).To(Succeed())
+
+			getter = NewGoGetter(nil, nil, nil)
+			getter.SetOffline(true)
+			Expect(getter.Fetch("name", "version", "file://"+src, filepath.Join(dir, "output"))).To(Succeed())
+			Expect(os.ReadFile(filepath.Join(dir, "output", "cmdr"))).To(Equal([]byte("cmdr")))
+		})
 	})
 })
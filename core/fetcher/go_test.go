@@ -1,6 +1,13 @@
 package fetcher_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	. "github.com/mrlyc/cmdr/core/fetcher"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -21,4 +28,106 @@ var _ = Describe("GoGetter", func() {
 		})
 	})
 
+	Context("Fetch", func() {
+		var (
+			tempDir   string
+			outputDir string
+			argsFile  string
+		)
+
+		readArgs := func() []string {
+			data, err := os.ReadFile(argsFile)
+			Expect(err).To(BeNil())
+			return strings.Split(strings.TrimSpace(string(data)), "\n")
+		}
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = os.MkdirTemp("", "")
+			Expect(err).To(BeNil())
+
+			outputDir = filepath.Join(tempDir, "output")
+			argsFile = filepath.Join(tempDir, "args")
+			Expect(os.MkdirAll(outputDir, 0755)).To(Succeed())
+
+			// a fake go which only knows the v prefixed version
+			goPath := filepath.Join(tempDir, "go")
+			Expect(os.WriteFile(goPath, []byte(`#!/bin/sh
+if [ "$1" = "version" ]; then
+	printf '%s: go1.22.3\n\tpath\tgithub.com/mrlyc/cmdr\n\tmod\tgithub.com/mrlyc/cmdr\tv1.0.0\th1:xxx=\n' "$3/cmdr"
+	exit 0
+fi
+echo "$@ GOFLAGS=$GOFLAGS CGO_ENABLED=$CGO_ENABLED GOPRIVATE=$GOPRIVATE GOTOOLCHAIN=$GOTOOLCHAIN GOPATH=$GOPATH" >> `+argsFile+`
+case "$*" in
+	*@v1.0.0) touch "$GOBIN/cmdr" ;;
+	*) exit 1 ;;
+esac
+`), 0755)).To(Succeed())
+
+			installer = NewGoInstaller("go", "go://")
+			installer.SetGoPath(goPath)
+			installer.SetOutput(&bytes.Buffer{})
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		It("should try the v prefixed version and record metadata", func() {
+			Expect(installer.Fetch("cmdr", "1.0.0", "go://github.com/mrlyc/cmdr", outputDir)).To(Succeed())
+			// the environment is inherited when not isolated
+			inherited := fmt.Sprintf(
+				"GOFLAGS=%s CGO_ENABLED=%s GOPRIVATE=%s GOTOOLCHAIN=local GOPATH=%s",
+				os.Getenv("GOFLAGS"), os.Getenv("CGO_ENABLED"), os.Getenv("GOPRIVATE"), os.Getenv("GOPATH"),
+			)
+			Expect(readArgs()).To(Equal([]string{
+				"install -v github.com/mrlyc/cmdr@1.0.0 " + inherited,
+				"install -v github.com/mrlyc/cmdr@v1.0.0 " + inherited,
+			}))
+
+			Expect(installer.GetMetadata("cmdr", "1.0.0")).To(Equal(map[string]string{
+				"go.toolchain": "go1.22.3",
+				"go.package":   "github.com/mrlyc/cmdr",
+				"go.module":    "github.com/mrlyc/cmdr",
+				"go.version":   "v1.0.0",
+				"go.sum":       "h1:xxx=",
+			}))
+		})
+
+		It("should not fall back to latest", func() {
+			Expect(installer.Fetch("cmdr", "2.0.0", "go://github.com/mrlyc/cmdr", outputDir)).NotTo(Succeed())
+			Expect(readArgs()).To(HaveLen(2))
+		})
+
+		It("should fall back to latest when allowed", func() {
+			installer.SetOptions(GoInstallOptions{AllowLatest: true})
+
+			Expect(installer.Fetch("cmdr", "2.0.0", "go://github.com/mrlyc/cmdr", outputDir)).NotTo(Succeed())
+			Expect(readArgs()[2]).To(HavePrefix("install -v github.com/mrlyc/cmdr@latest "))
+		})
+
+		It("should require a version", func() {
+			err := installer.Fetch("cmdr", "", "go://github.com/mrlyc/cmdr", outputDir)
+			Expect(errors.Is(err, ErrGoVersionRequired)).To(BeTrue())
+		})
+
+		It("should pass build options", func() {
+			installer.SetOffline(true)
+			installer.SetIsolatedDir(filepath.Join(tempDir, "isolated"))
+			installer.SetOptions(GoInstallOptions{
+				GoFlags:    "-trimpath",
+				CGOEnabled: "0",
+				Tags:       []string{"netgo", "osusergo"},
+				LDFlags:    "-s -w",
+				GoPrivate:  "github.com/mrlyc",
+			})
+
+			Expect(installer.Fetch("cmdr", "v1.0.0", "go://github.com/mrlyc/cmdr", outputDir)).To(Succeed())
+			Expect(readArgs()).To(Equal([]string{
+				"install -v -tags netgo,osusergo -ldflags -s -w github.com/mrlyc/cmdr@v1.0.0 " +
+					"GOFLAGS=-trimpath -mod=mod CGO_ENABLED=0 GOPRIVATE=github.com/mrlyc GOTOOLCHAIN=local " +
+					"GOPATH=" + filepath.Join(tempDir, "isolated", "path"),
+			}))
+		})
+	})
 })
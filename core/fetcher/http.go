@@ -0,0 +1,420 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bgentry/go-netrc/netrc"
+	"github.com/hashicorp/go-getter"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/strategy"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+const (
+	stagingPartExt = ".part"
+	stagingMetaExt = ".json"
+)
+
+var (
+	ErrUnexpectedContentRange = errors.New("unexpected content range")
+)
+
+// stagingURL is the url saved in the staging meta, without the credentials in the userinfo or the query
+func stagingURL(src *url.URL) string {
+	stripped := *src
+	stripped.User = nil
+	stripped.RawQuery = ""
+	stripped.Fragment = ""
+
+	return stripped.String()
+}
+
+type stagingMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	Size         int64  `json:"size"`
+}
+
+// validator returns the value for If-Range, a strong ETag is preferred over Last-Modified
+func (m *stagingMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+
+	return m.LastModified
+}
+
+// ResumableHttpGetter downloads single files into a staging area first, so an interrupted
+// download can be continued by a range request on the next attempt.
+type ResumableHttpGetter struct {
+	*getter.HttpGetter
+	stagingDir string
+	stagingTTL time.Duration
+	httpClient *http.Client
+	client     *getter.Client
+}
+
+// SetStagingTTL sets how long an abandoned staging file is kept, zero keeps them forever
+func (g *ResumableHttpGetter) SetStagingTTL(ttl time.Duration) {
+	g.stagingTTL = ttl
+}
+
+func (g *ResumableHttpGetter) SetClient(c *getter.Client) {
+	g.client = c
+	g.HttpGetter.SetClient(c)
+}
+
+func (g *ResumableHttpGetter) stagingPaths(src *url.URL) (string, string) {
+	sum := sha256.Sum256([]byte(src.String()))
+	key := hex.EncodeToString(sum[:])
+	helper := utils.NewPathHelper(g.stagingDir)
+
+	return helper.Child(key + stagingPartExt).Path(), helper.Child(key + stagingMetaExt).Path()
+}
+
+func (g *ResumableHttpGetter) loadMeta(path string, src *url.URL) *stagingMeta {
+	meta := &stagingMeta{URL: stagingURL(src)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return meta
+	}
+
+	var loaded stagingMeta
+	if json.Unmarshal(data, &loaded) != nil || loaded.URL != meta.URL {
+		return meta
+	}
+
+	return &loaded
+}
+
+func (g *ResumableHttpGetter) saveMeta(path string, meta *stagingMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return errors.Wrapf(err, "marshal staging meta failed")
+	}
+
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "write staging meta %s failed", path)
+	}
+
+	return nil
+}
+
+// netrcPath returns the netrc file of the user like go-getter does
+func netrcPath() (string, error) {
+	path := os.Getenv("NETRC")
+	if path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrapf(err, "get home dir failed")
+	}
+
+	filename := ".netrc"
+	if runtime.GOOS == "windows" {
+		filename = "_netrc"
+	}
+
+	return filepath.Join(home, filename), nil
+}
+
+// setNetrcAuth sets the basic auth of the request from the netrc file, unless the url carries the credentials
+func (g *ResumableHttpGetter) setNetrcAuth(req *http.Request) error {
+	if !g.Netrc || (req.URL.User != nil && req.URL.User.Username() != "") {
+		return nil
+	}
+
+	path, err := netrcPath()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return nil
+	}
+
+	rc, err := netrc.ParseFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "parse netrc file %s failed", path)
+	}
+
+	machine := rc.FindMachine(req.URL.Host)
+	if machine == nil {
+		return nil
+	}
+
+	req.SetBasicAuth(machine.Login, machine.Password)
+
+	return nil
+}
+
+// request resumes the download from offset, or revalidates the staging file when it is completed
+func (g *ResumableHttpGetter) request(src *url.URL, offset int64, meta *stagingMeta, completed bool) (*http.Response, error) {
+	ctx := g.Context()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.String(), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create request failed")
+	}
+
+	if g.Header != nil {
+		req.Header = g.Header.Clone()
+	}
+
+	err = g.setNetrcAuth(req)
+	if err != nil {
+		return nil, err
+	}
+
+	validator := meta.validator()
+	if completed && meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	} else if completed {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	} else if offset > 0 && validator != "" {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "request %s failed", src.Redacted())
+	}
+
+	return resp, nil
+}
+
+// parseContentRangeStart returns the first byte position of a "bytes start-end/size" header
+func parseContentRangeStart(value string) (int64, error) {
+	value = strings.TrimPrefix(value, "bytes ")
+	idx := strings.Index(value, "-")
+	if idx <= 0 {
+		return 0, errors.Wrapf(ErrUnexpectedContentRange, "%s", value)
+	}
+
+	return strconv.ParseInt(value[:idx], 10, 64)
+}
+
+func (g *ResumableHttpGetter) download(partPath, metaPath string, src *url.URL) error {
+	logger := core.GetLogger()
+	meta := g.loadMeta(metaPath, src)
+
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "open staging file %s failed", partPath)
+	}
+	defer utils.CallClose(file)
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "stat staging file %s failed", partPath)
+	}
+
+	offset := info.Size()
+	// a completed staging file is used only when the server confirms it is not modified
+	completed := meta.Size > 0 && offset == meta.Size && (meta.ETag != "" || meta.LastModified != "")
+
+	resp, err := g.request(src, offset, meta, completed)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if !completed {
+			return fmt.Errorf("bad response code: %d", resp.StatusCode)
+		}
+
+		logger.Debug("staging file already completed", map[string]interface{}{
+			"path": partPath,
+		})
+		return nil
+	case http.StatusPartialContent:
+		start, err := parseContentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return errors.Wrapf(ErrUnexpectedContentRange, "expected offset %d", offset)
+		}
+
+		logger.Info("resuming download", map[string]interface{}{
+			"uri":    src.Redacted(),
+			"offset": offset,
+		})
+	case http.StatusOK:
+		if offset > 0 {
+			logger.Info("server ignored range request, restarting download", map[string]interface{}{
+				"uri": src.Redacted(),
+			})
+		}
+
+		offset = 0
+		err = file.Truncate(0)
+		if err != nil {
+			return errors.Wrapf(err, "truncate staging file %s failed", partPath)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the staging file is broken, discard it and let the next attempt start over
+		err = file.Truncate(0)
+		if err != nil {
+			return errors.Wrapf(err, "truncate staging file %s failed", partPath)
+		}
+
+		return fmt.Errorf("bad response code: %d", resp.StatusCode)
+	default:
+		return fmt.Errorf("bad response code: %d", resp.StatusCode)
+	}
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return errors.Wrapf(err, "seek staging file %s failed", partPath)
+	}
+
+	meta.ETag = resp.Header.Get("ETag")
+	meta.LastModified = resp.Header.Get("Last-Modified")
+	meta.Size = -1
+	if resp.ContentLength >= 0 {
+		meta.Size = offset + resp.ContentLength
+	}
+
+	err = g.saveMeta(metaPath, meta)
+	if err != nil {
+		return err
+	}
+
+	var body io.ReadCloser = resp.Body
+	if g.client != nil && g.client.ProgressListener != nil {
+		body = g.client.ProgressListener.TrackProgress(filepath.Base(src.EscapedPath()), offset, meta.Size, resp.Body)
+		defer func() { _ = body.Close() }()
+	}
+
+	written, err := getter.Copy(g.Context(), file, body)
+	if err != nil {
+		return errors.Wrapf(err, "download %s failed", src.Redacted())
+	}
+
+	if meta.Size >= 0 && offset+written < meta.Size {
+		return errors.Wrapf(io.ErrUnexpectedEOF, "download %s incompleted", src.Redacted())
+	}
+
+	return nil
+}
+
+func (g *ResumableHttpGetter) GetFile(dst string, src *url.URL) error {
+	err := utils.NewPathHelper(g.stagingDir).MkdirAll(0755)
+	if err != nil {
+		return err
+	}
+
+	if g.stagingTTL > 0 {
+		_, err = CleanStaging(g.stagingDir, g.stagingTTL)
+		if err != nil {
+			core.GetLogger().Warn("failed to clean expired staging files", map[string]interface{}{
+				"dir":   g.stagingDir,
+				"error": err,
+			})
+		}
+	}
+
+	partPath, metaPath := g.stagingPaths(src)
+	err = g.download(partPath, metaPath, src)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "create dir of %s failed", dst)
+	}
+
+	err = utils.MoveFile(partPath, dst)
+	if err != nil {
+		return err
+	}
+
+	RecordArtifact(g.Context(), src.Redacted(), dst)
+
+	return utils.NewPathHelper(g.stagingDir).EnsureNotExists(filepath.Base(metaPath))
+}
+
+func NewResumableHttpGetter(stagingDir string, httpClient *http.Client) *ResumableHttpGetter {
+	return &ResumableHttpGetter{
+		HttpGetter: &getter.HttpGetter{
+			Netrc:              true,
+			XTerraformGetLimit: 10,
+			Client:             httpClient,
+		},
+		stagingDir: stagingDir,
+		httpClient: httpClient,
+	}
+}
+
+// CleanStaging removes the staging files which are not modified in ttl, and returns the removed paths
+func CleanStaging(stagingDir string, ttl time.Duration) ([]string, error) {
+	entries, err := os.ReadDir(stagingDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "read staging dir %s failed", stagingDir)
+	}
+
+	var removed []string
+	threshold := time.Now().Add(-ttl)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != stagingPartExt && ext != stagingMetaExt) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(threshold) {
+			continue
+		}
+
+		path := filepath.Join(stagingDir, entry.Name())
+		err = os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return removed, errors.Wrapf(err, "remove staging file %s failed", path)
+		}
+
+		removed = append(removed, path)
+	}
+
+	return removed, nil
+}
+
+// NewResumableGetters returns the default getters with http and https replaced by a resumable one
+func NewResumableGetters(stagingDir string, stagingTTL time.Duration) map[string]getter.Getter {
+	httpGetter := NewResumableHttpGetter(stagingDir, &http.Client{
+		Transport: strategy.NewTransport(http.DefaultTransport),
+	})
+	httpGetter.SetStagingTTL(stagingTTL)
+
+	getters := make(map[string]getter.Getter, len(getter.Getters))
+	for scheme, g := range getter.Getters {
+		getters[scheme] = g
+	}
+
+	getters["http"] = httpGetter
+	getters["https"] = httpGetter
+
+	return getters
+}
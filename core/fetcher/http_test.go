@@ -0,0 +1,252 @@
+package fetcher_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	gogetter "github.com/hashicorp/go-getter"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mrlyc/cmdr/core/fetcher"
+)
+
+var _ = Describe("ResumableHttpGetter", func() {
+	var (
+		stagingDir string
+		outputDir  string
+		content    []byte
+		ranges     []string
+		handler    http.HandlerFunc
+		server     *httptest.Server
+		getter     *ResumableHttpGetter
+	)
+
+	stagingPath := func(src string, ext string) string {
+		sum := sha256.Sum256([]byte(src))
+		return filepath.Join(stagingDir, hex.EncodeToString(sum[:])+ext)
+	}
+
+	BeforeEach(func() {
+		var err error
+		stagingDir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+		outputDir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+
+		content = bytes.Repeat([]byte("0123456789"), 1024)
+		ranges = nil
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "cmdr", time.Time{}, bytes.NewReader(content))
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ranges = append(ranges, r.Header.Get("Range"))
+			handler(w, r)
+		}))
+		getter = NewResumableHttpGetter(stagingDir, server.Client())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(stagingDir)).To(Succeed())
+		Expect(os.RemoveAll(outputDir)).To(Succeed())
+	})
+
+	get := func() string {
+		src, err := url.Parse(server.URL + "/cmdr")
+		Expect(err).To(BeNil())
+
+		dst := filepath.Join(outputDir, "cmdr")
+		Expect(getter.GetFile(dst, src)).To(Succeed())
+
+		return dst
+	}
+
+	writePartial := func(size int, etag string) {
+		src := server.URL + "/cmdr"
+		Expect(os.WriteFile(stagingPath(src, ".part"), content[:size], 0644)).To(Succeed())
+		Expect(os.WriteFile(stagingPath(src, ".json"), []byte(fmt.Sprintf(
+			`{"url": %q, "etag": %q, "size": %d}`, src, etag, len(content),
+		)), 0644)).To(Succeed())
+	}
+
+	It("should download the whole file", func() {
+		dst := get()
+
+		Expect(os.ReadFile(dst)).To(Equal(content))
+		Expect(ranges).To(Equal([]string{""}))
+		Expect(os.ReadDir(stagingDir)).To(BeEmpty())
+	})
+
+	It("should record the digest of the artifact", func() {
+		recorder := NewArtifactRecorder()
+		getter.SetClient(&gogetter.Client{Ctx: WithArtifactRecorder(context.Background(), recorder)})
+
+		get()
+
+		sum := sha256.Sum256(content)
+		Expect(recorder.Artifacts()).To(Equal([]Artifact{{
+			URL:    server.URL + "/cmdr",
+			SHA256: hex.EncodeToString(sum[:]),
+		}}))
+	})
+
+	It("should keep the artifact", func() {
+		recorder := NewArtifactRecorder()
+		recorder.SetKeepDir(outputDir)
+		getter.SetClient(&gogetter.Client{Ctx: WithArtifactRecorder(context.Background(), recorder)})
+
+		dst := get()
+		Expect(os.Remove(dst)).To(Succeed())
+
+		artifacts := recorder.Artifacts()
+		Expect(artifacts).To(HaveLen(1))
+		Expect(os.ReadFile(artifacts[0].Path)).To(Equal(content))
+	})
+
+	It("should resume from the staging file", func() {
+		writePartial(4096, `"v1"`)
+
+		dst := get()
+
+		Expect(os.ReadFile(dst)).To(Equal(content))
+		Expect(ranges).To(Equal([]string{"bytes=4096-"}))
+		Expect(os.ReadDir(stagingDir)).To(BeEmpty())
+	})
+
+	It("should restart when the remote file changed", func() {
+		writePartial(4096, `"v0"`)
+
+		dst := get()
+
+		Expect(os.ReadFile(dst)).To(Equal(content))
+		Expect(ranges).To(Equal([]string{"bytes=4096-"}))
+	})
+
+	It("should revalidate the completed staging file", func() {
+		writePartial(len(content), `"v1"`)
+
+		dst := get()
+
+		Expect(os.ReadFile(dst)).To(Equal(content))
+		Expect(ranges).To(Equal([]string{""}))
+		Expect(os.ReadDir(stagingDir)).To(BeEmpty())
+	})
+
+	It("should download again when the completed staging file is stale", func() {
+		writePartial(len(content), `"v0"`)
+		Expect(os.WriteFile(stagingPath(server.URL+"/cmdr", ".part"), bytes.Repeat([]byte("x"), len(content)), 0644)).To(Succeed())
+
+		dst := get()
+
+		Expect(os.ReadFile(dst)).To(Equal(content))
+	})
+
+	It("should not save the credentials of the url", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			_, _ = w.Write(content[:10])
+		}
+
+		src, err := url.Parse(server.URL + "/cmdr?token=secret")
+		Expect(err).To(BeNil())
+		src.User = url.UserPassword("user", "password")
+
+		Expect(getter.GetFile(filepath.Join(outputDir, "cmdr"), src)).NotTo(Succeed())
+
+		meta, err := os.ReadFile(stagingPath(src.String(), ".json"))
+		Expect(err).To(BeNil())
+		Expect(string(meta)).NotTo(ContainSubstring("secret"))
+		Expect(string(meta)).NotTo(ContainSubstring("password"))
+	})
+
+	It("should clean the expired staging files", func() {
+		writePartial(4096, `"v1"`)
+		expired := time.Now().Add(-2 * time.Hour)
+
+		other := filepath.Join(stagingDir, "other.part")
+		Expect(os.WriteFile(other, []byte("other"), 0644)).To(Succeed())
+		Expect(os.Chtimes(other, expired, expired)).To(Succeed())
+
+		removed, err := CleanStaging(stagingDir, time.Hour)
+		Expect(err).To(BeNil())
+		Expect(removed).To(Equal([]string{other}))
+		Expect(stagingPath(server.URL+"/cmdr", ".part")).To(BeARegularFile())
+
+		Expect(os.WriteFile(other, []byte("other"), 0644)).To(Succeed())
+		Expect(os.Chtimes(other, expired, expired)).To(Succeed())
+		getter.SetStagingTTL(time.Hour)
+		get()
+		Expect(other).NotTo(BeAnExistingFile())
+	})
+
+	It("should restart when the server does not support ranges", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(content)
+		}
+		writePartial(4096, `"v1"`)
+
+		dst := get()
+
+		Expect(os.ReadFile(dst)).To(Equal(content))
+	})
+
+	It("should keep the staging file when download failed", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+			_, _ = w.Write(content[:2048])
+		}
+
+		src, err := url.Parse(server.URL + "/cmdr")
+		Expect(err).To(BeNil())
+		Expect(getter.GetFile(filepath.Join(outputDir, "cmdr"), src)).NotTo(Succeed())
+
+		Expect(os.ReadFile(stagingPath(src.String(), ".part"))).To(Equal(content[:2048]))
+	})
+
+	Context("with basic auth", func() {
+		BeforeEach(func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				user, password, ok := r.BasicAuth()
+				if !ok || user != "cmdr" || password != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				http.ServeContent(w, r, "cmdr", time.Time{}, bytes.NewReader(content))
+			}
+
+			host, err := url.Parse(server.URL)
+			Expect(err).To(BeNil())
+
+			netrcPath := filepath.Join(outputDir, ".netrc")
+			Expect(os.WriteFile(netrcPath, []byte(fmt.Sprintf(
+				"machine %s login cmdr password secret\n", host.Host,
+			)), 0600)).To(Succeed())
+			Expect(os.Setenv("NETRC", netrcPath)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.Unsetenv("NETRC")).To(Succeed())
+		})
+
+		It("should authorize by the netrc file", func() {
+			dst := get()
+
+			Expect(os.ReadFile(dst)).To(Equal(content))
+		})
+
+	})
+})
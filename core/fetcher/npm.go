@@ -0,0 +1,84 @@
+package fetcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NpmInstaller installs node packages by npm://pkg[@version], the package is kept under the root dir
+// and entry shims are written into the download dir
+type NpmInstaller struct {
+	npmPath string
+	scheme  string
+	rootDir string
+	offline bool
+}
+
+// SetOffline makes npm install packages from the local cache only
+func (n *NpmInstaller) SetOffline(offline bool) {
+	n.offline = offline
+}
+
+func (n *NpmInstaller) IsSupport(uri string) bool {
+	return strings.HasPrefix(uri, n.scheme)
+}
+
+func (n *NpmInstaller) Fetch(name, version, uri, dst string) error {
+	pkg, version, err := splitPackageVersion(strings.TrimPrefix(uri, n.scheme), version)
+	if err != nil {
+		return err
+	}
+
+	prefix := packageDir(n.rootDir, NpmPackagesDir, pkg, version)
+	err = os.RemoveAll(prefix)
+	if err != nil {
+		return errors.Wrapf(err, "remove %s failed", prefix)
+	}
+
+	spec := pkg
+	if version != "" {
+		spec = fmt.Sprintf("%s@%s", pkg, version)
+	}
+
+	args := []string{"install", "--global", "--prefix", prefix}
+	if n.offline {
+		args = append(args, "--offline")
+	}
+
+	err = runPackageInstaller(nil, n.npmPath, append(args, spec)...)
+	if err != nil {
+		return err
+	}
+
+	// a global install only links the bins of the package itself
+	binDir := filepath.Join(prefix, "bin")
+	executables, err := listExecutables(binDir)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(executables))
+	for executable := range executables {
+		names = append(names, executable)
+	}
+	sort.Strings(names)
+
+	return writeEntryShims(dst, binDir, names)
+}
+
+func NewNpmInstaller(npmPath, scheme, rootDir string) *NpmInstaller {
+	return &NpmInstaller{
+		npmPath: npmPath,
+		scheme:  scheme,
+		rootDir: rootDir,
+	}
+}
+
+func NewDefaultNpmInstaller(rootDir string) *NpmInstaller {
+	return NewNpmInstaller("npm", "npm://", rootDir)
+}
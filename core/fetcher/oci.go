@@ -0,0 +1,601 @@
+package fetcher
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/strategy"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+const (
+	ociMediaTypeIndex        = "application/vnd.oci.image.index.v1+json"
+	ociMediaTypeManifest     = "application/vnd.oci.image.manifest.v1+json"
+	dockerMediaTypeList      = "application/vnd.docker.distribution.manifest.list.v2+json"
+	dockerMediaTypeManifest  = "application/vnd.docker.distribution.manifest.v2+json"
+	ociAnnotationTitle       = "org.opencontainers.image.title"
+	ociDefaultTag            = "latest"
+	dockerHubRegistry        = "docker.io"
+	dockerHubRegistryAPIHost = "registry-1.docker.io"
+	ociManifestSizeLimit     = 4 << 20
+)
+
+var (
+	ErrOCIUnauthorized     = errors.New("oci registry unauthorized")
+	ErrOCIPlatformNotFound = errors.New("oci platform not found")
+	ErrOCILayerNotFound    = errors.New("oci layer not found")
+	ErrOCIDigestMismatch   = errors.New("oci digest mismatch")
+)
+
+// OCIReference is parsed from oci://registry/repository[:tag|@digest][?media_type=...&title=...&annotation=key=value]
+type OCIReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+	MediaType  string
+	Title      string
+	Annotation string
+}
+
+// Reference returns the digest if set, otherwise the tag
+func (r *OCIReference) Reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+
+	return r.Tag
+}
+
+func ParseOCIReference(uri string) (*OCIReference, error) {
+	location, rawQuery, _ := strings.Cut(strings.TrimPrefix(uri, "oci://"), "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse query of %s failed", uri)
+	}
+
+	registry, repository, ok := strings.Cut(location, "/")
+	if !ok || registry == "" || repository == "" {
+		return nil, fmt.Errorf("invalid oci reference %s", uri)
+	}
+
+	ref := &OCIReference{
+		Registry:   registry,
+		MediaType:  query.Get("media_type"),
+		Title:      query.Get("title"),
+		Annotation: query.Get("annotation"),
+	}
+
+	if idx := strings.Index(repository, "@"); idx >= 0 {
+		ref.Digest = repository[idx+1:]
+		repository = repository[:idx]
+	}
+
+	// the tag is after the last colon of the last path segment
+	if idx := strings.LastIndex(repository, ":"); idx > strings.LastIndex(repository, "/") {
+		ref.Tag = repository[idx+1:]
+		repository = repository[:idx]
+	}
+
+	ref.Repository = repository
+	if registry == dockerHubRegistry && !strings.Contains(repository, "/") {
+		ref.Repository = "library/" + repository
+	}
+
+	return ref, nil
+}
+
+type ociPlatform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *ociPlatform      `json:"platform,omitempty"`
+}
+
+func (d *ociDescriptor) matchPlatform() bool {
+	return d.Platform != nil && d.Platform.OS == runtime.GOOS && d.Platform.Architecture == runtime.GOARCH
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests,omitempty"`
+	Layers    []ociDescriptor `json:"layers,omitempty"`
+}
+
+func (m *ociManifest) isIndex() bool {
+	return m.MediaType == ociMediaTypeIndex || m.MediaType == dockerMediaTypeList || len(m.Manifests) > 0
+}
+
+// ociSession talks to a repository of a registry, the authorization is reused by the requests
+type ociSession struct {
+	fetcher       *OCIFetcher
+	baseURL       string
+	ref           *OCIReference
+	authorization string
+}
+
+func (s *ociSession) authorize(resp *http.Response) error {
+	credential, err := s.fetcher.dockerConfig.Lookup(s.ref.Registry)
+	if err != nil {
+		return err
+	}
+
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	switch scheme {
+	case "bearer":
+		token, err := s.fetcher.requestToken(params, credential)
+		if err != nil {
+			return err
+		}
+
+		s.authorization = "Bearer " + token
+	case "basic":
+		if credential == nil {
+			return errors.Wrapf(ErrOCIUnauthorized, "no credential of %s", s.ref.Registry)
+		}
+
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(credential.Username, credential.Password)
+		s.authorization = req.Header.Get("Authorization")
+	default:
+		return errors.Wrapf(ErrOCIUnauthorized, "unsupported challenge %s", scheme)
+	}
+
+	return nil
+}
+
+func (s *ociSession) get(path string, accepts ...string) (*http.Response, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		req, err := http.NewRequestWithContext(s.fetcher.context(), http.MethodGet, s.baseURL+path, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "create request failed")
+		}
+
+		for _, accept := range accepts {
+			req.Header.Add("Accept", accept)
+		}
+
+		if s.authorization != "" {
+			req.Header.Set("Authorization", s.authorization)
+		}
+
+		resp, err := s.fetcher.client.Do(req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "request %s failed", req.URL)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return nil, fmt.Errorf("request %s failed: %s", req.URL, resp.Status)
+		}
+
+		err = s.authorize(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, errors.Wrapf(ErrOCIUnauthorized, "request %s%s", s.baseURL, path)
+}
+
+// getManifest verifies the manifest by the digest reference, or by the digest the registry tells for a tag
+func (s *ociSession) getManifest(reference string) (*ociManifest, error) {
+	resp, err := s.get(
+		fmt.Sprintf("/v2/%s/manifests/%s", s.ref.Repository, reference),
+		ociMediaTypeIndex, ociMediaTypeManifest, dockerMediaTypeList, dockerMediaTypeManifest,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, ociManifestSizeLimit+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read manifest %s failed", reference)
+	}
+
+	if len(data) > ociManifestSizeLimit {
+		return nil, fmt.Errorf("manifest %s is larger than %d bytes", reference, ociManifestSizeLimit)
+	}
+
+	// tags can't contain colons, so a reference with a colon is a digest
+	digest := reference
+	if !strings.Contains(reference, ":") {
+		digest = resp.Header.Get("Docker-Content-Digest")
+	}
+
+	if digest != "" {
+		hasher, expected, err := newDigestHash(digest)
+		if err != nil {
+			return nil, err
+		}
+
+		_, _ = hasher.Write(data)
+		err = checkDigest(hasher, expected)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "manifest %s", reference)
+		}
+	}
+
+	var manifest ociManifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse manifest %s failed", reference)
+	}
+
+	return &manifest, nil
+}
+
+// resolve returns the manifest of the current platform when the reference is an index
+func (s *ociSession) resolve() (*ociManifest, error) {
+	manifest, err := s.getManifest(s.ref.Reference())
+	if err != nil {
+		return nil, err
+	}
+
+	if !manifest.isIndex() {
+		return manifest, nil
+	}
+
+	for _, descriptor := range manifest.Manifests {
+		if descriptor.matchPlatform() {
+			return s.getManifest(descriptor.Digest)
+		}
+	}
+
+	return nil, errors.Wrapf(ErrOCIPlatformNotFound, "%s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+func newDigestHash(digest string) (hash.Hash, string, error) {
+	algorithm, encoded, _ := strings.Cut(digest, ":")
+	switch algorithm {
+	case "sha256":
+		return sha256.New(), encoded, nil
+	case "sha512":
+		return sha512.New(), encoded, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported digest %s", digest)
+	}
+}
+
+func checkDigest(hasher hash.Hash, expected string) error {
+	actual := hex.EncodeToString(hasher.Sum(nil))
+	if actual != expected {
+		return errors.Wrapf(ErrOCIDigestMismatch, "expected %s, got %s", expected, actual)
+	}
+
+	return nil
+}
+
+func (s *ociSession) downloadBlob(descriptor *ociDescriptor, path string) error {
+	hasher, expected, err := newDigestHash(descriptor.Digest)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.get(fmt.Sprintf("/v2/%s/blobs/%s", s.ref.Repository, descriptor.Digest))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "create file %s failed", path)
+	}
+	defer utils.CallClose(file)
+
+	_, err = io.Copy(io.MultiWriter(file, hasher), resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "download blob %s failed", descriptor.Digest)
+	}
+
+	return checkDigest(hasher, expected)
+}
+
+// OCIFetcher fetches binaries published as OCI artifacts
+type OCIFetcher struct {
+	scheme             string
+	client             *http.Client
+	dockerConfig       *DockerConfig
+	insecureRegistries []string
+	offline            bool
+	ctx                context.Context
+}
+
+func (f *OCIFetcher) IsSupport(uri string) bool {
+	return strings.HasPrefix(uri, f.scheme)
+}
+
+func (f *OCIFetcher) SetOffline(offline bool) {
+	f.offline = offline
+}
+
+// SetContext sets the context of the requests, which carries the current download strategy
+func (f *OCIFetcher) SetContext(ctx context.Context) {
+	f.ctx = ctx
+}
+
+func (f *OCIFetcher) context() context.Context {
+	if f.ctx == nil {
+		return context.Background()
+	}
+
+	return f.ctx
+}
+
+func (f *OCIFetcher) SetInsecureRegistries(registries []string) {
+	f.insecureRegistries = registries
+}
+
+func (f *OCIFetcher) baseURL(registry string) string {
+	if registry == dockerHubRegistry {
+		return "https://" + dockerHubRegistryAPIHost
+	}
+
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return "http://" + registry
+	}
+
+	for _, insecure := range f.insecureRegistries {
+		if insecure == registry || insecure == host {
+			return "http://" + registry
+		}
+	}
+
+	return "https://" + registry
+}
+
+func (f *OCIFetcher) selectLayer(name string, ref *OCIReference, layers []ociDescriptor) (*ociDescriptor, error) {
+	annotationKey, annotationValue, _ := strings.Cut(ref.Annotation, "=")
+	candidates := make([]*ociDescriptor, 0, len(layers))
+
+	for idx := range layers {
+		layer := &layers[idx]
+		if ref.MediaType != "" && layer.MediaType != ref.MediaType {
+			continue
+		}
+
+		if ref.Title != "" && layer.Annotations[ociAnnotationTitle] != ref.Title {
+			continue
+		}
+
+		if annotationKey != "" && layer.Annotations[annotationKey] != annotationValue {
+			continue
+		}
+
+		candidates = append(candidates, layer)
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.Wrapf(ErrOCILayerNotFound, "no layer matched in %s", ref.Repository)
+	}
+
+	var (
+		selected  = candidates[0]
+		bestScore = -1.0
+	)
+
+	for _, layer := range candidates {
+		title := strings.ToLower(layer.Annotations[ociAnnotationTitle])
+		score := 0.0
+
+		if layer.matchPlatform() {
+			score += 2
+		}
+		if strings.Contains(title, runtime.GOOS) {
+			score += 1
+		}
+		if strings.Contains(title, runtime.GOARCH) {
+			score += 1
+		}
+		if name != "" && strings.Contains(title, strings.ToLower(name)) {
+			score += 0.5
+		}
+
+		if score > bestScore {
+			selected = layer
+			bestScore = score
+		}
+	}
+
+	return selected, nil
+}
+
+func isTarLayer(layer *ociDescriptor) bool {
+	title := layer.Annotations[ociAnnotationTitle]
+	return strings.Contains(layer.MediaType, "tar") ||
+		strings.HasSuffix(title, ".tar") ||
+		strings.HasSuffix(title, ".tar.gz") ||
+		strings.HasSuffix(title, ".tgz")
+}
+
+func extractTar(path, dst string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "open %s failed", path)
+	}
+	defer utils.CallClose(file)
+
+	var reader io.Reader = bufio.NewReader(file)
+	magic, err := reader.(*bufio.Reader).Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return errors.Wrapf(err, "open gzip %s failed", path)
+		}
+		defer utils.CallClose(gzipReader)
+
+		reader = gzipReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrapf(err, "read tar %s failed", path)
+		}
+
+		target := filepath.Join(dst, header.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal path %s in tar", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = extractTarFile(tarReader, target, os.FileMode(header.Mode)&os.ModePerm)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractTarFile(reader io.Reader, target string, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "create dir of %s failed", target)
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return errors.Wrapf(err, "create file %s failed", target)
+	}
+	defer utils.CallClose(file)
+
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		return errors.Wrapf(err, "extract file %s failed", target)
+	}
+
+	return nil
+}
+
+func (f *OCIFetcher) Fetch(name, version, uri, dst string) error {
+	logger := core.GetLogger()
+	if f.offline {
+		return errors.Wrapf(core.ErrOffline, "fetch %s", uri)
+	}
+
+	ref, err := ParseOCIReference(uri)
+	if err != nil {
+		return err
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		// the command version is used as the tag by default
+		ref.Tag = version
+		if ref.Tag == "" {
+			ref.Tag = ociDefaultTag
+		}
+	}
+
+	session := &ociSession{
+		fetcher: f,
+		baseURL: f.baseURL(ref.Registry),
+		ref:     ref,
+	}
+
+	manifest, err := session.resolve()
+	if err != nil {
+		return errors.Wrapf(err, "resolve manifest of %s failed", uri)
+	}
+
+	layer, err := f.selectLayer(name, ref, manifest.Layers)
+	if err != nil {
+		return err
+	}
+
+	fileName := filepath.Base(layer.Annotations[ociAnnotationTitle])
+	if fileName == "." || fileName == string(os.PathSeparator) {
+		fileName = name
+	}
+
+	logger.Info("downloading oci layer", map[string]interface{}{
+		"repository": ref.Repository,
+		"digest":     layer.Digest,
+		"media_type": layer.MediaType,
+		"title":      fileName,
+	})
+
+	err = os.MkdirAll(dst, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "create dir %s failed", dst)
+	}
+
+	path := filepath.Join(dst, fileName)
+	err = session.downloadBlob(layer, path)
+	if err != nil {
+		return err
+	}
+
+	if !isTarLayer(layer) {
+		return nil
+	}
+
+	err = extractTar(path, dst)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func NewOCIFetcher(scheme string, client *http.Client, dockerConfig *DockerConfig) *OCIFetcher {
+	return &OCIFetcher{
+		scheme:       scheme,
+		client:       client,
+		dockerConfig: dockerConfig,
+	}
+}
+
+func NewDefaultOCIFetcher() (*OCIFetcher, error) {
+	dockerConfig, err := LoadDockerConfig(getDefaultDockerConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOCIFetcher("oci://", &http.Client{
+		Transport: strategy.NewTransport(http.DefaultTransport),
+	}, dockerConfig), nil
+}
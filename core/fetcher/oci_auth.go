@@ -0,0 +1,246 @@
+package fetcher
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	dockerHubAuthHost   = "index.docker.io"
+	dockerHubAuthServer = "https://index.docker.io/v1/"
+)
+
+// DockerConfig is the credential part of ~/.docker/config.json
+type DockerConfig struct {
+	Auths       map[string]DockerAuth `json:"auths"`
+	CredsStore  string                `json:"credsStore"`
+	CredHelpers map[string]string     `json:"credHelpers"`
+}
+
+type DockerAuth struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type registryCredential struct {
+	Username string
+	Password string
+}
+
+// dockerConfigHost returns the registry of a key of the docker config,
+// the docker cli saves the credential of docker hub as https://index.docker.io/v1/
+func dockerConfigHost(key string) string {
+	host := key
+	if parsed, err := url.Parse(key); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+
+	switch host {
+	case dockerHubAuthHost, dockerHubRegistryAPIHost:
+		return dockerHubRegistry
+	default:
+		return host
+	}
+}
+
+func (c *DockerConfig) lookupAuths(registry string) (*registryCredential, error) {
+	registry = dockerConfigHost(registry)
+	for key, auth := range c.Auths {
+		if dockerConfigHost(key) != registry {
+			continue
+		}
+
+		if auth.Auth == "" {
+			return &registryCredential{Username: auth.Username, Password: auth.Password}, nil
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decode auth of %s failed", key)
+		}
+
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid auth of %s", key)
+		}
+
+		return &registryCredential{Username: username, Password: password}, nil
+	}
+
+	return nil, nil
+}
+
+func (c *DockerConfig) lookupHelper(helper, registry string) (*registryCredential, error) {
+	cmd := exec.Command(fmt.Sprintf("docker-credential-%s", helper), "get")
+	cmd.Stdin = strings.NewReader(registry)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "get credential of %s by helper %s failed", registry, helper)
+	}
+
+	var result struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	err = json.Unmarshal(bytes.TrimSpace(output), &result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse credential of %s failed", registry)
+	}
+
+	return &registryCredential{Username: result.Username, Password: result.Secret}, nil
+}
+
+// Lookup returns the credential of registry, nil means anonymous
+func (c *DockerConfig) Lookup(registry string) (*registryCredential, error) {
+	// the credential helpers know docker hub by the server url of the docker cli
+	server := registry
+	if dockerConfigHost(registry) == dockerHubRegistry {
+		server = dockerHubAuthServer
+	}
+
+	for key, helper := range c.CredHelpers {
+		if dockerConfigHost(key) == dockerConfigHost(registry) {
+			return c.lookupHelper(helper, server)
+		}
+	}
+
+	credential, err := c.lookupAuths(registry)
+	if credential != nil || err != nil {
+		return credential, err
+	}
+
+	if c.CredsStore != "" {
+		return c.lookupHelper(c.CredsStore, server)
+	}
+
+	return nil, nil
+}
+
+func getDefaultDockerConfigPath() string {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(homeDir, ".docker")
+	}
+
+	return filepath.Join(dir, "config.json")
+}
+
+// LoadDockerConfig loads the docker config, an empty one is returned when the file does not exist
+func LoadDockerConfig(path string) (*DockerConfig, error) {
+	config := &DockerConfig{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "read docker config %s failed", path)
+	}
+
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse docker config %s failed", path)
+	}
+
+	return config, nil
+}
+
+// parseChallenge parses a WWW-Authenticate header like: Bearer realm="...",service="...",scope="...",
+// the quoted values may contain commas, like the scopes of several actions
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(header, " ")
+	params := make(map[string]string)
+
+	for {
+		rest = strings.TrimLeft(rest, ", ")
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+
+		var param strings.Builder
+		if strings.HasPrefix(value, `"`) {
+			idx := 1
+			for ; idx < len(value) && value[idx] != '"'; idx++ {
+				if value[idx] == '\\' && idx+1 < len(value) {
+					idx++
+				}
+				param.WriteByte(value[idx])
+			}
+			rest = value[min(idx+1, len(value)):]
+		} else {
+			token, remain, _ := strings.Cut(value, ",")
+			param.WriteString(strings.TrimSpace(token))
+			rest = remain
+		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = param.String()
+	}
+
+	return strings.ToLower(scheme), params
+}
+
+func (f *OCIFetcher) requestToken(params map[string]string, credential *registryCredential) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(f.context(), http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "create token request failed")
+	}
+
+	if credential != nil {
+		req.SetBasicAuth(credential.Username, credential.Password)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "request token failed")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Wrapf(ErrOCIUnauthorized, "request token: %s", resp.Status)
+	}
+
+	var result struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse token failed")
+	}
+
+	if result.Token != "" {
+		return result.Token, nil
+	}
+
+	return result.AccessToken, nil
+}
@@ -0,0 +1,352 @@
+package fetcher_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	. "github.com/mrlyc/cmdr/core/fetcher"
+	"github.com/mrlyc/cmdr/core/strategy"
+)
+
+var _ = Describe("OCIFetcher", func() {
+	DescribeTable("ParseOCIReference", func(uri string, expected OCIReference) {
+		ref, err := ParseOCIReference(uri)
+		Expect(err).To(BeNil())
+		Expect(*ref).To(Equal(expected))
+	},
+		Entry("tag", "oci://ghcr.io/mrlyc/cmdr:v1.0.0", OCIReference{
+			Registry: "ghcr.io", Repository: "mrlyc/cmdr", Tag: "v1.0.0",
+		}),
+		Entry("digest", "oci://localhost:5000/cmdr@sha256:abc", OCIReference{
+			Registry: "localhost:5000", Repository: "cmdr", Digest: "sha256:abc",
+		}),
+		Entry("no tag", "oci://localhost:5000/tools/cmdr", OCIReference{
+			Registry: "localhost:5000", Repository: "tools/cmdr",
+		}),
+		Entry("docker hub", "oci://docker.io/cmdr:1", OCIReference{
+			Registry: "docker.io", Repository: "library/cmdr", Tag: "1",
+		}),
+		Entry("query", "oci://ghcr.io/cmdr:1?media_type=application/x&title=cmdr&annotation=a=b", OCIReference{
+			Registry: "ghcr.io", Repository: "cmdr", Tag: "1",
+			MediaType: "application/x", Title: "cmdr", Annotation: "a=b",
+		}),
+	)
+
+	Context("Fetch", func() {
+		var (
+			server       *httptest.Server
+			registry     string
+			blobs        map[string][]byte
+			manifests    map[string][]byte
+			digests      map[string]string
+			tokenCalls   int
+			dockerConfig *DockerConfig
+			fetcher      *OCIFetcher
+			outputDir    string
+		)
+
+		digestOf := func(data []byte) string {
+			sum := sha256.Sum256(data)
+			return "sha256:" + hex.EncodeToString(sum[:])
+		}
+
+		addBlob := func(data []byte) string {
+			digest := digestOf(data)
+			blobs[digest] = data
+			return digest
+		}
+
+		addManifest := func(reference string, manifest map[string]interface{}) string {
+			data, err := json.Marshal(manifest)
+			Expect(err).To(BeNil())
+
+			digest := digestOf(data)
+			manifests[digest] = data
+			if reference != "" {
+				manifests[reference] = data
+				digests[reference] = digest
+			}
+			return digest
+		}
+
+		layer := func(mediaType, title string, data []byte) map[string]interface{} {
+			return map[string]interface{}{
+				"mediaType":   mediaType,
+				"digest":      addBlob(data),
+				"size":        len(data),
+				"annotations": map[string]string{"org.opencontainers.image.title": title},
+			}
+		}
+
+		BeforeEach(func() {
+			var err error
+			outputDir, err = os.MkdirTemp("", "")
+			Expect(err).To(BeNil())
+
+			blobs = make(map[string][]byte)
+			manifests = make(map[string][]byte)
+			digests = make(map[string]string)
+			tokenCalls = 0
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/token" {
+					username, password, ok := r.BasicAuth()
+					if !ok || username != "user" || password != "pass" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+
+					Expect(r.URL.Query().Get("scope")).To(Equal("repository:tools/cmdr:pull,push"))
+					tokenCalls++
+					_, _ = fmt.Fprint(w, `{"token": "secret"}`)
+					return
+				}
+
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+						`Bearer realm="%s/token",service="registry",scope="repository:tools/cmdr:pull,push"`, server.URL,
+					))
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				var (
+					data  []byte
+					found bool
+				)
+				if reference := strings.TrimPrefix(r.URL.Path, "/v2/tools/cmdr/manifests/"); reference != r.URL.Path {
+					data, found = manifests[reference]
+					if digest, ok := digests[reference]; ok {
+						w.Header().Set("Docker-Content-Digest", digest)
+					}
+				} else if digest := strings.TrimPrefix(r.URL.Path, "/v2/tools/cmdr/blobs/"); digest != r.URL.Path {
+					data, found = blobs[digest]
+				}
+
+				if !found {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				_, _ = w.Write(data)
+			}))
+			registry = strings.TrimPrefix(server.URL, "http://")
+
+			dockerConfig = &DockerConfig{Auths: map[string]DockerAuth{
+				registry: {Auth: base64.StdEncoding.EncodeToString([]byte("user:pass"))},
+			}}
+			fetcher = NewOCIFetcher("oci://", server.Client(), dockerConfig)
+		})
+
+		AfterEach(func() {
+			server.Close()
+			Expect(os.RemoveAll(outputDir)).To(Succeed())
+		})
+
+		It("should support oci scheme", func() {
+			Expect(fetcher.IsSupport("oci://ghcr.io/mrlyc/cmdr:v1")).To(BeTrue())
+			Expect(fetcher.IsSupport("https://ghcr.io/mrlyc/cmdr")).To(BeFalse())
+		})
+
+		It("should fetch the layer of the current platform", func() {
+			addManifest("1.0.0", map[string]interface{}{
+				"mediaType": "application/vnd.oci.image.manifest.v1+json",
+				"layers": []interface{}{
+					layer("application/octet-stream", "cmdr-plan9-mips", []byte("other")),
+					layer("application/octet-stream", fmt.Sprintf("cmdr-%s-%s", runtime.GOOS, runtime.GOARCH), []byte("cmdr")),
+				},
+			})
+
+			Expect(fetcher.Fetch("cmdr", "1.0.0", fmt.Sprintf("oci://%s/tools/cmdr", registry), outputDir)).To(Succeed())
+
+			path := filepath.Join(outputDir, fmt.Sprintf("cmdr-%s-%s", runtime.GOOS, runtime.GOARCH))
+			Expect(os.ReadFile(path)).To(Equal([]byte("cmdr")))
+			Expect(tokenCalls).To(Equal(1))
+		})
+
+		It("should resolve index and extract tar layer", func() {
+			var buf bytes.Buffer
+			gzipWriter := gzip.NewWriter(&buf)
+			tarWriter := tar.NewWriter(gzipWriter)
+			Expect(tarWriter.WriteHeader(&tar.Header{Name: "bin/cmdr", Mode: 0755, Size: 4, Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tarWriter.Write([]byte("cmdr"))
+			Expect(err).To(BeNil())
+			Expect(tarWriter.Close()).To(Succeed())
+			Expect(gzipWriter.Close()).To(Succeed())
+
+			other := addManifest("", map[string]interface{}{
+				"layers": []interface{}{layer("application/octet-stream", "other", []byte("other"))},
+			})
+			current := addManifest("", map[string]interface{}{
+				"layers": []interface{}{layer("application/vnd.oci.image.layer.v1.tar+gzip", "cmdr.tar.gz", buf.Bytes())},
+			})
+			index := addManifest("", map[string]interface{}{
+				"mediaType": "application/vnd.oci.image.index.v1+json",
+				"manifests": []interface{}{
+					map[string]interface{}{"digest": other, "platform": map[string]string{"os": "plan9", "architecture": "mips"}},
+					map[string]interface{}{"digest": current, "platform": map[string]string{"os": runtime.GOOS, "architecture": runtime.GOARCH}},
+				},
+			})
+
+			Expect(fetcher.Fetch("cmdr", "1.0.0", fmt.Sprintf("oci://%s/tools/cmdr@%s", registry, index), outputDir)).To(Succeed())
+
+			info, err := os.Stat(filepath.Join(outputDir, "bin", "cmdr"))
+			Expect(err).To(BeNil())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+			Expect(filepath.Join(outputDir, "cmdr.tar.gz")).NotTo(BeAnExistingFile())
+		})
+
+		It("should select layer by query", func() {
+			addManifest("latest", map[string]interface{}{
+				"layers": []interface{}{
+					layer("application/vnd.cmdr.binary", fmt.Sprintf("cmdr-%s-%s", runtime.GOOS, runtime.GOARCH), []byte("cmdr")),
+					layer("application/vnd.cmdr.doc", "README.md", []byte("readme")),
+				},
+			})
+
+			uri := fmt.Sprintf("oci://%s/tools/cmdr:latest?media_type=application/vnd.cmdr.doc", registry)
+			Expect(fetcher.Fetch("cmdr", "1.0.0", uri, outputDir)).To(Succeed())
+			Expect(os.ReadFile(filepath.Join(outputDir, "README.md"))).To(Equal([]byte("readme")))
+
+			uri = fmt.Sprintf("oci://%s/tools/cmdr:latest?title=missing", registry)
+			err := fetcher.Fetch("cmdr", "1.0.0", uri, outputDir)
+			Expect(errors.Is(err, ErrOCILayerNotFound)).To(BeTrue())
+		})
+
+		It("should verify the digest", func() {
+			descriptor := layer("application/octet-stream", "cmdr", []byte("cmdr"))
+			blobs[descriptor["digest"].(string)] = []byte("evil")
+			addManifest("latest", map[string]interface{}{
+				"layers": []interface{}{descriptor},
+			})
+
+			err := fetcher.Fetch("cmdr", "", fmt.Sprintf("oci://%s/tools/cmdr", registry), outputDir)
+			Expect(errors.Is(err, ErrOCIDigestMismatch)).To(BeTrue())
+		})
+
+		It("should verify the manifest by the digest reference", func() {
+			current := addManifest("", map[string]interface{}{
+				"layers": []interface{}{layer("application/octet-stream", "cmdr", []byte("cmdr"))},
+			})
+			index := addManifest("", map[string]interface{}{
+				"manifests": []interface{}{
+					map[string]interface{}{"digest": current, "platform": map[string]string{"os": runtime.GOOS, "architecture": runtime.GOARCH}},
+				},
+			})
+			manifests[current] = []byte(`{"layers": []}`)
+
+			err := fetcher.Fetch("cmdr", "", fmt.Sprintf("oci://%s/tools/cmdr@%s", registry, index), outputDir)
+			Expect(errors.Is(err, ErrOCIDigestMismatch)).To(BeTrue())
+
+			manifests[index] = []byte(`{"manifests": []}`)
+			err = fetcher.Fetch("cmdr", "", fmt.Sprintf("oci://%s/tools/cmdr@%s", registry, index), outputDir)
+			Expect(errors.Is(err, ErrOCIDigestMismatch)).To(BeTrue())
+		})
+
+		It("should verify the manifest of a tag by the registry digest", func() {
+			addManifest("latest", map[string]interface{}{
+				"layers": []interface{}{layer("application/octet-stream", "cmdr", []byte("cmdr"))},
+			})
+			manifests["latest"] = []byte(`{"layers": []}`)
+
+			err := fetcher.Fetch("cmdr", "", fmt.Sprintf("oci://%s/tools/cmdr", registry), outputDir)
+			Expect(errors.Is(err, ErrOCIDigestMismatch)).To(BeTrue())
+		})
+
+		It("should send the requests by the strategy of the context", func() {
+			addManifest("latest", map[string]interface{}{
+				"layers": []interface{}{layer("application/octet-stream", "cmdr", []byte("cmdr"))},
+			})
+
+			cfg := viper.New()
+			cfg.Set("download.proxy.enabled", true)
+			cfg.Set("download.proxy.type", "http")
+			cfg.Set("download.proxy.address", server.URL)
+
+			proxy := strategy.NewProxyStrategy()
+			Expect(proxy.Configure(cfg)).To(Succeed())
+
+			// the registry is only reachable by the proxy, which is the test server
+			fetcher = NewOCIFetcher("oci://", &http.Client{Transport: strategy.NewTransport(http.DefaultTransport)}, &DockerConfig{
+				Auths: map[string]DockerAuth{"registry.invalid": {Username: "user", Password: "pass"}},
+			})
+			fetcher.SetInsecureRegistries([]string{"registry.invalid"})
+			fetcher.SetContext(strategy.WithStrategy(context.Background(), proxy))
+
+			Expect(fetcher.Fetch("cmdr", "", "oci://registry.invalid/tools/cmdr", outputDir)).To(Succeed())
+			Expect(os.ReadFile(filepath.Join(outputDir, "cmdr"))).To(Equal([]byte("cmdr")))
+		})
+
+		It("should fail without credential", func() {
+			fetcher = NewOCIFetcher("oci://", server.Client(), &DockerConfig{})
+
+			err := fetcher.Fetch("cmdr", "", fmt.Sprintf("oci://%s/tools/cmdr", registry), outputDir)
+			Expect(errors.Is(err, ErrOCIUnauthorized)).To(BeTrue())
+		})
+
+		It("should fail fast in offline mode", func() {
+			fetcher.SetOffline(true)
+
+			err := fetcher.Fetch("cmdr", "", fmt.Sprintf("oci://%s/tools/cmdr", registry), outputDir)
+			Expect(errors.Is(err, core.ErrOffline)).To(BeTrue())
+		})
+	})
+
+	Context("DockerConfig", func() {
+		It("should load credentials", func() {
+			dir, err := os.MkdirTemp("", "")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "config.json")
+			Expect(os.WriteFile(path, []byte(`{"auths": {
+				"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"},
+				"ghcr.io": {"username": "user", "password": "token"}
+			}}`), 0600)).To(Succeed())
+
+			config, err := LoadDockerConfig(path)
+			Expect(err).To(BeNil())
+
+			for _, registry := range []string{"docker.io", "index.docker.io"} {
+				credential, err := config.Lookup(registry)
+				Expect(err).To(BeNil())
+				Expect(credential.Username).To(Equal("user"))
+				Expect(credential.Password).To(Equal("pass"))
+			}
+
+			credential, err := config.Lookup("ghcr.io")
+			Expect(err).To(BeNil())
+			Expect(credential.Password).To(Equal("token"))
+
+			credential, err = config.Lookup("quay.io")
+			Expect(err).To(BeNil())
+			Expect(credential).To(BeNil())
+		})
+
+		It("should return empty config when missing", func() {
+			config, err := LoadDockerConfig("/not/exists/config.json")
+			Expect(err).To(BeNil())
+			Expect(config.Auths).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,123 @@
+package fetcher
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+var (
+	ErrPackageVersionConflict = errors.New("package version conflict")
+)
+
+// the dirs under the root dir which keep the packages of the installers
+const (
+	NpmPackagesDir  = ".npm"
+	PipxPackagesDir = ".pipx"
+)
+
+// splitPackageVersion splits location like pkg@version, the leading @ of npm scopes is kept,
+// the version of -v is used, the one of the location is only used when -v is empty
+func splitPackageVersion(location, version string) (string, string, error) {
+	index := strings.LastIndex(location, "@")
+	if index <= 0 {
+		return location, version, nil
+	}
+
+	pkg, pinned := location[:index], location[index+1:]
+	if version == "" {
+		return pkg, pinned, nil
+	}
+
+	if !versioning.Equal(pinned, version) {
+		return "", "", errors.Wrapf(ErrPackageVersionConflict, "%s pins %s, but the version is %s", pkg, pinned, version)
+	}
+
+	return pkg, version, nil
+}
+
+// packageDir returns a persistent directory for the package, which must outlive the download
+func packageDir(rootDir, kind, pkg, version string) string {
+	if version == "" {
+		version = "latest"
+	}
+
+	name := strings.NewReplacer("/", "_", "@", "").Replace(pkg)
+
+	return filepath.Join(rootDir, kind, fmt.Sprintf("%s_%s", name, version))
+}
+
+// runPackageInstaller runs the installer without a terminal, its output is logged instead
+func runPackageInstaller(envs []string, name string, args ...string) error {
+	logger := core.GetLogger()
+	logger.Info("running installer", map[string]interface{}{
+		"command": name,
+		"args":    args,
+	})
+
+	var output bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.Env = append(os.Environ(), envs...)
+
+	err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "run %s %s failed: %s", name, strings.Join(args, " "), bytes.TrimSpace(output.Bytes()))
+	}
+
+	logger.Debug("installer finished", map[string]interface{}{
+		"command": name,
+		"output":  string(bytes.TrimSpace(output.Bytes())),
+	})
+
+	return nil
+}
+
+func listExecutables(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return map[string]bool{}, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "read dir %s failed", dir)
+	}
+
+	executables := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		executables[entry.Name()] = true
+	}
+
+	return executables, nil
+}
+
+// writeEntryShims writes scripts into dst which exec the executables in dir
+func writeEntryShims(dst, dir string, executables []string) error {
+	if len(executables) == 0 {
+		return errors.Wrapf(core.ErrBinaryNotFound, "no executable found in %s", dir)
+	}
+
+	for _, name := range executables {
+		target := filepath.Join(dir, name)
+		quoted := "'" + strings.ReplaceAll(target, "'", `'\''`) + "'"
+		script := fmt.Sprintf("#!/bin/sh\nexec %s \"$@\"\n", quoted)
+
+		err := os.WriteFile(filepath.Join(dst, name), []byte(script), 0755)
+		if err != nil {
+			return errors.Wrapf(err, "write entry shim of %s failed", target)
+		}
+	}
+
+	return nil
+}
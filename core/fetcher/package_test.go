@@ -0,0 +1,168 @@
+package fetcher_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mrlyc/cmdr/core/fetcher"
+)
+
+var _ = Describe("Package installers", func() {
+	var (
+		tempDir   string
+		rootDir   string
+		outputDir string
+		argsFile  string
+	)
+
+	// writeToolchain writes a fake toolchain which records its arguments
+	writeToolchain := func(name, body string) string {
+		path := filepath.Join(tempDir, name)
+		script := "#!/bin/sh\necho \"$@\" >> " + argsFile + "\n" + body
+		Expect(os.WriteFile(path, []byte(script), 0755)).To(Succeed())
+		return path
+	}
+
+	readArgs := func() []string {
+		data, err := os.ReadFile(argsFile)
+		Expect(err).To(BeNil())
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	runShim := func(path string) string {
+		output, err := exec.Command(path, "--help").Output()
+		Expect(err).To(BeNil())
+		return strings.TrimSpace(string(output))
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+
+		rootDir = filepath.Join(tempDir, "shims")
+		outputDir = filepath.Join(tempDir, "output")
+		argsFile = filepath.Join(tempDir, "args")
+		Expect(os.MkdirAll(outputDir, 0755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	Context("CargoInstaller", func() {
+		var installer *CargoInstaller
+
+		BeforeEach(func() {
+			installer = NewCargoInstaller(writeToolchain("cargo", ""), "cargo://")
+		})
+
+		It("should support cargo scheme", func() {
+			Expect(installer.IsSupport("cargo://ripgrep")).To(BeTrue())
+			Expect(installer.IsSupport("go://ripgrep")).To(BeFalse())
+		})
+
+		It("should install the version from flag", func() {
+			Expect(installer.Fetch("rg", "14.1.0", "cargo://ripgrep", outputDir)).To(Succeed())
+			Expect(readArgs()).To(Equal([]string{
+				"install --root " + outputDir + " --version 14.1.0 ripgrep",
+			}))
+		})
+
+		It("should use the version in uri without the flag", func() {
+			installer.SetOffline(true)
+
+			Expect(installer.Fetch("rg", "", "cargo://ripgrep@13.0.0", outputDir)).To(Succeed())
+			Expect(readArgs()).To(Equal([]string{
+				"install --root " + outputDir + " --version 13.0.0 --offline ripgrep",
+			}))
+		})
+
+		It("should use the version from flag when the uri pins the same one", func() {
+			Expect(installer.Fetch("rg", "13.0.0", "cargo://ripgrep@13.0", outputDir)).To(Succeed())
+			Expect(readArgs()).To(Equal([]string{
+				"install --root " + outputDir + " --version 13.0.0 ripgrep",
+			}))
+		})
+
+		It("should fail when the versions of the uri and the flag differ", func() {
+			err := installer.Fetch("rg", "14.1.0", "cargo://ripgrep@13.0.0", outputDir)
+			Expect(errors.Is(err, ErrPackageVersionConflict)).To(BeTrue())
+		})
+	})
+
+	Context("NpmInstaller", func() {
+		var installer *NpmInstaller
+
+		BeforeEach(func() {
+			installer = NewNpmInstaller(writeToolchain("npm", `
+while [ "$1" != "--prefix" ]; do shift; done
+mkdir -p "$2/bin"
+printf '#!/bin/sh\necho prettier "$@"\n' > "$2/bin/prettier"
+chmod +x "$2/bin/prettier"
+`), "npm://", rootDir)
+		})
+
+		It("should install package and write entry shim", func() {
+			Expect(installer.Fetch("prettier", "3.0.0", "npm://@prettier/cli", outputDir)).To(Succeed())
+
+			prefix := filepath.Join(rootDir, ".npm", "prettier_cli_3.0.0")
+			Expect(readArgs()).To(Equal([]string{
+				"install --global --prefix " + prefix + " @prettier/cli@3.0.0",
+			}))
+			Expect(runShim(filepath.Join(outputDir, "prettier"))).To(Equal("prettier --help"))
+		})
+
+		It("should fail when package has no bin", func() {
+			installer = NewNpmInstaller(writeToolchain("npm", ""), "npm://", rootDir)
+
+			Expect(installer.Fetch("lodash", "4.0.0", "npm://lodash", outputDir)).NotTo(Succeed())
+		})
+	})
+
+	Context("PipxInstaller", func() {
+		var installer *PipxInstaller
+
+		BeforeEach(func() {
+			installer = NewPipxInstaller(writeToolchain("python3", `
+if [ "$2" = "venv" ]; then
+	mkdir -p "$3/bin"
+	cp "$0" "$3/bin/python"
+	touch "$3/bin/activate"
+	exit 0
+fi
+bin=$(dirname "$0")
+printf '#!/bin/sh\necho black "$@"\n' > "$bin/black"
+chmod +x "$bin/black"
+`), "pipx://", rootDir)
+		})
+
+		It("should install package into venv and expose console scripts", func() {
+			Expect(installer.Fetch("black", "24.1.0", "pipx://black", outputDir)).To(Succeed())
+
+			venv := filepath.Join(rootDir, ".pipx", "black_24.1.0")
+			Expect(readArgs()).To(Equal([]string{
+				"-m venv " + venv,
+				"-m pip install --disable-pip-version-check black==24.1.0",
+			}))
+
+			entries, err := os.ReadDir(outputDir)
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(1))
+			Expect(runShim(filepath.Join(outputDir, "black"))).To(Equal("black --help"))
+		})
+
+		It("should install without index in offline mode", func() {
+			installer.SetOffline(true)
+
+			Expect(installer.Fetch("black", "", "pipx://black", outputDir)).To(Succeed())
+			Expect(readArgs()[1]).To(Equal("-m pip install --disable-pip-version-check --no-index black"))
+		})
+	})
+})
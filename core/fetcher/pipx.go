@@ -0,0 +1,96 @@
+package fetcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PipxInstaller installs python packages by pipx://pkg[@version] into an isolated venv under the root dir,
+// like pipx does, and writes shims of its console scripts into the download dir
+type PipxInstaller struct {
+	pythonPath string
+	scheme     string
+	rootDir    string
+	offline    bool
+}
+
+// SetOffline makes pip install packages without the index
+func (p *PipxInstaller) SetOffline(offline bool) {
+	p.offline = offline
+}
+
+func (p *PipxInstaller) IsSupport(uri string) bool {
+	return strings.HasPrefix(uri, p.scheme)
+}
+
+func (p *PipxInstaller) Fetch(name, version, uri, dst string) error {
+	pkg, version, err := splitPackageVersion(strings.TrimPrefix(uri, p.scheme), version)
+	if err != nil {
+		return err
+	}
+
+	venv := packageDir(p.rootDir, PipxPackagesDir, pkg, version)
+	err = os.RemoveAll(venv)
+	if err != nil {
+		return errors.Wrapf(err, "remove %s failed", venv)
+	}
+
+	err = runPackageInstaller(nil, p.pythonPath, "-m", "venv", venv)
+	if err != nil {
+		return err
+	}
+
+	binDir := filepath.Join(venv, "bin")
+	builtins, err := listExecutables(binDir)
+	if err != nil {
+		return err
+	}
+
+	spec := pkg
+	if version != "" {
+		spec = fmt.Sprintf("%s==%s", pkg, version)
+	}
+
+	args := []string{"-m", "pip", "install", "--disable-pip-version-check"}
+	if p.offline {
+		args = append(args, "--no-index")
+	}
+
+	err = runPackageInstaller(nil, filepath.Join(binDir, "python"), append(args, spec)...)
+	if err != nil {
+		return err
+	}
+
+	executables, err := listExecutables(binDir)
+	if err != nil {
+		return err
+	}
+
+	// the console scripts are the ones installed by the package
+	var scripts []string
+	for executable := range executables {
+		if !builtins[executable] {
+			scripts = append(scripts, executable)
+		}
+	}
+	sort.Strings(scripts)
+
+	return writeEntryShims(dst, binDir, scripts)
+}
+
+func NewPipxInstaller(pythonPath, scheme, rootDir string) *PipxInstaller {
+	return &PipxInstaller{
+		pythonPath: pythonPath,
+		scheme:     scheme,
+		rootDir:    rootDir,
+	}
+}
+
+func NewDefaultPipxInstaller(rootDir string) *PipxInstaller {
+	return NewPipxInstaller("python3", "pipx://", rootDir)
+}
@@ -0,0 +1,207 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+const DefaultTimeout = 60
+
+var (
+	ErrHookFailed   = errors.New("hook failed")
+	ErrHookTimeout  = errors.New("hook timed out")
+	ErrUnknownEvent = errors.New("unknown event")
+)
+
+// Hook is a script which runs on an event, the event is passed to stdin as json
+type Hook struct {
+	Event string `mapstructure:"event"`
+	// Command is a glob of command names, the hook runs for every command when empty
+	Command string `mapstructure:"command"`
+	Run     string `mapstructure:"run"`
+	// Timeout is in seconds
+	Timeout int `mapstructure:"timeout"`
+}
+
+func (h *Hook) match(event *core.CommandEvent) bool {
+	if h.Event != event.Event {
+		return false
+	}
+
+	if h.Command == "" {
+		return true
+	}
+
+	matched, err := path.Match(h.Command, event.Name)
+	return err == nil && matched
+}
+
+func (h *Hook) getTimeout() time.Duration {
+	if h.Timeout <= 0 {
+		return DefaultTimeout * time.Second
+	}
+
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// Runner runs the hooks of events, a failed pre hook aborts the event
+type Runner struct {
+	hooks  []*Hook
+	output io.Writer
+}
+
+func (r *Runner) SetOutput(output io.Writer) {
+	r.output = output
+}
+
+func (r *Runner) Hooks() []*Hook {
+	return r.hooks
+}
+
+func getShell() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C"}
+	}
+
+	return []string{"sh", "-c"}
+}
+
+func (r *Runner) run(hook *Hook, event *core.CommandEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "encode event %s failed", event.Event)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hook.getTimeout())
+	defer cancel()
+
+	shell := getShell()
+	cmd := exec.CommandContext(ctx, shell[0], append(shell[1:], hook.Run)...)
+	// the children of the shell may keep the output open after it is killed
+	cmd.WaitDelay = time.Second
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = r.output
+	cmd.Stderr = r.output
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("CMDR_EVENT=%s", event.Event),
+		fmt.Sprintf("CMDR_COMMAND_NAME=%s", event.Name),
+		fmt.Sprintf("CMDR_COMMAND_VERSION=%s", event.Version),
+		fmt.Sprintf("CMDR_COMMAND_LOCATION=%s", event.Location),
+	)
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Wrapf(ErrHookTimeout, "%s hook %q after %s", event.Event, hook.Run, hook.getTimeout())
+	}
+
+	if err != nil {
+		return errors.Wrapf(ErrHookFailed, "%s hook %q: %v", event.Event, hook.Run, err)
+	}
+
+	return nil
+}
+
+// Handle runs the matched hooks in order, the remaining hooks are skipped when a pre hook failed
+func (r *Runner) Handle(event *core.CommandEvent) {
+	logger := core.GetLogger()
+	pre := strings.HasPrefix(event.Event, "pre-")
+
+	for _, hook := range r.hooks {
+		if !hook.match(event) {
+			continue
+		}
+
+		logger.Debug("running hook", map[string]interface{}{
+			"event":   event.Event,
+			"command": event.Name,
+			"run":     hook.Run,
+		})
+
+		err := r.run(hook, event)
+		if err == nil {
+			continue
+		}
+
+		if pre {
+			event.Abort(err)
+			return
+		}
+
+		logger.Warn("hook failed", map[string]interface{}{
+			"event":   event.Event,
+			"command": event.Name,
+			"error":   err,
+		})
+	}
+}
+
+// Subscribe subscribes the events which have hooks
+func (r *Runner) Subscribe() {
+	subscribed := make(map[string]bool)
+	for _, hook := range r.hooks {
+		if subscribed[hook.Event] {
+			continue
+		}
+
+		subscribed[hook.Event] = true
+		core.SubscribeEvent(hook.Event, r.Handle)
+	}
+}
+
+func (r *Runner) Unsubscribe() {
+	for _, event := range core.CommandEvents {
+		core.UnsubscribeEvent(event, r.Handle)
+	}
+}
+
+func NewRunner(hooks ...*Hook) (*Runner, error) {
+	for index, hook := range hooks {
+		known := false
+		for _, event := range core.CommandEvents {
+			if hook.Event == event {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return nil, errors.Wrapf(ErrUnknownEvent, "%q of hook %d", hook.Event, index)
+		}
+
+		if hook.Run == "" {
+			return nil, errors.Errorf("run of hook %d is required", index)
+		}
+
+		if _, err := path.Match(hook.Command, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid command pattern %q of hook %d", hook.Command, index)
+		}
+	}
+
+	return &Runner{
+		hooks:  hooks,
+		output: os.Stderr,
+	}, nil
+}
+
+func NewRunnerByConfiguration(cfg core.Configuration) (*Runner, error) {
+	var hooks []*Hook
+	err := cfg.UnmarshalKey(core.CfgKeyHooks, &hooks)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", core.CfgKeyHooks)
+	}
+
+	return NewRunner(hooks...)
+}
@@ -0,0 +1,132 @@
+package hook_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	. "github.com/mrlyc/cmdr/core/hook"
+)
+
+var _ = Describe("Hook", func() {
+	var (
+		dir    string
+		runner *Runner
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip("hooks are run by sh")
+		}
+
+		var err error
+		dir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		if runner != nil {
+			runner.Unsubscribe()
+			runner = nil
+		}
+
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	newRunner := func(hooks ...*Hook) {
+		var err error
+		runner, err = NewRunner(hooks...)
+		Expect(err).To(BeNil())
+		runner.SetOutput(io.Discard)
+		runner.Subscribe()
+	}
+
+	It("should pass the event to stdin and environment", func() {
+		output := filepath.Join(dir, "event.json")
+		newRunner(&Hook{
+			Event: core.EventPostInstall,
+			Run:   fmt.Sprintf(`cat > %s && echo "$CMDR_EVENT $CMDR_COMMAND_NAME $CMDR_COMMAND_VERSION" > %s.env`, output, output),
+		})
+
+		Expect(core.PublishCommandEvent(core.EventPostInstall, "gofmt", "1.22.0", "/shims/gofmt")).To(Succeed())
+
+		data, err := os.ReadFile(output)
+		Expect(err).To(BeNil())
+
+		var event map[string]interface{}
+		Expect(json.Unmarshal(data, &event)).To(Succeed())
+		Expect(event).To(HaveKeyWithValue("event", core.EventPostInstall))
+		Expect(event).To(HaveKeyWithValue("name", "gofmt"))
+		Expect(event).To(HaveKeyWithValue("version", "1.22.0"))
+		Expect(event).To(HaveKeyWithValue("location", "/shims/gofmt"))
+
+		Expect(os.ReadFile(output + ".env")).To(Equal([]byte("post-install gofmt 1.22.0\n")))
+	})
+
+	It("should only run the hooks of the command", func() {
+		output := filepath.Join(dir, "output")
+		newRunner(
+			&Hook{Event: core.EventPostActivate, Command: "go*", Run: fmt.Sprintf("echo $CMDR_COMMAND_NAME >> %s", output)},
+			&Hook{Event: core.EventPostActivate, Command: "kubectl", Run: "exit 1"},
+		)
+
+		Expect(core.PublishCommandEvent(core.EventPostActivate, "gofmt", "1.22.0", "")).To(Succeed())
+		Expect(core.PublishCommandEvent(core.EventPostActivate, "node", "20.0.0", "")).To(Succeed())
+		Expect(os.ReadFile(output)).To(Equal([]byte("gofmt\n")))
+	})
+
+	It("should abort by a failed pre hook", func() {
+		output := filepath.Join(dir, "output")
+		newRunner(
+			&Hook{Event: core.EventPreRemove, Run: "exit 3"},
+			&Hook{Event: core.EventPreRemove, Run: fmt.Sprintf("touch %s", output)},
+		)
+
+		err := core.PublishCommandEvent(core.EventPreRemove, "gofmt", "1.22.0", "")
+		Expect(errors.Is(err, ErrHookFailed)).To(BeTrue())
+		Expect(output).NotTo(BeAnExistingFile())
+	})
+
+	It("should not abort by a failed post hook", func() {
+		newRunner(&Hook{Event: core.EventPostRemove, Run: "exit 1"})
+
+		Expect(core.PublishCommandEvent(core.EventPostRemove, "gofmt", "1.22.0", "")).To(Succeed())
+	})
+
+	It("should time out", func() {
+		newRunner(&Hook{Event: core.EventPreInstall, Run: "sleep 5", Timeout: 1})
+
+		err := core.PublishCommandEvent(core.EventPreInstall, "gofmt", "1.22.0", "")
+		Expect(errors.Is(err, ErrHookTimeout)).To(BeTrue())
+	})
+
+	It("should reject unknown events", func() {
+		_, err := NewRunner(&Hook{Event: "after-install", Run: "true"})
+		Expect(errors.Is(err, ErrUnknownEvent)).To(BeTrue())
+
+		_, err = NewRunner(&Hook{Event: core.EventPreInstall})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should create runner by configuration", func() {
+		cfg := viper.New()
+		cfg.Set(core.CfgKeyHooks, []map[string]interface{}{
+			{"event": core.EventPostActivate, "command": "node", "run": "true", "timeout": 10},
+		})
+
+		runner, err := NewRunnerByConfiguration(cfg)
+		Expect(err).To(BeNil())
+		Expect(runner.Hooks()).To(Equal([]*Hook{
+			{Event: core.EventPostActivate, Command: "node", Run: "true", Timeout: 10},
+		}))
+	})
+})
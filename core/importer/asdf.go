@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+// AsdfImporter imports <data_dir>/installs/<tool>/<version>/bin, mise uses the same layout
+type AsdfImporter struct {
+	dataDir      string
+	versionFiles []string
+}
+
+func (i *AsdfImporter) Scan() ([]*Candidate, error) {
+	installsDir := filepath.Join(i.dataDir, "installs")
+	_, err := os.Stat(installsDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s failed", installsDir)
+	}
+
+	activeVersions := readVersionFiles(i.versionFiles)
+
+	var candidates []*Candidate
+	for _, tool := range scanVersionDirs(installsDir) {
+		for _, version := range scanVersionDirs(filepath.Join(installsDir, tool)) {
+			candidates = append(candidates, newCandidates(
+				tool, version, filepath.Join(installsDir, tool, version, "bin"), activeVersions[tool],
+			)...)
+		}
+	}
+
+	return candidates, nil
+}
+
+// NewAsdfImporter creates an importer, the former version files take precedence
+func NewAsdfImporter(dataDir string, versionFiles []string) *AsdfImporter {
+	return &AsdfImporter{
+		dataDir:      dataDir,
+		versionFiles: versionFiles,
+	}
+}
+
+func init() {
+	Register("asdf", func(cfg core.Configuration) (Importer, error) {
+		dataDir, err := getDir(cfg, "ASDF_DATA_DIR", ".asdf")
+		if err != nil {
+			return nil, err
+		}
+
+		workDir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		versionFiles := findUpward(workDir, ".tool-versions")
+		home, err := os.UserHomeDir()
+		if err == nil {
+			versionFiles = append(versionFiles, filepath.Join(home, ".tool-versions"))
+		}
+
+		return NewAsdfImporter(dataDir, versionFiles), nil
+	})
+
+	Register("mise", func(cfg core.Configuration) (Importer, error) {
+		dataDir, err := getDir(cfg, "MISE_DATA_DIR", ".local", "share", "mise")
+		if err != nil {
+			return nil, err
+		}
+
+		workDir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		configDir, err := getEnvDir("MISE_CONFIG_DIR", ".config", "mise")
+		if err != nil {
+			return nil, err
+		}
+
+		versionFiles := append(
+			findUpward(workDir, "mise.toml", ".mise.toml", ".tool-versions"),
+			filepath.Join(configDir, "config.toml"),
+		)
+
+		return NewAsdfImporter(dataDir, versionFiles), nil
+	})
+}
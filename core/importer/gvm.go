@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+var gvmGoNamePattern = regexp.MustCompile(`gvm_go_name="?([^"\s;]+)"?`)
+
+// GvmImporter imports <gvm_root>/gos/go<version>/bin
+type GvmImporter struct {
+	root string
+}
+
+// readDefault reads the go selected by gvm use --default
+func (i *GvmImporter) readDefault() string {
+	file, err := os.Open(filepath.Join(i.root, "environments", "default"))
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		matches := gvmGoNamePattern.FindStringSubmatch(scanner.Text())
+		if matches != nil {
+			return strings.TrimPrefix(matches[1], "go")
+		}
+	}
+
+	return ""
+}
+
+func (i *GvmImporter) Scan() ([]*Candidate, error) {
+	gosDir := filepath.Join(i.root, "gos")
+	_, err := os.Stat(gosDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s failed", gosDir)
+	}
+
+	activeVersion := i.readDefault()
+
+	var candidates []*Candidate
+	for _, dir := range scanVersionDirs(gosDir) {
+		if !strings.HasPrefix(dir, "go") {
+			continue
+		}
+
+		candidates = append(candidates, newCandidates(
+			"go", strings.TrimPrefix(dir, "go"), filepath.Join(gosDir, dir, "bin"), activeVersion,
+		)...)
+	}
+
+	return candidates, nil
+}
+
+func NewGvmImporter(root string) *GvmImporter {
+	return &GvmImporter{
+		root: root,
+	}
+}
+
+func init() {
+	Register("gvm", func(cfg core.Configuration) (Importer, error) {
+		root, err := getDir(cfg, "GVM_ROOT", ".gvm")
+		if err != nil {
+			return nil, err
+		}
+
+		return NewGvmImporter(root), nil
+	})
+}
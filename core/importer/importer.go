@@ -0,0 +1,219 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versionfile"
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+var (
+	ErrImporterNotFound = fmt.Errorf("importer not found")
+	// the files are initialized in order of names, so the map can not be created in init
+	factories = make(map[string]func(cfg core.Configuration) (Importer, error))
+)
+
+// Candidate is a binary installed by another version manager
+type Candidate struct {
+	// Tool is the name used by the version manager, like nodejs of asdf
+	Tool      string
+	Name      string
+	Version   string
+	Location  string
+	Activated bool
+}
+
+// Importer scans the install dirs of a version manager
+type Importer interface {
+	Scan() ([]*Candidate, error)
+}
+
+func Register(name string, fn func(cfg core.Configuration) (Importer, error)) {
+	factories[name] = fn
+}
+
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func New(name string, cfg core.Configuration) (Importer, error) {
+	fn, ok := factories[name]
+	if !ok {
+		return nil, ErrImporterNotFound
+	}
+
+	return fn(cfg)
+}
+
+// scanBinDir returns the executable files in dir, the symlinks are kept as they are
+func scanBinDir(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+			continue
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// scanVersionDirs returns the version dirs under dir, the symlinks like latest are skipped
+func scanVersionDirs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+// findUpward returns the files in dir and its parents, the nearest comes first
+func findUpward(dir string, names ...string) []string {
+	var paths []string
+	for {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				paths = append(paths, path)
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return paths
+		}
+
+		dir = parent
+	}
+}
+
+// readMiseConfig reads the tools table of mise config files
+func readMiseConfig(path string) (map[string]string, error) {
+	versions := make(map[string]string)
+
+	cfg := viper.New()
+	cfg.SetConfigFile(path)
+	cfg.SetConfigType("toml")
+	err := cfg.ReadInConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", path)
+	}
+
+	for tool, value := range cfg.GetStringMap("tools") {
+		switch value := value.(type) {
+		case string:
+			versions[tool] = value
+		case []interface{}:
+			if len(value) > 0 {
+				versions[tool] = fmt.Sprintf("%v", value[0])
+			}
+		case map[string]interface{}:
+			versions[tool] = fmt.Sprintf("%v", value["version"])
+		}
+	}
+
+	return versions, nil
+}
+
+// readVersionFiles merges the versions of files, the former files take precedence
+func readVersionFiles(paths []string) map[string]string {
+	versions := make(map[string]string)
+	for _, path := range paths {
+		read := versionfile.ReadToolVersions
+		if filepath.Ext(path) == ".toml" {
+			read = readMiseConfig
+		}
+
+		tools, err := read(path)
+		if err != nil {
+			continue
+		}
+
+		for tool, version := range tools {
+			if _, ok := versions[tool]; !ok {
+				versions[tool] = version
+			}
+		}
+	}
+
+	return versions
+}
+
+// newCandidates returns the binaries of a tool version
+func newCandidates(tool, version, binDir, activeVersion string) []*Candidate {
+	activated := activeVersion != "" && versioning.Equal(activeVersion, version)
+
+	var candidates []*Candidate
+	for _, path := range scanBinDir(binDir) {
+		candidates = append(candidates, &Candidate{
+			Tool:      tool,
+			Name:      filepath.Base(path),
+			Version:   version,
+			Location:  path,
+			Activated: activated,
+		})
+	}
+
+	return candidates
+}
+
+// getEnvDir returns the dir configured by env, or the default one under home
+func getEnvDir(env string, defaults ...string) (string, error) {
+	dir := os.Getenv(env)
+	if dir != "" {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(append([]string{home}, defaults...)...), nil
+}
+
+// getDir returns the dir configured by flag first
+func getDir(cfg core.Configuration, env string, defaults ...string) (string, error) {
+	dir := cfg.GetString(core.CfgKeyXImportDir)
+	if dir != "" {
+		return dir, nil
+	}
+
+	return getEnvDir(env, defaults...)
+}
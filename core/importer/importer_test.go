@@ -0,0 +1,176 @@
+package importer_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	. "github.com/mrlyc/cmdr/core/importer"
+)
+
+var _ = Describe("Importer", func() {
+	var rootDir string
+
+	writeFile := func(path string, content string, mode os.FileMode) {
+		path = filepath.Join(rootDir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), mode)).To(Succeed())
+	}
+
+	activated := func(candidates []*Candidate) map[string]bool {
+		results := make(map[string]bool, len(candidates))
+		for _, candidate := range candidates {
+			results[candidate.Name+"@"+candidate.Version] = candidate.Activated
+		}
+
+		return results
+	}
+
+	BeforeEach(func() {
+		var err error
+		rootDir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	It("should list importers", func() {
+		Expect(Names()).To(Equal([]string{"asdf", "gvm", "mise", "nvm"}))
+
+		_, err := New("unknown", viper.New())
+		Expect(err).To(Equal(ErrImporterNotFound))
+	})
+
+	It("should create importer by configuration", func() {
+		cfg := viper.New()
+		cfg.Set(core.CfgKeyXImportDir, rootDir)
+		writeFile("gos/go1.21.0/bin/go", "", 0755)
+
+		importer, err := New("gvm", cfg)
+		Expect(err).To(BeNil())
+
+		candidates, err := importer.Scan()
+		Expect(err).To(BeNil())
+		Expect(candidates).To(HaveLen(1))
+	})
+
+	Context("asdf", func() {
+		BeforeEach(func() {
+			writeFile("installs/nodejs/18.17.0/bin/node", "", 0755)
+			writeFile("installs/nodejs/18.17.0/bin/README", "", 0644)
+			writeFile("installs/nodejs/20.5.0/bin/node", "", 0755)
+			writeFile("installs/golang/1.21.0/bin/go", "", 0755)
+			Expect(os.Symlink(
+				filepath.Join(rootDir, "installs/nodejs/20.5.0"), filepath.Join(rootDir, "installs/nodejs/latest"),
+			)).To(Succeed())
+		})
+
+		It("should scan binaries and activate by version files", func() {
+			writeFile("project/.tool-versions", "nodejs 20.5.0 18.17.0 # comment\n", 0644)
+			writeFile("home/.tool-versions", "nodejs 18.17.0\ngolang 1.21\n", 0644)
+
+			importer := NewAsdfImporter(rootDir, []string{
+				filepath.Join(rootDir, "project/.tool-versions"),
+				filepath.Join(rootDir, "home/.tool-versions"),
+			})
+
+			candidates, err := importer.Scan()
+			Expect(err).To(BeNil())
+			Expect(activated(candidates)).To(Equal(map[string]bool{
+				"node@18.17.0": false,
+				"node@20.5.0":  true,
+				"go@1.21.0":    true,
+			}))
+
+			for _, candidate := range candidates {
+				if candidate.Name == "go" {
+					Expect(candidate.Tool).To(Equal("golang"))
+					Expect(candidate.Location).To(Equal(filepath.Join(rootDir, "installs/golang/1.21.0/bin/go")))
+				}
+			}
+		})
+
+		It("should read mise config", func() {
+			writeFile("mise.toml", "[tools]\nnodejs = [\"18.17.0\", \"20.5.0\"]\ngolang = { version = \"1.20\" }\n", 0644)
+
+			importer := NewAsdfImporter(rootDir, []string{filepath.Join(rootDir, "mise.toml")})
+
+			candidates, err := importer.Scan()
+			Expect(err).To(BeNil())
+			Expect(activated(candidates)).To(Equal(map[string]bool{
+				"node@18.17.0": true,
+				"node@20.5.0":  false,
+				"go@1.21.0":    false,
+			}))
+		})
+
+		It("should fail when not installed", func() {
+			_, err := NewAsdfImporter(filepath.Join(rootDir, "missing"), nil).Scan()
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("nvm", func() {
+		BeforeEach(func() {
+			writeFile("versions/node/v16.20.0/bin/node", "", 0755)
+			writeFile("versions/node/v18.16.0/bin/node", "", 0755)
+			writeFile("versions/node/v18.17.0/bin/node", "", 0755)
+			writeFile("versions/node/v18.17.0/lib/node_modules/npm/bin/npm-cli.js", "", 0755)
+			Expect(os.Symlink(
+				"../lib/node_modules/npm/bin/npm-cli.js", filepath.Join(rootDir, "versions/node/v18.17.0/bin/npm"),
+			)).To(Succeed())
+			writeFile("alias/default", "lts/hydrogen\n", 0644)
+			writeFile("alias/lts/hydrogen", "v18\n", 0644)
+		})
+
+		It("should resolve the default alias", func() {
+			importer := NewNvmImporter(rootDir, []string{filepath.Join(rootDir, "alias/default")})
+
+			candidates, err := importer.Scan()
+			Expect(err).To(BeNil())
+			Expect(activated(candidates)).To(Equal(map[string]bool{
+				"node@16.20.0": false,
+				"node@18.16.0": false,
+				"node@18.17.0": true,
+				"npm@18.17.0":  true,
+			}))
+		})
+
+		It("should prefer nvmrc", func() {
+			writeFile("project/.nvmrc", "v16\n", 0644)
+
+			importer := NewNvmImporter(rootDir, []string{
+				filepath.Join(rootDir, "project/.nvmrc"),
+				filepath.Join(rootDir, "alias/default"),
+			})
+
+			candidates, err := importer.Scan()
+			Expect(err).To(BeNil())
+			Expect(activated(candidates)).To(HaveKeyWithValue("node@16.20.0", true))
+			Expect(activated(candidates)).To(HaveKeyWithValue("node@18.17.0", false))
+		})
+	})
+
+	Context("gvm", func() {
+		It("should activate the default go", func() {
+			writeFile("gos/go1.20.0/bin/go", "", 0755)
+			writeFile("gos/go1.21.0/bin/go", "", 0755)
+			writeFile("gos/go1.21.0/bin/gofmt", "", 0755)
+			writeFile("environments/default", "export gvm_go_name; gvm_go_name=\"go1.21.0\"\n", 0644)
+
+			candidates, err := NewGvmImporter(rootDir).Scan()
+			Expect(err).To(BeNil())
+			Expect(activated(candidates)).To(Equal(map[string]bool{
+				"go@1.20.0":    false,
+				"go@1.21.0":    true,
+				"gofmt@1.21.0": true,
+			}))
+		})
+	})
+})
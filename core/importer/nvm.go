@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+// NvmImporter imports <nvm_dir>/versions/node/v<version>/bin
+type NvmImporter struct {
+	dir          string
+	versionFiles []string
+}
+
+// resolveVersion resolves an alias like 18, v18.17 or node to the latest installed version
+func (i *NvmImporter) resolveVersion(alias string, versions []string) string {
+	alias = strings.TrimPrefix(strings.TrimSpace(alias), "v")
+	if alias == "" {
+		return ""
+	}
+
+	resolved := ""
+	for _, version := range versions {
+		switch alias {
+		case "node", "stable":
+		default:
+			if version != alias && !strings.HasPrefix(version, alias+".") {
+				continue
+			}
+		}
+
+		if resolved == "" || versioning.Compare(version, resolved) > 0 {
+			resolved = version
+		}
+	}
+
+	return resolved
+}
+
+// readAlias returns the content of the first existing file, the aliases of nvm may refer to each other
+func (i *NvmImporter) readAlias() string {
+	for _, path := range i.versionFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		alias := strings.TrimSpace(string(data))
+		for depth := 0; depth < 8; depth++ {
+			data, err = os.ReadFile(filepath.Join(i.dir, "alias", alias))
+			if err != nil {
+				break
+			}
+
+			alias = strings.TrimSpace(string(data))
+		}
+
+		return alias
+	}
+
+	return ""
+}
+
+func (i *NvmImporter) Scan() ([]*Candidate, error) {
+	nodeDir := filepath.Join(i.dir, "versions", "node")
+	_, err := os.Stat(nodeDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s failed", nodeDir)
+	}
+
+	dirs := scanVersionDirs(nodeDir)
+	versions := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		versions = append(versions, strings.TrimPrefix(dir, "v"))
+	}
+
+	activeVersion := i.resolveVersion(i.readAlias(), versions)
+
+	var candidates []*Candidate
+	for index, dir := range dirs {
+		candidates = append(candidates, newCandidates(
+			"node", versions[index], filepath.Join(nodeDir, dir, "bin"), activeVersion,
+		)...)
+	}
+
+	return candidates, nil
+}
+
+// NewNvmImporter creates an importer, the version files are like .nvmrc and the default alias
+func NewNvmImporter(dir string, versionFiles []string) *NvmImporter {
+	return &NvmImporter{
+		dir:          dir,
+		versionFiles: versionFiles,
+	}
+}
+
+func init() {
+	Register("nvm", func(cfg core.Configuration) (Importer, error) {
+		dir, err := getDir(cfg, "NVM_DIR", ".nvm")
+		if err != nil {
+			return nil, err
+		}
+
+		workDir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		versionFiles := append(findUpward(workDir, ".nvmrc"), filepath.Join(dir, "alias", "default"))
+
+		return NewNvmImporter(dir, versionFiles), nil
+	})
+}
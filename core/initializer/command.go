@@ -1,24 +1,67 @@
 package initializer
 
 import (
+	"bytes"
+	"context"
 	"os"
+	"os/exec"
+	"sort"
+	"time"
 
-	ver "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
+// cmdrHealthCheckTimeout limits the health check, a hanging binary is unhealthy
+const cmdrHealthCheckTimeout = 30 * time.Second
+
+// HealthChecker checks whether the binary of a cmdr command works
+type HealthChecker func(location string) error
+
+// CheckCmdrHealth runs `cmdr version` by the binary, the exit status must be zero
+func CheckCmdrHealth(location string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cmdrHealthCheckTimeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	process := exec.CommandContext(ctx, location, "version")
+	process.Stdout = &output
+	process.Stderr = &output
+
+	err := process.Run()
+	if err != nil {
+		return errors.Wrapf(err, "run %s version failed: %s", location, bytes.TrimSpace(output.Bytes()))
+	}
+
+	return nil
+}
+
 type CmdrUpdater struct {
 	name     string
 	version  string
 	location string
+	keep     int
+	checker  HealthChecker
 	manager  core.CommandManager
 }
 
+// SetKeep sets how many legacy versions are kept for rolling back
+func (c *CmdrUpdater) SetKeep(keep int) {
+	if keep < 0 {
+		keep = 0
+	}
+
+	c.keep = keep
+}
+
+func (c *CmdrUpdater) SetHealthChecker(checker HealthChecker) {
+	c.checker = checker
+}
+
 func (c *CmdrUpdater) collectLegacyVersions() ([]string, error) {
 	logger := core.GetLogger()
-	currentVersion := ver.Must(ver.NewVersion(c.version))
 
 	query, err := c.manager.Query()
 	if err != nil {
@@ -47,24 +90,79 @@ func (c *CmdrUpdater) collectLegacyVersions() ([]string, error) {
 			"command": command,
 		})
 
-		if command.GetActivated() {
+		definedVersion := command.GetVersion()
+		if versioning.Compare(c.version, definedVersion) <= 0 {
 			continue
 		}
 
-		definedVersion := command.GetVersion()
-		semver := ver.Must(ver.NewVersion(definedVersion))
+		legacyVersions = append(legacyVersions, definedVersion)
+	}
 
-		if currentVersion.Compare(semver) <= 0 {
-			continue
-		}
+	// the newest legacy versions are kept
+	sort.SliceStable(legacyVersions, func(i, j int) bool {
+		return versioning.Compare(legacyVersions[i], legacyVersions[j]) > 0
+	})
 
+	if len(legacyVersions) <= c.keep {
+		return nil, nil
+	}
+
+	for _, version := range legacyVersions[c.keep:] {
 		logger.Info("collected legacy cmdr", map[string]interface{}{
-			"command": command,
+			"name":    c.name,
+			"version": version,
 		})
-		legacyVersions = append(legacyVersions, definedVersion)
 	}
 
-	return legacyVersions, nil
+	return legacyVersions[c.keep:], nil
+}
+
+// activatedVersion returns the version which is activated before updating, it is empty when not found
+func (c *CmdrUpdater) activatedVersion() string {
+	query, err := c.manager.Query()
+	if err != nil {
+		return ""
+	}
+
+	command, err := query.WithName(c.name).WithActivated(true).One()
+	if err != nil {
+		return ""
+	}
+
+	// the short form of the version replaces the stored one when activating, like 1 of 1.0.0
+	return versioning.Normalize(command.GetVersion())
+}
+
+// checkHealth checks the new version, the previous version is activated again when it is unhealthy
+func (c *CmdrUpdater) checkHealth(previous string) error {
+	query, err := c.manager.Query()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create command query")
+	}
+
+	command, err := query.WithName(c.name).WithVersion(c.version).One()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get command %s(%s)", c.name, c.version)
+	}
+
+	err = c.checker(command.GetLocation())
+	if err == nil {
+		return nil
+	}
+
+	if previous != "" && previous != c.version {
+		core.GetLogger().Warn("new cmdr is unhealthy, activating the previous version", map[string]interface{}{
+			"version":  c.version,
+			"previous": previous,
+		})
+
+		activateErr := c.manager.Activate(c.name, previous)
+		if activateErr != nil {
+			return errors.Wrapf(activateErr, "failed to activate previous version %s after health check failed: %v", previous, err)
+		}
+	}
+
+	return errors.Wrapf(err, "health check of %s(%s) failed", c.name, c.version)
 }
 
 func (c *CmdrUpdater) Init(isUpgrade bool) error {
@@ -79,6 +177,8 @@ func (c *CmdrUpdater) Init(isUpgrade bool) error {
 		return errors.Wrapf(err, "failed to collect legacy versions")
 	}
 
+	previous := c.activatedVersion()
+
 	if !isUpgrade {
 		_, err := c.manager.Define(c.name, c.version, c.location)
 		if err != nil {
@@ -91,6 +191,14 @@ func (c *CmdrUpdater) Init(isUpgrade bool) error {
 		return errors.Wrapf(err, "failed to activate command %s", c.name)
 	}
 
+	// the legacy versions are removed only when the new one works
+	if c.checker != nil {
+		err = c.checkHealth(previous)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, version := range legacyVersions {
 		err = c.manager.Undefine(c.name, version)
 		if err != nil {
@@ -122,6 +230,10 @@ func init() {
 			return nil, errors.Wrapf(err, "failed to create command manager")
 		}
 
-		return NewCmdrUpdater(manager, core.Name, core.Version, location), nil
+		updater := NewCmdrUpdater(manager, core.Name, core.Version, location)
+		updater.SetKeep(cfg.GetInt(core.CfgKeyUpgradeKeep))
+		updater.SetHealthChecker(CheckCmdrHealth)
+
+		return updater, nil
 	})
 }
@@ -1,6 +1,8 @@
 package initializer_test
 
 import (
+	"fmt"
+
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -35,10 +37,12 @@ var _ = Describe("Command", func() {
 		activatedCommand.EXPECT().GetName().Return(name).AnyTimes()
 		activatedCommand.EXPECT().GetVersion().Return("10.0.0").AnyTimes()
 		activatedCommand.EXPECT().GetActivated().Return(true).AnyTimes()
+		activatedCommand.EXPECT().GetLocation().Return(location).AnyTimes()
 
 		query = mock.NewMockCommandQuery(ctrl)
 		query.EXPECT().WithName(name).Return(query).AnyTimes()
 		query.EXPECT().WithActivated(true).Return(query).AnyTimes()
+		query.EXPECT().WithVersion(gomock.Any()).Return(query).AnyTimes()
 		query.EXPECT().All().Return([]core.Command{legacyCommand, activatedCommand}, nil).AnyTimes()
 		query.EXPECT().One().Return(activatedCommand, nil).AnyTimes()
 		query.EXPECT().Count().Return(2, nil).AnyTimes()
@@ -49,6 +53,7 @@ var _ = Describe("Command", func() {
 		updater = initializer.NewCmdrUpdater(
 			manager, name, version, location,
 		)
+		updater.SetKeep(1)
 	})
 
 	AfterEach(func() {
@@ -69,4 +74,45 @@ var _ = Describe("Command", func() {
 
 		Expect(updater.Init(true)).To(Succeed())
 	})
+
+	It("should undefine all legacy versions", func() {
+		updater.SetKeep(0)
+		manager.EXPECT().Activate(name, version)
+		manager.EXPECT().Undefine(name, activatedCommand.GetVersion())
+		manager.EXPECT().Undefine(name, legacyCommand.GetVersion())
+
+		Expect(updater.Init(true)).To(Succeed())
+	})
+
+	It("should keep the legacy versions", func() {
+		updater.SetKeep(2)
+		manager.EXPECT().Activate(name, version)
+
+		Expect(updater.Init(true)).To(Succeed())
+	})
+
+	It("should remove the legacy versions when healthy", func() {
+		var checked string
+		updater.SetHealthChecker(func(location string) error {
+			checked = location
+			return nil
+		})
+		manager.EXPECT().Activate(name, version)
+		manager.EXPECT().Undefine(name, legacyCommand.GetVersion())
+
+		Expect(updater.Init(true)).To(Succeed())
+		Expect(checked).To(Equal(location))
+	})
+
+	It("should activate the previous version when unhealthy", func() {
+		updater.SetHealthChecker(func(location string) error {
+			return fmt.Errorf("broken")
+		})
+		gomock.InOrder(
+			manager.EXPECT().Activate(name, version),
+			manager.EXPECT().Activate(name, activatedCommand.GetVersion()),
+		)
+
+		Expect(updater.Init(true)).NotTo(Succeed())
+	})
 })
@@ -0,0 +1,157 @@
+package lockfile
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+const (
+	// FormatVersion is the version of the lockfile format
+	FormatVersion = 1
+	DefaultPath   = "cmdr.lock"
+)
+
+var (
+	ErrNotLocked   = errors.New("command not locked")
+	ErrDigestDrift = errors.New("digest drift")
+)
+
+// Artifact is what was fetched for a platform
+type Artifact struct {
+	// URL is the final download url, the rewrite rules are applied already
+	URL      string `yaml:"url"`
+	Strategy string `yaml:"strategy,omitempty"`
+	// Entry is the path of the binary in the artifact, like bin/cmdr of an archive
+	Entry          string `yaml:"entry,omitempty"`
+	ArtifactSHA256 string `yaml:"artifact_sha256,omitempty"`
+	SHA256         string `yaml:"sha256"`
+}
+
+// Verify returns ErrDigestDrift when the digests are different, an empty digest is not checked
+func (a *Artifact) Verify(artifactSHA256, sha256 string) error {
+	if a.ArtifactSHA256 != "" && a.ArtifactSHA256 != artifactSHA256 {
+		return errors.Wrapf(ErrDigestDrift, "artifact of %s expected %s, got %s", a.URL, a.ArtifactSHA256, artifactSHA256)
+	}
+
+	if a.SHA256 != "" && a.SHA256 != sha256 {
+		return errors.Wrapf(ErrDigestDrift, "binary of %s expected %s, got %s", a.URL, a.SHA256, sha256)
+	}
+
+	return nil
+}
+
+type Entry struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	// Location is the location before rewritten
+	Location  string               `yaml:"location"`
+	Platforms map[string]*Artifact `yaml:"platforms"`
+}
+
+type Lockfile struct {
+	Version  int      `yaml:"version"`
+	Commands []*Entry `yaml:"commands"`
+}
+
+func (l *Lockfile) find(name, version string) *Entry {
+	for _, entry := range l.Commands {
+		if entry.Name == name && versioning.Equal(entry.Version, version) {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// Lookup returns the locked artifact of the platform
+func (l *Lockfile) Lookup(name, version, platform string) (*Entry, *Artifact, error) {
+	entry := l.find(name, version)
+	if entry == nil {
+		return nil, nil, errors.Wrapf(ErrNotLocked, "%s %s", name, version)
+	}
+
+	artifact, ok := entry.Platforms[platform]
+	if !ok {
+		return nil, nil, errors.Wrapf(ErrNotLocked, "%s %s on %s", name, version, platform)
+	}
+
+	return entry, artifact, nil
+}
+
+// Set adds or replaces the artifact of the platform
+func (l *Lockfile) Set(name, version, location, platform string, artifact *Artifact) {
+	entry := l.find(name, version)
+	if entry == nil {
+		entry = &Entry{Name: name, Version: version}
+		l.Commands = append(l.Commands, entry)
+	}
+
+	if entry.Platforms == nil {
+		entry.Platforms = make(map[string]*Artifact)
+	}
+
+	entry.Location = location
+	entry.Platforms[platform] = artifact
+}
+
+// Save writes the lockfile with sorted commands, so the file is stable to diff
+func (l *Lockfile) Save(path string) error {
+	sort.SliceStable(l.Commands, func(i, j int) bool {
+		if l.Commands[i].Name != l.Commands[j].Name {
+			return l.Commands[i].Name < l.Commands[j].Name
+		}
+
+		return versioning.Compare(l.Commands[i].Version, l.Commands[j].Version) < 0
+	})
+
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return errors.Wrapf(err, "marshal lockfile failed")
+	}
+
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "write lockfile %s failed", path)
+	}
+
+	return nil
+}
+
+func New() *Lockfile {
+	return &Lockfile{Version: FormatVersion}
+}
+
+func Load(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read lockfile %s failed", path)
+	}
+
+	lock := New()
+	err = yaml.Unmarshal(data, lock)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse lockfile %s failed", path)
+	}
+
+	if lock.Version > FormatVersion {
+		return nil, fmt.Errorf("lockfile %s version %d is not supported", path, lock.Version)
+	}
+
+	return lock, nil
+}
+
+// Platform returns the key of platforms, like linux/amd64
+func Platform(goos, goarch string) string {
+	return fmt.Sprintf("%s/%s", goos, goarch)
+}
+
+func CurrentPlatform() string {
+	return Platform(runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,13 @@
+package lockfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLockfile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lockfile Suite")
+}
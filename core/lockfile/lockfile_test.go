@@ -0,0 +1,95 @@
+package lockfile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mrlyc/cmdr/core/lockfile"
+)
+
+var _ = Describe("Lockfile", func() {
+	var (
+		lock     *Lockfile
+		artifact *Artifact
+	)
+
+	BeforeEach(func() {
+		lock = New()
+		artifact = &Artifact{
+			URL:            "https://example.com/cmdr.tar.gz",
+			Strategy:       "direct",
+			Entry:          "bin/cmdr",
+			ArtifactSHA256: "aaa",
+			SHA256:         "bbb",
+		}
+	})
+
+	It("should return the current platform", func() {
+		Expect(CurrentPlatform()).To(Equal(runtime.GOOS + "/" + runtime.GOARCH))
+	})
+
+	It("should lookup by equal versions", func() {
+		lock.Set("cmdr", "1.0.0", "https://example.com/cmdr", "linux/amd64", artifact)
+
+		entry, found, err := lock.Lookup("cmdr", "1.0", "linux/amd64")
+		Expect(err).To(BeNil())
+		Expect(entry.Location).To(Equal("https://example.com/cmdr"))
+		Expect(found).To(Equal(artifact))
+
+		_, _, err = lock.Lookup("cmdr", "1.0.0", "darwin/arm64")
+		Expect(errors.Is(err, ErrNotLocked)).To(BeTrue())
+
+		_, _, err = lock.Lookup("cmdr", "2.0.0", "linux/amd64")
+		Expect(errors.Is(err, ErrNotLocked)).To(BeTrue())
+	})
+
+	It("should verify digests", func() {
+		Expect(artifact.Verify("aaa", "bbb")).To(Succeed())
+		Expect(errors.Is(artifact.Verify("aaa", "ccc"), ErrDigestDrift)).To(BeTrue())
+		Expect(errors.Is(artifact.Verify("ccc", "bbb"), ErrDigestDrift)).To(BeTrue())
+
+		artifact.ArtifactSHA256 = ""
+		Expect(artifact.Verify("", "bbb")).To(Succeed())
+	})
+
+	It("should save sorted and load", func() {
+		dir, err := os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+
+		lock.Set("go", "1.22.0", "go-location", "linux/amd64", artifact)
+		lock.Set("cmdr", "1.10.0", "cmdr-location", "linux/amd64", artifact)
+		lock.Set("cmdr", "1.9.0", "cmdr-location", "linux/amd64", artifact)
+		lock.Set("cmdr", "1.9.0", "cmdr-location", "darwin/arm64", artifact)
+
+		path := filepath.Join(dir, DefaultPath)
+		Expect(lock.Save(path)).To(Succeed())
+
+		loaded, err := Load(path)
+		Expect(err).To(BeNil())
+		Expect(loaded.Version).To(Equal(FormatVersion))
+		Expect(loaded.Commands).To(HaveLen(3))
+		Expect(loaded.Commands[0].Version).To(Equal("1.9.0"))
+		Expect(loaded.Commands[0].Platforms).To(HaveLen(2))
+		Expect(loaded.Commands[1].Version).To(Equal("1.10.0"))
+		Expect(loaded.Commands[2].Name).To(Equal("go"))
+		Expect(loaded.Commands[2].Platforms["linux/amd64"]).To(Equal(artifact))
+	})
+
+	It("should reject newer format", func() {
+		dir, err := os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, DefaultPath)
+		Expect(os.WriteFile(path, []byte("version: 100\n"), 0644)).To(Succeed())
+
+		_, err = Load(path)
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -2,17 +2,20 @@ package manager
 
 import (
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
 	. "github.com/ahmetb/go-linq/v3"
-	ver "github.com/hashicorp/go-version"
+	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/fetcher"
 	"github.com/mrlyc/cmdr/core/utils"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
 type Binary struct {
@@ -78,11 +81,8 @@ func (f *BinariesFilter) WithName(name string) core.CommandQuery {
 }
 
 func (f *BinariesFilter) WithVersion(version string) core.CommandQuery {
-	semver := ver.Must(ver.NewVersion(version))
-
 	return f.Filter(func(b interface{}) bool {
-		binVer := ver.Must(ver.NewVersion(b.(*Binary).GetVersion()))
-		return semver.Equal(binVer)
+		return versioning.Equal(b.(*Binary).GetVersion(), version)
 	})
 }
 
@@ -123,11 +123,60 @@ func NewBinariesFilter(binaries []*Binary) *BinariesFilter {
 	return &BinariesFilter{binaries}
 }
 
+// link modes of core.link_mode
+const (
+	LinkModeDefault  = "default"
+	LinkModeLink     = "link"
+	LinkModeHardlink = "hardlink"
+	LinkModeReflink  = "reflink"
+)
+
+var (
+	ErrUnknownLinkMode = errors.New("unknown link mode")
+	ErrNoLinkSource    = errors.New("no source to link")
+)
+
 type BinaryManager struct {
 	binDir   string
 	shimsDir string
 	dirMode  os.FileMode
 	linkFn   func(shimsHelper *utils.PathHelper, source, shimsName string, mode os.FileMode) error
+	linkMode string
+	store    *BinaryStore
+	wrappers []*Wrapper
+}
+
+// SetStore keeps the binaries in the store, the shims become symlinks to the blobs,
+// except for the link mode which always refers to the sources
+func (m *BinaryManager) SetStore(store *BinaryStore) {
+	m.store = store
+}
+
+func (m *BinaryManager) GetStore() *BinaryStore {
+	return m.store
+}
+
+// materialize links source as name by the link mode, or puts it into the store and refers to the blob
+func (m *BinaryManager) materialize(helper *utils.PathHelper, source, name string) error {
+	if m.store == nil || m.linkMode == LinkModeLink {
+		return m.linkFn(helper, source, name, 0755)
+	}
+
+	blob, err := m.store.Put(source, m.linkFn)
+	if err != nil {
+		return err
+	}
+
+	return helper.SymbolLink(name, blob, 0755)
+}
+
+func (m *BinaryManager) LinkMode() string {
+	return m.linkMode
+}
+
+// SetWrappers makes the shims of the matched commands wrapper scripts
+func (m *BinaryManager) SetWrappers(wrappers []*Wrapper) {
+	m.wrappers = wrappers
 }
 
 func (m *BinaryManager) Init(isUpgrade bool) error {
@@ -146,7 +195,71 @@ func (m *BinaryManager) Init(isUpgrade bool) error {
 		}
 	}
 
-	return nil
+	if m.store == nil || m.linkMode == LinkModeLink {
+		return nil
+	}
+
+	// the existing shims are kept as they are, cmdr store migrate moves them into the store
+	return m.store.Init()
+}
+
+// MigrateToStore moves the binaries of the shims which are files into the store
+func (m *BinaryManager) MigrateToStore() error {
+	if m.store == nil || m.linkMode == LinkModeLink {
+		return errors.Wrapf(ErrStoreDisabled, "link mode %s", m.linkMode)
+	}
+
+	query, err := m.Query()
+	if err != nil {
+		return err
+	}
+
+	commands, err := query.All()
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, command := range commands {
+		shim := command.GetLocation()
+
+		path := shim
+		wrapping, err := LoadWrapping(shim)
+		if err == nil {
+			path = wrapping.Location
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		core.GetLogger().Info("moving binary into store", map[string]interface{}{
+			"name":    command.GetName(),
+			"version": command.GetVersion(),
+		})
+
+		err = m.Relink(shim)
+		if err != nil {
+			errs = multierror.Append(errs, errors.WithMessagef(err, "migrate %s(%s) failed", command.GetName(), command.GetVersion()))
+		}
+	}
+
+	return errs
+}
+
+// CollectGarbage removes the blobs which are not referenced by any shim
+func (m *BinaryManager) CollectGarbage(dryRun bool) ([]string, error) {
+	if m.store == nil {
+		return nil, nil
+	}
+
+	references, err := m.store.References(m.shimsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.store.GC(references, dryRun)
 }
 
 func (m *BinaryManager) Close() error {
@@ -162,8 +275,7 @@ func (m *BinaryManager) ShimsName(name, version string) string {
 }
 
 func (m *BinaryManager) GetNormalizedVersion(version string) string {
-	semver := ver.Must(ver.NewVersion(version))
-	return semver.String()
+	return versioning.Normalize(version)
 }
 
 func (m *BinaryManager) getAllPossibleShimsNames(name, version string) []string {
@@ -185,6 +297,11 @@ func (m *BinaryManager) Query() (core.CommandQuery, error) {
 		}
 
 		if info.IsDir() {
+			// hidden dirs hold the packages installed by fetchers
+			if path != m.shimsDir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+
 			return nil
 		}
 
@@ -229,7 +346,12 @@ func (m *BinaryManager) linkBinary(name string, version string, location string,
 		return errors.Wrapf(err, "get source path of %s failed", location)
 	}
 
-	err = m.linkFn(shimsHelper, srcLocation, shimsName, 0755)
+	wrapped, err := m.wrapBinary(shimsHelper, name, version, srcLocation, shimsName)
+	if err != nil || wrapped {
+		return err
+	}
+
+	err = m.materialize(shimsHelper, srcLocation, shimsName)
 	if err != nil {
 		return errors.WithMessagef(err, "link %s to %s failed", location, shimsName)
 	}
@@ -237,9 +359,113 @@ func (m *BinaryManager) linkBinary(name string, version string, location string,
 	return nil
 }
 
+// wrapBinary links the binary into the wrapped dir and writes the shim as a script when any wrapper matched,
+// the previous wrapped binary is removed when nothing matched
+func (m *BinaryManager) wrapBinary(shimsHelper *utils.PathHelper, name, version, srcLocation, shimsName string) (bool, error) {
+	wrappedHelper := shimsHelper.Child(WrappedDir)
+	wrapping, err := renderWrappers(m.wrappers, &WrapperVariables{
+		Name:     name,
+		Version:  version,
+		ShimDir:  shimsHelper.Path(),
+		Location: wrappedHelper.Child(shimsName).Path(),
+		Source:   srcLocation,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if wrapping == nil {
+		return false, m.unwrapBinary(shimsHelper, shimsName)
+	}
+
+	err = wrappedHelper.MkdirAll(m.dirMode)
+	if err != nil {
+		return false, errors.WithMessagef(err, "create dir %s failed", wrappedHelper.Path())
+	}
+
+	err = m.materialize(wrappedHelper, srcLocation, shimsName)
+	if err != nil {
+		return false, errors.WithMessagef(err, "link %s to %s failed", srcLocation, wrapping.Location)
+	}
+
+	err = shimsHelper.WriteFile(shimsName, []byte(wrapping.Script()), 0755)
+	if err != nil {
+		return false, errors.WithMessagef(err, "write wrapper of %s failed", name)
+	}
+
+	err = saveWrapping(shimsHelper.Child(shimsName).Path(), wrapping)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (m *BinaryManager) unwrapBinary(shimsHelper *utils.PathHelper, shimsName string) error {
+	wrappedHelper := shimsHelper.Child(WrappedDir)
+	for _, name := range []string{shimsName, shimsName + ".json"} {
+		err := wrappedHelper.EnsureNotExists(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Relink converts the shim in place by the link mode of the manager, the binary of a wrapper shim is converted instead.
+// The source is the target of a symlink, or the shim itself which can not be converted to a symlink
+func (m *BinaryManager) Relink(shim string) error {
+	path := shim
+	wrapping, err := LoadWrapping(shim)
+	if err == nil {
+		path = wrapping.Location
+	} else if errors.Cause(err) != ErrNotWrapped {
+		return err
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return errors.Wrapf(err, "stat %s failed", path)
+	}
+
+	source := path
+	if info.Mode()&os.ModeSymlink != 0 {
+		source, err = filepath.EvalSymlinks(path)
+		if err != nil {
+			return errors.Wrapf(err, "resolve %s failed", path)
+		}
+	} else if m.linkMode == LinkModeLink {
+		return errors.Wrapf(ErrNoLinkSource, "%s is not a symlink", path)
+	}
+
+	core.GetLogger().Debug("relinking binary", map[string]interface{}{
+		"path":      path,
+		"source":    source,
+		"link_mode": m.linkMode,
+	})
+
+	// link beside the shim then replace it, so the shim is never missing
+	helper := utils.NewPathHelper(filepath.Dir(path))
+	tempName := fmt.Sprintf(".%s.relink", filepath.Base(path))
+	err = m.materialize(helper, source, tempName)
+	if err != nil {
+		_ = helper.EnsureNotExists(tempName)
+		return errors.WithMessagef(err, "link %s to %s failed", source, path)
+	}
+
+	err = os.Rename(helper.Child(tempName).Path(), path)
+	if err != nil {
+		_ = helper.EnsureNotExists(tempName)
+		return errors.Wrapf(err, "replace %s failed", path)
+	}
+
+	// renaming a hard link to the same file does nothing
+	return helper.EnsureNotExists(tempName)
+}
+
 func (m *BinaryManager) getNormalizedShimsName(name, version string) string {
-	semver := ver.Must(ver.NewVersion(version))
-	return fmt.Sprintf("%s_%s", name, semver.String())
+	return fmt.Sprintf("%s_%s", name, versioning.Normalize(version))
 }
 
 func (m *BinaryManager) Define(name string, version string, location string) (core.Command, error) {
@@ -307,6 +533,10 @@ func (m *BinaryManager) Undefine(name string, version string) error {
 	for _, shimsName := range []string{normalizedShimsName, oldShimsName} {
 		if shimsName != "" {
 			err := helper.EnsureNotExists(shimsName)
+			if err == nil {
+				err = m.unwrapBinary(helper, shimsName)
+			}
+
 			if err != nil {
 				core.GetLogger().Warn("failed to remove shims file", map[string]interface{}{
 					"shims_name": shimsName,
@@ -316,9 +546,84 @@ func (m *BinaryManager) Undefine(name string, version string) error {
 		}
 	}
 
+	_, err := m.removeOrphanPackages()
+	if err != nil {
+		core.GetLogger().Warn("failed to remove orphan packages", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
 	return nil
 }
 
+// readShimScript returns the head of the binary which the shim refers to, the entry shims of
+// the package installers are short scripts
+func readShimScript(shim string) string {
+	wrapping, err := LoadWrapping(shim)
+	if err == nil {
+		shim = wrapping.Location
+	}
+
+	file, err := os.Open(shim)
+	if err != nil {
+		return ""
+	}
+	defer utils.CallClose(file)
+
+	head := make([]byte, 4096)
+	size, _ := io.ReadFull(file, head)
+
+	return string(head[:size])
+}
+
+// removeOrphanPackages removes the packages of the installers which are not referred by any shim
+func (m *BinaryManager) removeOrphanPackages() ([]string, error) {
+	query, err := m.Query()
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := query.All()
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := make([]string, 0, len(commands))
+	for _, command := range commands {
+		scripts = append(scripts, readShimScript(command.GetLocation()))
+	}
+
+	var removed []string
+	for _, kind := range []string{fetcher.NpmPackagesDir, fetcher.PipxPackagesDir} {
+		helper := utils.NewPathHelper(m.shimsDir).Child(kind)
+		entries, err := os.ReadDir(helper.Path())
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return removed, errors.Wrapf(err, "read dir %s failed", helper.Path())
+		}
+
+		for _, entry := range entries {
+			dir := helper.Child(entry.Name()).Path() + string(filepath.Separator)
+			referred := From(scripts).AnyWith(func(script interface{}) bool {
+				return strings.Contains(script.(string), dir)
+			})
+			if referred {
+				continue
+			}
+
+			err = helper.EnsureNotExists(entry.Name())
+			if err != nil {
+				return removed, err
+			}
+
+			removed = append(removed, dir)
+		}
+	}
+
+	return removed, nil
+}
+
 func (m *BinaryManager) Activate(name, version string) error {
 	shimsHelper := utils.NewPathHelper(m.shimsDir).Child(name)
 	normalizedShimsName := m.getNormalizedShimsName(name, version)
@@ -376,8 +681,7 @@ func (m *BinaryManager) GetShimsDir() string {
 }
 
 func GetNormalizedVersion(version string) string {
-	semver := ver.Must(ver.NewVersion(version))
-	return semver.String()
+	return versioning.Normalize(version)
 }
 
 func NewBinaryManager(
@@ -385,43 +689,121 @@ func NewBinaryManager(
 	dirMode os.FileMode,
 	linkFn func(shimsHelper *utils.PathHelper, source, shimsName string, mode os.FileMode) error,
 ) *BinaryManager {
-	return &BinaryManager{binDir, shimsDir, dirMode, linkFn}
+	return &BinaryManager{binDir: binDir, shimsDir: shimsDir, dirMode: dirMode, linkFn: linkFn}
 }
 
 func NewBinaryManagerWithCopy(
 	binDir, shimsDir string,
 	dirMode os.FileMode,
 ) *BinaryManager {
-	return NewBinaryManager(
+	manager := NewBinaryManager(
 		binDir, shimsDir, dirMode,
 		func(shimsHelper *utils.PathHelper, source, shimsName string, mode os.FileMode) error {
 			return shimsHelper.CopyFile(shimsName, source, mode)
 		},
 	)
+	manager.linkMode = LinkModeDefault
+
+	return manager
 }
 
 func NewBinaryManagerWithLink(
 	binDir, shimsDir string,
 	dirMode os.FileMode,
 ) *BinaryManager {
-	return NewBinaryManager(
+	manager := NewBinaryManager(
 		binDir, shimsDir, dirMode,
 		func(shimsHelper *utils.PathHelper, source, shimsName string, mode os.FileMode) error {
 			return shimsHelper.SymbolLink(shimsName, source, mode)
 		},
 	)
+	manager.linkMode = LinkModeLink
+
+	return manager
 }
 
-func newBinaryManagerByConfiguration(cfg core.Configuration) *BinaryManager {
+func NewBinaryManagerWithHardlink(
+	binDir, shimsDir string,
+	dirMode os.FileMode,
+) *BinaryManager {
+	manager := NewBinaryManager(
+		binDir, shimsDir, dirMode,
+		func(shimsHelper *utils.PathHelper, source, shimsName string, mode os.FileMode) error {
+			return shimsHelper.HardLink(shimsName, source, mode)
+		},
+	)
+	manager.linkMode = LinkModeHardlink
+
+	return manager
+}
+
+func NewBinaryManagerWithReflink(
+	binDir, shimsDir string,
+	dirMode os.FileMode,
+) *BinaryManager {
+	manager := NewBinaryManager(
+		binDir, shimsDir, dirMode,
+		func(shimsHelper *utils.PathHelper, source, shimsName string, mode os.FileMode) error {
+			return shimsHelper.Reflink(shimsName, source, mode)
+		},
+	)
+	manager.linkMode = LinkModeReflink
+
+	return manager
+}
+
+// InstallLinkMode returns the link mode to install the downloaded files,
+// which are removed after installing, so they can not be symlinked
+func InstallLinkMode(linkMode string) string {
+	switch linkMode {
+	case LinkModeHardlink, LinkModeReflink:
+		return linkMode
+	default:
+		return LinkModeDefault
+	}
+}
+
+// NewBinaryManagerWithLinkMode returns the manager of the link mode, the empty mode is the default one
+func NewBinaryManagerWithLinkMode(
+	binDir, shimsDir string,
+	dirMode os.FileMode,
+	linkMode string,
+) (*BinaryManager, error) {
+	switch linkMode {
+	case "", LinkModeDefault:
+		return NewBinaryManagerWithCopy(binDir, shimsDir, dirMode), nil
+	case LinkModeLink:
+		return NewBinaryManagerWithLink(binDir, shimsDir, dirMode), nil
+	case LinkModeHardlink:
+		return NewBinaryManagerWithHardlink(binDir, shimsDir, dirMode), nil
+	case LinkModeReflink:
+		return NewBinaryManagerWithReflink(binDir, shimsDir, dirMode), nil
+	default:
+		return nil, errors.Wrapf(ErrUnknownLinkMode, "%s", linkMode)
+	}
+}
+
+func newBinaryManagerByConfiguration(cfg core.Configuration) (*BinaryManager, error) {
 	binDir := cfg.GetString(core.CfgKeyCmdrBinDir)
 	shimsDir := cfg.GetString(core.CfgKeyCmdrShimsDir)
 
-	switch cfg.GetString(core.CfgKeyCmdrLinkMode) {
-	case "link":
-		return NewBinaryManagerWithLink(binDir, shimsDir, 0755)
-	default:
-		return NewBinaryManagerWithCopy(binDir, shimsDir, 0755)
+	manager, err := NewBinaryManagerWithLinkMode(binDir, shimsDir, 0755, cfg.GetString(core.CfgKeyCmdrLinkMode))
+	if err != nil {
+		core.GetLogger().Warn("unknown link mode, copying binaries", map[string]interface{}{
+			"link_mode": cfg.GetString(core.CfgKeyCmdrLinkMode),
+		})
+		manager = NewBinaryManagerWithCopy(binDir, shimsDir, 0755)
 	}
+
+	wrappers, err := NewWrappersByConfiguration(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.SetWrappers(wrappers)
+	manager.SetStore(NewBinaryStoreByConfiguration(cfg))
+
+	return manager, nil
 }
 
 func init() {
@@ -433,10 +815,10 @@ func init() {
 	)
 
 	core.RegisterCommandManagerFactory(core.CommandProviderBinary, func(cfg core.Configuration) (core.CommandManager, error) {
-		return newBinaryManagerByConfiguration(cfg), nil
+		return newBinaryManagerByConfiguration(cfg)
 	})
 
 	core.RegisterInitializerFactory("binary", func(cfg core.Configuration) (core.Initializer, error) {
-		return newBinaryManagerByConfiguration(cfg), nil
+		return newBinaryManagerByConfiguration(cfg)
 	})
 }
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/fetcher"
 	"github.com/mrlyc/cmdr/core/manager"
 	"github.com/mrlyc/cmdr/core/utils"
 )
@@ -263,6 +266,43 @@ var _ = Describe("Binary", func() {
 				checkDefineResult(commandName)
 			})
 
+			It("should define a wrapped command", func() {
+				mgr.SetWrappers([]*manager.Wrapper{
+					{Command: "java", Env: []string{"JAVA_HOME={{ dir .Source }}"}},
+					{Env: []string{"CMDR_TEST_VERSION={{ .Version }}"}, Args: []string{"-server"}},
+				})
+
+				_, err := mgr.Define("java", version, location)
+				Expect(err).To(BeNil())
+				checkDefineResult("java")
+
+				wrapped := filepath.Join(shimsDir, "java", manager.WrappedDir, "java_1.0.0")
+				Expect(os.Readlink(wrapped)).To(Equal(location))
+
+				script, err := os.ReadFile(getShimsPath("java"))
+				Expect(err).To(BeNil())
+				Expect(string(script)).To(ContainSubstring(fmt.Sprintf(`export JAVA_HOME="%s"`, tempDir)))
+				Expect(string(script)).To(ContainSubstring(fmt.Sprintf(`exec '%s' '-server' "$@"`, wrapped)))
+
+				wrapping, err := manager.LoadWrapping(getShimsPath("java"))
+				Expect(err).To(BeNil())
+				Expect(wrapping.Environ()).To(Equal(map[string]string{
+					"JAVA_HOME":         tempDir,
+					"CMDR_TEST_VERSION": version,
+				}))
+
+				By("redefining without wrappers")
+				mgr.SetWrappers(nil)
+				_, err = mgr.Define("java", version, location)
+				Expect(err).To(BeNil())
+
+				Expect(os.Readlink(getShimsPath("java"))).To(Equal(location))
+				Expect(wrapped).NotTo(BeAnExistingFile())
+
+				_, err = manager.LoadWrapping(getShimsPath("java"))
+				Expect(errors.Cause(err)).To(Equal(manager.ErrNotWrapped))
+			})
+
 			checkUndefineResult := func(name string) {
 				shimsPath := getShimsPath(name)
 				Expect(shimsPath).NotTo(BeAnExistingFile())
@@ -279,6 +319,31 @@ var _ = Describe("Binary", func() {
 				Expect(mgr.Undefine(nonexistsCommand, version)).To(Succeed())
 				checkUndefineResult(nonexistsCommand)
 			})
+
+			It("should remove the packages which are not referred after undefining", func() {
+				packageDir := func(kind, name string) string {
+					dir := filepath.Join(shimsDir, kind, name, "bin")
+					Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+
+					return filepath.Dir(dir)
+				}
+
+				referred := packageDir(fetcher.NpmPackagesDir, "typescript_5.0.0")
+				orphan := packageDir(fetcher.PipxPackagesDir, "black_23.0.0")
+				Expect(os.WriteFile(location, []byte(fmt.Sprintf(
+					"#!/bin/sh\nexec '%s' \"$@\"\n", filepath.Join(referred, "bin", "tsc"),
+				)), 0755)).To(Succeed())
+
+				_, err := mgr.Define("tsc", version, location)
+				Expect(err).To(BeNil())
+
+				Expect(mgr.Undefine(commandName, version)).To(Succeed())
+				Expect(referred).To(BeADirectory())
+				Expect(orphan).NotTo(BeADirectory())
+
+				Expect(mgr.Undefine("tsc", version)).To(Succeed())
+				Expect(referred).NotTo(BeADirectory())
+			})
 		})
 
 		Context("Activate", func() {
@@ -339,6 +404,16 @@ var _ = Describe("Binary", func() {
 				Expect(command.GetVersion()).To(Equal(version))
 				Expect(command.GetLocation()).To(Equal(getShimsPath(command.GetName())))
 			})
+
+			It("should skip hidden dirs", func() {
+				pkgDir := filepath.Join(shimsDir, ".pipx", "bin")
+				Expect(os.MkdirAll(pkgDir, 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(pkgDir, "bin_1.0.0"), []byte(""), 0755)).To(Succeed())
+
+				query, err := mgr.Query()
+				Expect(err).To(BeNil())
+				Expect(query.Count()).To(Equal(1))
+			})
 		})
 
 		Context("Version normalization and backward compatibility", func() {
@@ -355,6 +430,29 @@ var _ = Describe("Binary", func() {
 				Expect(shimsPath).To(BeARegularFile())
 			})
 
+			DescribeTable("should keep version through shims name", func(version, shimsName string) {
+				testCmd := "testcmd"
+				tempDir, _ := os.MkdirTemp("", "")
+				defer os.RemoveAll(tempDir)
+				location := filepath.Join(tempDir, "location")
+				Expect(os.WriteFile(location, []byte(""), 0755)).To(Succeed())
+
+				_, err := mgr.Define(testCmd, version, location)
+				Expect(err).To(BeNil())
+				Expect(filepath.Join(shimsDir, testCmd, shimsName)).To(BeARegularFile())
+
+				query, err := mgr.Query()
+				Expect(err).To(BeNil())
+
+				command, err := query.WithName(testCmd).WithVersion(version).One()
+				Expect(err).To(BeNil())
+				Expect(command.GetVersion()).To(Equal(strings.TrimPrefix(shimsName, "testcmd_")))
+			},
+				Entry("prerelease", "1.2.0-rc.1+build.5", "testcmd_1.2.0-rc.1+build.5"),
+				Entry("calver", "2024-05-01", "testcmd_2024-05-01"),
+				Entry("opaque", "nightly", "testcmd_nightly"),
+			)
+
 			It("should migrate old format (1.4) to new format (1.4.0) if file exists", func() {
 				testCmd := "testcmd"
 				cmdShimsDir := filepath.Join(shimsDir, testCmd)
@@ -474,5 +572,34 @@ var _ = Describe("Binary", func() {
 			_, ok := initializer.(*manager.BinaryManager)
 			Expect(ok).To(BeTrue())
 		})
+
+		DescribeTable("should follow the link mode", func(linkMode, expected string) {
+			cfg.Set(core.CfgKeyCmdrLinkMode, linkMode)
+
+			mgr, err := core.NewCommandManager(core.CommandProviderBinary, cfg)
+			Expect(err).To(BeNil())
+			Expect(mgr.(*manager.BinaryManager).LinkMode()).To(Equal(expected))
+		},
+			Entry("empty", "", manager.LinkModeDefault),
+			Entry("link", manager.LinkModeLink, manager.LinkModeLink),
+			Entry("hardlink", manager.LinkModeHardlink, manager.LinkModeHardlink),
+			Entry("reflink", manager.LinkModeReflink, manager.LinkModeReflink),
+			Entry("unknown", "symlink", manager.LinkModeDefault),
+		)
+
+		It("should keep the binaries in the store", func() {
+			cfg.Set(core.CfgKeyCmdrStoreDir, "store")
+
+			mgr, err := core.NewCommandManager(core.CommandProviderBinary, cfg)
+			Expect(err).To(BeNil())
+			Expect(mgr.(*manager.BinaryManager).GetStore().GetDir()).To(Equal("store"))
+		})
+
+		It("should return an error because of invalid wrappers", func() {
+			cfg.Set(core.CfgKeyXCommandDefineEnv, []string{"JAVA_HOME"})
+
+			_, err := core.NewCommandManager(core.CommandProviderBinary, cfg)
+			Expect(errors.Cause(err)).To(Equal(manager.ErrInvalidWrapper))
+		})
 	})
 })
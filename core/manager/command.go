@@ -2,15 +2,14 @@ package manager
 
 import (
 	"fmt"
-	"strconv"
 
 	. "github.com/ahmetb/go-linq/v3"
 	"github.com/asdine/storm/v3"
 	"github.com/asdine/storm/v3/q"
-	ver "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
 type Command struct {
@@ -19,6 +18,8 @@ type Command struct {
 	Version   string `storm:"index" json:"version"`
 	Activated bool   `storm:"index" json:"activated"`
 	Location  string `storm:"" json:"location"`
+	// Metadata is reported by the fetchers, like the go module sum
+	Metadata map[string]string `storm:"" json:"metadata,omitempty"`
 }
 
 func (c *Command) String() string {
@@ -34,15 +35,16 @@ func (c *Command) GetName() string {
 }
 
 func (c *Command) GetVersion() string {
-	semver := ver.Must(ver.NewVersion(c.Version))
-	segments := semver.Segments()
-	if segments[1] == 0 && segments[2] == 0 {
-		return strconv.Itoa(segments[0])
+	version, err := versioning.Parse(c.Version)
+	if err != nil {
+		return c.Version
 	}
-	if segments[2] == 0 {
-		return fmt.Sprintf("%d.%d", segments[0], segments[1])
+
+	if semver, ok := version.(versioning.SemverVersion); ok {
+		return semver.Short()
 	}
-	return fmt.Sprintf("%d.%d.%d", segments[0], segments[1], segments[2])
+
+	return version.String()
 }
 
 func (c *Command) GetActivated() bool {
@@ -53,6 +55,10 @@ func (c *Command) GetLocation() string {
 	return c.Location
 }
 
+func (c *Command) GetMetadata() map[string]string {
+	return c.Metadata
+}
+
 type CommandFilter struct {
 	commands []*Command
 }
@@ -69,11 +75,8 @@ func (f *CommandFilter) WithName(name string) core.CommandQuery {
 }
 
 func (f *CommandFilter) WithVersion(version string) core.CommandQuery {
-	semver := ver.Must(ver.NewVersion(version))
-
 	return f.Filter(func(b interface{}) bool {
-		cmdVersion := ver.Must(ver.NewVersion(b.(*Command).GetVersion()))
-		return cmdVersion.Equal(semver)
+		return versioning.Equal(b.(*Command).GetVersion(), version)
 	})
 }
 
@@ -42,6 +42,10 @@ var _ = Describe("Database", func() {
 			Entry("1.1", "1.1", "1.1"),
 			Entry("1.1.0", "1.1.0", "1.1"),
 			Entry("1.1.1", "1.1.1", "1.1.1"),
+			Entry("prerelease", "1.2.0-rc.1", "1.2.0-rc.1"),
+			Entry("build metadata", "1.2.0+build.5", "1.2.0+build.5"),
+			Entry("calver", "2024-05-01", "2024-05-01"),
+			Entry("opaque", "nightly", "nightly"),
 		)
 	})
 
@@ -3,23 +3,65 @@ package manager
 import (
 	"github.com/asdine/storm/v3"
 	"github.com/asdine/storm/v3/q"
-	ver "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
 func queryMatchVersion(version string) q.Matcher {
-	semver := ver.Must(ver.NewVersion(version))
 	return q.Or(
 		q.Eq("Version", version),
-		q.Eq("Version", semver.String()),
+		q.Eq("Version", versioning.Normalize(version)),
 	)
 }
 
 type DatabaseManager struct {
 	Client  core.Database
 	manager core.CommandManager
+	history *HistoryStore
+	cause   string
+}
+
+// SetHistory enables recording the operations to the store
+func (m *DatabaseManager) SetHistory(history *HistoryStore) {
+	m.history = history
+}
+
+func (m *DatabaseManager) History() *HistoryStore {
+	return m.history
+}
+
+func (m *DatabaseManager) SetHistoryCause(cause string) {
+	m.cause = cause
+}
+
+func (m *DatabaseManager) RecordHistory(operation, name, version, location, previous string) error {
+	if m.history == nil {
+		return nil
+	}
+
+	return m.history.Record(&History{
+		Operation: operation,
+		Name:      name,
+		Version:   version,
+		Location:  location,
+		Previous:  previous,
+		Cause:     m.cause,
+	})
+}
+
+// record records an operation which is done, so a failure is only warned
+func (m *DatabaseManager) record(operation, name, version, location, previous string) {
+	err := m.RecordHistory(operation, name, version, location, previous)
+	if err != nil {
+		core.GetLogger().Warn("failed to record history", map[string]interface{}{
+			"operation": operation,
+			"name":      name,
+			"version":   version,
+			"error":     err,
+		})
+	}
 }
 
 func (m *DatabaseManager) Close() error {
@@ -66,12 +108,25 @@ func (m *DatabaseManager) Define(name string, version string, location string) (
 
 	location = defined.GetLocation()
 
-	command, _, err := m.getOrNew(name, version)
+	command, found, err := m.getOrNew(name, version)
 	if err != nil {
 		return nil, errors.Wrapf(err, "define command failed")
 	}
 
+	previous := ""
+	if found {
+		previous = command.Location
+	}
+
 	command.Location = location
+	// the metadata of the previous location is dropped, the fingerprint is verified later
+	command.Metadata, err = FingerprintBinary(location)
+	if err != nil {
+		core.GetLogger().Debug("failed to fingerprint binary", map[string]interface{}{
+			"location": location,
+			"error":    err,
+		})
+	}
 	core.GetLogger().Debug("defining command", map[string]interface{}{
 		"name":     name,
 		"version":  version,
@@ -83,9 +138,37 @@ func (m *DatabaseManager) Define(name string, version string, location string) (
 		return nil, errors.Wrapf(err, "save command failed")
 	}
 
+	m.record(core.HistoryOperationDefine, name, version, location, previous)
+
 	return command, nil
 }
 
+func (m *DatabaseManager) SetMetadata(name string, version string, metadata map[string]string) error {
+	command, found, err := m.getOrNew(name, version)
+	if err != nil {
+		return errors.Wrapf(err, "set metadata failed")
+	}
+
+	if !found {
+		return errors.Wrapf(core.ErrBinaryNotFound, "command %s(%s) not found", name, version)
+	}
+
+	if command.Metadata == nil {
+		command.Metadata = make(map[string]string, len(metadata))
+	}
+
+	for key, value := range metadata {
+		command.Metadata[key] = value
+	}
+
+	err = m.Client.Save(command)
+	if err != nil {
+		return errors.Wrapf(err, "save command failed")
+	}
+
+	return nil
+}
+
 func (m *DatabaseManager) Undefine(name string, version string) error {
 	command, found, err := m.getOrNew(name, version)
 	if err != nil {
@@ -105,12 +188,26 @@ func (m *DatabaseManager) Undefine(name string, version string) error {
 		return errors.Wrapf(core.ErrCommandAlreadyActivated, "command %s:%s is activated", name, version)
 	}
 
+	err = core.PublishCommandEvent(core.EventPreRemove, name, version, command.Location)
+	if err != nil {
+		return errors.WithMessagef(err, "%s of %s aborted", core.EventPreRemove, name)
+	}
+
 	err = m.Client.DeleteStruct(command)
 	if err != nil {
 		return errors.Wrapf(err, "delete command failed")
 	}
 
-	return m.manager.Undefine(name, version)
+	err = m.manager.Undefine(name, version)
+	if err != nil {
+		return err
+	}
+
+	_ = core.PublishCommandEvent(core.EventPostRemove, name, version, command.Location)
+
+	m.record(core.HistoryOperationUndefine, name, version, command.Location, "")
+
+	return nil
 }
 
 func (m *DatabaseManager) Activate(name string, version string) error {
@@ -128,7 +225,19 @@ func (m *DatabaseManager) Activate(name string, version string) error {
 		"version": version,
 	})
 
-	err = m.Deactivate(name)
+	// the events are published before any write, so an aborted activation changes nothing
+	err = core.PublishCommandEvent(core.EventPreActivate, name, version, command.Location)
+	if err != nil {
+		return errors.WithMessagef(err, "%s of %s aborted", core.EventPreActivate, name)
+	}
+
+	activated, err := m.activatedCommands(name)
+	if err != nil {
+		return err
+	}
+
+	// replacing the activated version is not a deactivation, so no deactivate event is published
+	previous, err := m.deactivate(name, activated)
 	if err != nil {
 		return errors.Wrapf(err, "deactivate commands failed")
 	}
@@ -140,36 +249,79 @@ func (m *DatabaseManager) Activate(name string, version string) error {
 		return errors.Wrapf(err, "save command failed")
 	}
 
-	return m.manager.Activate(name, version)
+	err = m.manager.Activate(name, version)
+	if err != nil {
+		return err
+	}
+
+	_ = core.PublishCommandEvent(core.EventPostActivate, name, version, command.Location)
+
+	m.record(core.HistoryOperationActivate, name, version, command.Location, previous)
+
+	return nil
 }
 
 func (m *DatabaseManager) Deactivate(name string) error {
+	commands, err := m.activatedCommands(name)
+	if err != nil || len(commands) == 0 {
+		return err
+	}
+
+	err = core.PublishCommandEvent(core.EventPreDeactivate, name, "", "")
+	if err != nil {
+		return errors.WithMessagef(err, "%s of %s aborted", core.EventPreDeactivate, name)
+	}
+
+	previous, err := m.deactivate(name, commands)
+	if err != nil {
+		return err
+	}
+
+	_ = core.PublishCommandEvent(core.EventPostDeactivate, name, "", "")
+
+	m.record(core.HistoryOperationDeactivate, name, "", "", previous)
+
+	return nil
+}
+
+func (m *DatabaseManager) activatedCommands(name string) ([]*Command, error) {
 	var commands []*Command
 	err := m.Client.Select(
 		q.Eq("Name", name),
 		q.Eq("Activated", true),
 	).Find(&commands)
 	switch errors.Cause(err) {
-	case nil:
-	case storm.ErrNotFound:
-		return nil
+	case nil, storm.ErrNotFound:
+		return commands, nil
 	default:
-		return errors.Wrapf(err, "select commands failed")
+		return nil, errors.Wrapf(err, "select commands failed")
+	}
+}
+
+// deactivate returns the version which was activated, it is empty when nothing changed
+func (m *DatabaseManager) deactivate(name string, commands []*Command) (string, error) {
+	if len(commands) == 0 {
+		return "", nil
 	}
 
 	core.GetLogger().Debug("deactivating commands", map[string]interface{}{
 		"name": name,
 	})
 
+	previous := ""
 	for _, cmd := range commands {
+		if previous == "" {
+			previous = cmd.Version
+		}
+
 		cmd.Activated = false
 		err := m.Client.Save(cmd)
 		if err != nil {
-			return errors.Wrapf(err, "deactivate command failed")
+			return "", errors.Wrapf(err, "deactivate command failed")
 		}
 	}
 
-	return m.manager.Deactivate(name)
+	return previous, m.manager.Deactivate(name)
 }
 
 func NewDatabaseManager(db core.Database, manager core.CommandManager) *DatabaseManager {
@@ -180,6 +332,10 @@ func NewDatabaseManager(db core.Database, manager core.CommandManager) *Database
 }
 
 func init() {
+	var _ core.CommandMetadataSetter = (*DatabaseManager)(nil)
+	var _ core.CommandHistoryRecorder = (*DatabaseManager)(nil)
+	var _ core.CommandMetadataGetter = (*Command)(nil)
+
 	core.RegisterCommandManagerFactory(core.CommandProviderDatabase, func(cfg core.Configuration) (core.CommandManager, error) {
 		mgr, err := core.NewCommandManager(core.CommandProviderBinary, cfg)
 		if err != nil {
@@ -191,6 +347,9 @@ func init() {
 			return nil, errors.Wrapf(err, "open database failed")
 		}
 
-		return NewDatabaseManager(db, mgr), nil
+		databaseMgr := NewDatabaseManager(db, mgr)
+		databaseMgr.SetHistory(NewHistoryStore(db))
+
+		return databaseMgr, nil
 	})
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 
 	"github.com/mrlyc/cmdr/core"
@@ -180,6 +181,63 @@ var _ = Describe("Database", func() {
 				Expect(err).To(BeNil())
 				Expect(result.GetLocation()).To(Equal(binaryCommand.GetLocation()))
 			})
+
+			It("should record the fingerprint of the binary", func() {
+				rootDir, err := os.MkdirTemp("", "")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(rootDir)
+
+				rootDir, err = filepath.EvalSymlinks(rootDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				binary := filepath.Join(rootDir, "binary")
+				Expect(os.WriteFile(binary, []byte("#!/bin/sh"), 0755)).To(Succeed())
+
+				shimCommand := mock.NewMockCommand(ctrl)
+				shimCommand.EXPECT().GetLocation().Return(binary).AnyTimes()
+
+				makeCommandFound()
+
+				binaryMgr.EXPECT().Define(commandName, version, location).Return(shimCommand, nil)
+
+				db.EXPECT().Save(gomock.Any()).DoAndReturn(func(data interface{}) error {
+					command := data.(*manager.Command)
+					Expect(command.Metadata).To(HaveKeyWithValue(manager.MetadataKeyBinarySize, "9"))
+					Expect(command.Metadata).To(HaveKeyWithValue(manager.MetadataKeyBinarySource, binary))
+					Expect(command.Metadata).To(HaveKey(manager.MetadataKeyBinarySHA256))
+					return nil
+				})
+
+				_, err = mgr.Define(commandName, version, location)
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Context("SetMetadata", func() {
+			It("should merge metadata", func() {
+				existsCommand.Metadata = map[string]string{"go.module": "github.com/mrlyc/cmdr"}
+				defer func() { existsCommand.Metadata = nil }()
+				makeCommandFound()
+
+				db.EXPECT().Save(gomock.Any()).DoAndReturn(func(data interface{}) error {
+					command, ok := data.(*manager.Command)
+					Expect(ok).To(BeTrue())
+
+					Expect(command.Metadata).To(Equal(map[string]string{
+						"go.module": "github.com/mrlyc/cmdr",
+						"go.sum":    "h1:xxx",
+					}))
+					return nil
+				})
+
+				Expect(mgr.SetMetadata(commandName, version, map[string]string{"go.sum": "h1:xxx"})).To(Succeed())
+			})
+
+			It("should return an error because command not found", func() {
+				makeCommandNotFound()
+
+				Expect(mgr.SetMetadata(commandName, version, nil)).NotTo(Succeed())
+			})
 		})
 
 		Context("Undefine", func() {
@@ -266,6 +324,92 @@ var _ = Describe("Database", func() {
 			})
 		})
 
+		Context("Events", func() {
+			var (
+				events []string
+				abort  string
+			)
+
+			handler := func(event *core.CommandEvent) {
+				events = append(events, event.Event)
+				if event.Event == abort {
+					event.Abort(errors.New("aborted"))
+				}
+			}
+
+			BeforeEach(func() {
+				events = nil
+				abort = ""
+				existsCommand.Activated = false
+				for _, event := range core.CommandEvents {
+					core.SubscribeEvent(event, handler)
+				}
+			})
+
+			AfterEach(func() {
+				for _, event := range core.CommandEvents {
+					core.UnsubscribeEvent(event, handler)
+				}
+			})
+
+			It("should not write when the activation is aborted", func() {
+				abort = core.EventPreActivate
+				makeCommandFound()
+
+				Expect(mgr.Activate(commandName, version)).To(MatchError(ContainSubstring("aborted")))
+				Expect(events).To(Equal([]string{core.EventPreActivate}))
+			})
+
+			It("should not publish deactivate events when replacing the activated version", func() {
+				abort = core.EventPreDeactivate
+				makeCommandFound()
+				makeActivatedCommandFound()
+
+				db.EXPECT().Save(gomock.Any()).Return(nil).Times(2)
+				binaryMgr.EXPECT().Deactivate(commandName).Return(nil)
+				binaryMgr.EXPECT().Activate(commandName, version).Return(nil)
+
+				Expect(mgr.Activate(commandName, version)).To(Succeed())
+				Expect(events).To(Equal([]string{core.EventPreActivate, core.EventPostActivate}))
+			})
+
+			It("should not publish post events when the shims failed", func() {
+				makeCommandFound()
+				makeActivatedCommandNotFound()
+
+				db.EXPECT().Save(gomock.Any()).Return(nil)
+				binaryMgr.EXPECT().Activate(commandName, version).Return(core.ErrBinaryNotFound)
+
+				Expect(mgr.Activate(commandName, version)).NotTo(Succeed())
+				Expect(events).To(Equal([]string{core.EventPreActivate}))
+			})
+
+			It("should not write when the deactivation is aborted", func() {
+				abort = core.EventPreDeactivate
+				makeActivatedCommandFound()
+
+				Expect(mgr.Deactivate(commandName)).To(MatchError(ContainSubstring("aborted")))
+				Expect(events).To(Equal([]string{core.EventPreDeactivate}))
+			})
+
+			It("should not write when the removal is aborted", func() {
+				abort = core.EventPreRemove
+				makeCommandFound()
+
+				Expect(mgr.Undefine(commandName, version)).To(MatchError(ContainSubstring("aborted")))
+				Expect(events).To(Equal([]string{core.EventPreRemove}))
+			})
+
+			It("should publish remove events", func() {
+				makeCommandFound()
+				db.EXPECT().DeleteStruct(gomock.Any()).Return(nil)
+				binaryMgr.EXPECT().Undefine(commandName, version).Return(nil)
+
+				Expect(mgr.Undefine(commandName, version)).To(Succeed())
+				Expect(events).To(Equal([]string{core.EventPreRemove, core.EventPostRemove}))
+			})
+		})
+
 		Context("Deactivate", func() {
 			It("should deactivate a command", func() {
 				makeActivatedCommandFound()
@@ -174,6 +174,27 @@ func (d *DoctorManager) Query() (core.CommandQuery, error) {
 	return NewCommandFilter(merged), nil
 }
 
+func (d *DoctorManager) SetHistoryCause(cause string) {
+	_ = d.all(func(mgr core.CommandManager) error {
+		recorder, ok := mgr.(core.CommandHistoryRecorder)
+		if ok {
+			recorder.SetHistoryCause(cause)
+		}
+
+		return nil
+	})
+}
+
+// RecordHistory records by the database manager
+func (d *DoctorManager) RecordHistory(operation, name, version, location, previous string) error {
+	recorder, ok := d.databaseMgr.(core.CommandHistoryRecorder)
+	if !ok {
+		return nil
+	}
+
+	return recorder.RecordHistory(operation, name, version, location, previous)
+}
+
 func NewDoctorManager(binaryMgr core.CommandManager, databaseMgr core.CommandManager) *DoctorManager {
 	return &DoctorManager{
 		binaryMgr:   binaryMgr,
@@ -254,6 +275,11 @@ func (d *CommandDoctor) FixWithOptions(dryRun bool, backup bool) error {
 		logger.Info("running in dry-run mode, no changes will be made", nil)
 	}
 
+	recorder, ok := d.CommandManager.(core.CommandHistoryRecorder)
+	if ok {
+		recorder.SetHistoryCause(HistoryCauseDoctor)
+	}
+
 	if backup && !dryRun {
 		backupDir, err := d.backup()
 		if err != nil {
@@ -405,6 +431,7 @@ func NewCommandDoctor(manager core.CommandManager, rootDir string) *CommandDocto
 
 func init() {
 	var _ core.CommandManager = (*DoctorManager)(nil)
+	var _ core.CommandHistoryRecorder = (*DoctorManager)(nil)
 
 	core.RegisterCommandManagerFactory(core.CommandProviderDoctor, func(cfg core.Configuration) (core.CommandManager, error) {
 		mainMgr, err := core.NewCommandManager(core.CommandProviderBinary, cfg)
@@ -1,41 +1,101 @@
 package manager
 
 import (
+	"context"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/hashicorp/go-getter"
+	ver "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 
 	"github.com/mrlyc/cmdr/core"
 	"github.com/mrlyc/cmdr/core/fetcher"
+	"github.com/mrlyc/cmdr/core/lockfile"
+	"github.com/mrlyc/cmdr/core/registry"
+	"github.com/mrlyc/cmdr/core/rewrite"
+	"github.com/mrlyc/cmdr/core/signature"
 	"github.com/mrlyc/cmdr/core/strategy"
 	"github.com/mrlyc/cmdr/core/utils"
 )
 
+// the metadata recorded by DownloadManager, which are used to lock the commands
+const (
+	MetadataKeyDownloadLocation       = "download.location"
+	MetadataKeyDownloadURI            = "download.uri"
+	MetadataKeyDownloadStrategy       = "download.strategy"
+	MetadataKeyDownloadEntry          = "download.entry"
+	MetadataKeyDownloadArtifactSHA256 = "download.artifact_sha256"
+	MetadataKeyDownloadSHA256         = "download.sha256"
+	MetadataKeyDownloadPlatform       = "download.platform"
+	MetadataKeyDownloadRegistry       = "download.registry"
+	MetadataKeyDownloadSignature      = "download.signature"
+)
+
 type DownloadManager struct {
 	core.CommandManager
-	fetchers     []core.Fetcher
-	retries      int
-	replacements utils.Replacements
-	strategy     *strategy.StrategyChain
+	fetchers []core.Fetcher
+	retries  int
+	rewriter *rewrite.Engine
+	strategy *strategy.StrategyChain
+	lockfile *lockfile.Lockfile
+	registry *registry.Registry
+	checker  *signature.Checker
 }
 
-func (m *DownloadManager) SetReplacements(replacements utils.Replacements) {
-	m.replacements = replacements
+// SetSignatureChecker requires the downloads to be signed by the keys of the matched policies
+func (m *DownloadManager) SetSignatureChecker(checker *signature.Checker) {
+	m.checker = checker
+}
+
+// SetRegistry allows to install the commands which are defined by registries without a location
+func (m *DownloadManager) SetRegistry(reg *registry.Registry) {
+	m.registry = reg
+}
+
+// SetLockfile freezes the manager, the commands are installed from the lockfile only
+func (m *DownloadManager) SetLockfile(lock *lockfile.Lockfile) {
+	m.lockfile = lock
+}
+
+func (m *DownloadManager) SetRewriter(rewriter *rewrite.Engine) {
+	m.rewriter = rewriter
 }
 
 func (m *DownloadManager) SetStrategyChain(chain *strategy.StrategyChain) {
 	m.strategy = chain
 }
 
-func (m *DownloadManager) getFetcherOptions() []getter.ClientOption {
-	return nil
+// fetcherOptionsSetter is implemented by the fetchers which download by go-getter
+type fetcherOptionsSetter interface {
+	SetOptions(options []getter.ClientOption)
 }
 
-func (m *DownloadManager) search(name, output string) (string, error) {
+// fetcherContextSetter is implemented by the fetchers which send the http requests by themselves
+type fetcherContextSetter interface {
+	SetContext(ctx context.Context)
+}
+
+func (m *DownloadManager) getFetcherOptions(ctx context.Context) []getter.ClientOption {
+	// the context carries the current strategy, which decides the transport of http requests
+	return []getter.ClientOption{getter.WithContext(ctx)}
+}
+
+func (m *DownloadManager) search(name, entry, output string) (string, error) {
+	// the entry is given by registries, so it must exist
+	if entry != "" {
+		path := filepath.Join(output, filepath.FromSlash(entry))
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			return "", errors.Wrapf(core.ErrBinaryNotFound, "entry %s not found", entry)
+		}
+
+		return path, nil
+	}
+
 	files := utils.NewSortedHeap(1)
 	nameLower := strings.ToLower(name)
 	nameLength := float64(len(nameLower))
@@ -79,47 +139,67 @@ func (m *DownloadManager) search(name, output string) (string, error) {
 	return file.(string), nil
 }
 
-func (m *DownloadManager) fetch(f core.Fetcher, name, version, location, output string) (string, error) {
+type fetchResult struct {
+	path      string
+	strategy  string
+	artifacts []fetcher.Artifact
+}
+
+// entry returns the path of the binary relative to the output dir
+func (r *fetchResult) entry(output string) string {
+	entry, err := filepath.Rel(output, r.path)
+	if err != nil {
+		return ""
+	}
+
+	return filepath.ToSlash(entry)
+}
+
+// artifact returns the last downloaded artifact
+func (r *fetchResult) artifact() *fetcher.Artifact {
+	if len(r.artifacts) == 0 {
+		return nil
+	}
+
+	return &r.artifacts[len(r.artifacts)-1]
+}
+
+// artifactSHA256 returns the digest of the last downloaded artifact
+func (r *fetchResult) artifactSHA256() string {
+	artifact := r.artifact()
+	if artifact == nil {
+		return ""
+	}
+
+	return artifact.SHA256
+}
+
+// fetch downloads the location into output, the artifacts are kept in keepDir when it is not empty
+func (m *DownloadManager) fetch(f core.Fetcher, name, version, location, entry, output, keepDir string) (*fetchResult, error) {
 	logger := core.GetLogger()
 	logger.Info("fetching", map[string]interface{}{
 		"uri": location,
 	})
 
+	recorder := fetcher.NewArtifactRecorder()
+	recorder.SetKeepDir(keepDir)
+	ctx := fetcher.WithArtifactRecorder(context.Background(), recorder)
+	result := &fetchResult{}
+
 	// Use strategy chain if available
 	if m.strategy != nil {
-		var finalResult string
-
 		// Execute strategy chain
-		err := m.strategy.Execute(location, func(uri string) error {
+		err := m.strategy.ExecuteContext(ctx, location, func(ctx context.Context, uri string) error {
 			logger.Debug("downloading with URI", map[string]interface{}{
 				"uri": uri,
 			})
 
-			// Apply URL rewriting if enabled
-			for _, strat := range m.strategy.Strategies() {
-				if rewriteStrat, ok := strat.(*strategy.RewriteStrategy); ok && rewriteStrat.IsEnabledConfigured() {
-					rewritten, err := rewriteStrat.GetRewrittenURI(uri)
-					if err != nil {
-						logger.Warn("URL rewrite failed, using original", map[string]interface{}{
-							"error": err.Error(),
-						})
-					} else if rewritten != uri {
-						logger.Info("URL rewritten", map[string]interface{}{
-							"original":  uri,
-							"rewritten": rewritten,
-						})
-						uri = rewritten
-					}
-				}
-			}
-
-			// Apply replacements
-			uri, _ = m.replacements.ReplaceString(uri)
-
 			// Update fetcher options based on current strategy
-			if gg, ok := f.(*fetcher.GoGetter); ok {
-				options := m.getFetcherOptions()
-				gg.SetOptions(options)
+			if setter, ok := f.(fetcherOptionsSetter); ok {
+				setter.SetOptions(m.getFetcherOptions(ctx))
+			}
+			if setter, ok := f.(fetcherContextSetter); ok {
+				setter.SetContext(ctx)
 			}
 
 			// Try download
@@ -129,28 +209,37 @@ func (m *DownloadManager) fetch(f core.Fetcher, name, version, location, output
 			}
 
 			// Download succeeded, search for binary
-			result, searchErr := m.search(name, output)
+			path, searchErr := m.search(name, entry, output)
 			if searchErr != nil {
 				return searchErr
 			}
 
-			finalResult = result
+			result.path = path
+			if current, ok := strategy.StrategyFromContext(ctx); ok {
+				result.strategy = current.Name()
+			}
+
 			return nil
 		})
 
 		if err != nil {
-			return "", errors.Wrapf(err, "failed to download %s", location)
+			return nil, errors.Wrapf(err, "failed to download %s", location)
 		}
 
-		return finalResult, nil
+		result.artifacts = recorder.Artifacts()
+		return result, nil
+	}
+
+	if setter, ok := f.(fetcherOptionsSetter); ok {
+		setter.SetOptions(m.getFetcherOptions(ctx))
+	}
+	if setter, ok := f.(fetcherContextSetter); ok {
+		setter.SetContext(ctx)
 	}
 
 	// Fallback to old retry logic
 	var err error
 	for i := 0; i < m.retries; i++ {
-		// Apply replacements
-		location, _ = m.replacements.ReplaceString(location)
-
 		err = f.Fetch(name, version, location, output)
 		if err == nil {
 			break
@@ -162,17 +251,126 @@ func (m *DownloadManager) fetch(f core.Fetcher, name, version, location, output
 	}
 
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to download %s", location)
+		return nil, errors.Wrapf(err, "failed to download %s", location)
+	}
+
+	result.path, err = m.search(name, entry, output)
+	if err != nil {
+		return nil, err
+	}
+
+	result.artifacts = recorder.Artifacts()
+	return result, nil
+}
+
+// lookupLockfile returns the locked artifact, the location is replaced by the locked one
+func (m *DownloadManager) lookupLockfile(name, version, location, platform string) (string, *lockfile.Artifact, error) {
+	entry, artifact, err := m.lockfile.Lookup(name, version, platform)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return m.search(name, output)
+	if location != "" && location != entry.Location {
+		core.GetLogger().Warn("location differs from the lockfile, using the locked one", map[string]interface{}{
+			"location": location,
+			"locked":   entry.Location,
+		})
+	}
+
+	return entry.Location, artifact, nil
 }
 
 func (m *DownloadManager) Define(name string, version string, uriOrLocation string) (core.Command, error) {
-	uriOrLocation, _ = m.replacements.ReplaceString(uriOrLocation)
+	err := core.PublishCommandEvent(core.EventPreInstall, name, version, uriOrLocation)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%s of %s aborted", core.EventPreInstall, name)
+	}
+
+	command, err := m.define(name, version, uriOrLocation)
+	if err != nil {
+		return command, err
+	}
+
+	location := ""
+	if command != nil {
+		location = command.GetLocation()
+	}
+
+	_ = core.PublishCommandEvent(core.EventPostInstall, name, version, location)
+
+	return command, nil
+}
+
+func (m *DownloadManager) define(name string, version string, uriOrLocation string) (core.Command, error) {
+	var (
+		locked     *lockfile.Artifact
+		resolution *registry.Resolution
+		location   = uriOrLocation
+		platform   = lockfile.CurrentPlatform()
+	)
+
+	if m.lockfile != nil {
+		var err error
+		location, locked, err = m.lookupLockfile(name, version, location, platform)
+		if err != nil {
+			return nil, err
+		}
+
+		// the locked url is rewritten already
+		uriOrLocation = locked.URL
+	} else if location == "" && m.registry != nil {
+		var err error
+		resolution, err = m.registry.Resolve(name, version, runtime.GOOS, runtime.GOARCH)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %s %s by registries", name, version)
+		}
+
+		uriOrLocation = m.rewriter.Rewrite(name, version, resolution.Location)
+	} else {
+		// the rules are applied only once, so the final uri is predictable
+		uriOrLocation = m.rewriter.Rewrite(name, version, uriOrLocation)
+	}
 
-	for _, fetcher := range m.fetchers {
-		if !fetcher.IsSupport(uriOrLocation) {
+	metadata := map[string]string{
+		MetadataKeyDownloadLocation: location,
+		MetadataKeyDownloadURI:      uriOrLocation,
+		MetadataKeyDownloadPlatform: platform,
+	}
+
+	entry := ""
+	subject := &signature.Subject{
+		Location: location,
+		URL:      uriOrLocation,
+		Path:     uriOrLocation,
+	}
+	if locked != nil {
+		// the locked entry was found in the same artifact, the search heuristics may pick another file
+		entry = locked.Entry
+	} else if resolution != nil {
+		entry = resolution.Entry
+		metadata[MetadataKeyDownloadRegistry] = resolution.Registry
+		subject.Location = resolution.Location
+		subject.Registry = resolution.Registry
+	}
+
+	// the downloaded files are signed rather than the extracted binaries, so they are kept to verify
+	keepDir := ""
+	if m.checker != nil && len(m.checker.Match(subject.Location, subject.Registry)) > 0 {
+		dir, err := os.MkdirTemp("", "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create temp dir")
+		}
+		defer os.RemoveAll(dir)
+
+		keepDir = dir
+	}
+
+	var (
+		fetched        bool
+		artifactSHA256 string
+	)
+	for _, f := range m.fetchers {
+		if !f.IsSupport(uriOrLocation) {
 			continue
 		}
 
@@ -182,25 +380,335 @@ func (m *DownloadManager) Define(name string, version string, uriOrLocation stri
 		}
 		defer os.RemoveAll(dst)
 
-		location, err := m.fetch(fetcher, name, version, uriOrLocation, dst)
+		result, err := m.fetch(f, name, version, uriOrLocation, entry, dst, keepDir)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to fetch %s", location)
+			return nil, errors.Wrapf(err, "failed to fetch %s", uriOrLocation)
+		}
+
+		// the entry is in the downloaded artifact only
+		entry = ""
+
+		if !fetched {
+			fetched = true
+			artifactSHA256 = result.artifactSHA256()
+			metadata[MetadataKeyDownloadStrategy] = result.strategy
+			metadata[MetadataKeyDownloadEntry] = result.entry(dst)
+			metadata[MetadataKeyDownloadArtifactSHA256] = artifactSHA256
+
+			artifact := result.artifact()
+			if artifact != nil && artifact.Path != "" {
+				subject.URL, subject.Path = artifact.URL, artifact.Path
+			}
 		}
 
-		uriOrLocation = location
+		uriOrLocation = result.path
+
+		if metadataFetcher, ok := f.(core.MetadataFetcher); ok {
+			for key, value := range metadataFetcher.GetMetadata(name, version) {
+				metadata[key] = value
+			}
+		}
+	}
+
+	digest, err := utils.SHA256File(uriOrLocation)
+	if err == nil {
+		metadata[MetadataKeyDownloadSHA256] = digest
+	} else if locked != nil {
+		return nil, errors.Wrapf(err, "failed to verify %s", name)
 	}
 
-	return m.CommandManager.Define(name, version, uriOrLocation)
+	if locked != nil {
+		err = locked.Verify(artifactSHA256, digest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to verify %s %s", name, version)
+		}
+	}
+
+	if resolution != nil {
+		err = m.verifyChecksum(resolution, artifactSHA256, digest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to verify %s %s", name, version)
+		}
+	}
+
+	err = m.verifySignature(subject, uriOrLocation, metadata)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to verify signature of %s %s", name, version)
+	}
+
+	command, err := m.CommandManager.Define(name, version, uriOrLocation)
+	if err != nil {
+		return command, err
+	}
+
+	setter, ok := m.CommandManager.(core.CommandMetadataSetter)
+	if !ok {
+		return command, nil
+	}
+
+	for key, value := range metadata {
+		if value == "" {
+			delete(metadata, key)
+		}
+	}
+
+	err = setter.SetMetadata(name, version, metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to record metadata of %s", name)
+	}
+
+	return command, nil
+}
+
+// verifyChecksum compares the published checksum with the downloaded artifact,
+// the binary is compared when it is downloaded directly
+func (m *DownloadManager) verifyChecksum(resolution *registry.Resolution, artifactSHA256, sha256 string) error {
+	expected, err := m.registry.Checksum(context.Background(), resolution)
+	if err != nil || expected == "" {
+		return err
+	}
+
+	actual := artifactSHA256
+	if actual == "" {
+		actual = sha256
+	}
+
+	if actual != expected {
+		return errors.Wrapf(registry.ErrChecksumMismatch, "expected %s, got %s", expected, actual)
+	}
+
+	return nil
+}
+
+// verifySignature checks the downloaded file by the signature policies, the binary is checked when
+// nothing was downloaded, like the local files, then the verified policies are recorded
+func (m *DownloadManager) verifySignature(subject *signature.Subject, binary string, metadata map[string]string) error {
+	if m.checker == nil {
+		return nil
+	}
+
+	info, err := os.Stat(subject.Path)
+	if err != nil || info.IsDir() {
+		subject.Path = binary
+	}
+
+	policies, err := m.checker.Check(subject)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+	}
+
+	metadata[MetadataKeyDownloadSignature] = strings.Join(names, ",")
+
+	return nil
+}
+
+// Resolve fetches the location for a platform and returns what would be locked,
+// only go-getter downloads do not depend on the running platform, so the others are resolved for it only
+func (m *DownloadManager) Resolve(name, version, location, goos, goarch string) (*lockfile.Artifact, error) {
+	var (
+		resolution *registry.Resolution
+		entry      string
+	)
+	if location == "" && m.registry != nil {
+		var err error
+		resolution, err = m.registry.Resolve(name, version, goos, goarch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %s %s by registries", name, version)
+		}
+
+		location = resolution.Location
+		entry = resolution.Entry
+	}
+
+	uri := m.rewriter.RewriteFor(name, version, location, goos, goarch)
+	native := lockfile.Platform(goos, goarch) == lockfile.CurrentPlatform()
+
+	// the same uri would download the native artifact and record its digest for another platform
+	if !native && resolution == nil && uri == m.rewriter.Explain(name, version, location).Output {
+		return nil, errors.Wrapf(core.ErrPlatformNotSupported, "%s does not vary by platform", uri)
+	}
+
+	for _, f := range m.fetchers {
+		if !f.IsSupport(uri) {
+			continue
+		}
+
+		if _, ok := f.(*fetcher.GoGetter); !ok && !native {
+			return nil, errors.Wrapf(core.ErrPlatformNotSupported, "%s can not be resolved for %s/%s", uri, goos, goarch)
+		}
+
+		dst, err := os.MkdirTemp("", "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create temp dir")
+		}
+		defer os.RemoveAll(dst)
+
+		result, err := m.fetch(f, name, version, uri, entry, dst, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch %s", uri)
+		}
+
+		digest, err := utils.SHA256File(result.path)
+		if err != nil {
+			return nil, err
+		}
+
+		if resolution != nil {
+			err = m.verifyChecksum(resolution, result.artifactSHA256(), digest)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to verify %s %s", name, version)
+			}
+		}
+
+		return &lockfile.Artifact{
+			URL:            uri,
+			Strategy:       result.strategy,
+			Entry:          result.entry(dst),
+			ArtifactSHA256: result.artifactSHA256(),
+			SHA256:         digest,
+		}, nil
+	}
+
+	if !native {
+		return nil, errors.Wrapf(core.ErrPlatformNotSupported, "%s is a local file", uri)
+	}
+
+	digest, err := utils.SHA256File(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lockfile.Artifact{URL: uri, SHA256: digest}, nil
+}
+
+// findGoToolchain finds the latest go command managed by cmdr which matches the version, like 1.22
+func findGoToolchain(manager core.CommandManager, version string) (string, error) {
+	query, err := manager.Query()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to query go commands")
+	}
+
+	commands, err := query.WithName("go").All()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to query go commands")
+	}
+
+	version = strings.TrimPrefix(version, "go")
+
+	var (
+		location string
+		latest   *ver.Version
+	)
+	for _, command := range commands {
+		current, err := ver.NewVersion(command.GetVersion())
+		if err != nil {
+			continue
+		}
+
+		normalized := current.String()
+		if normalized != version && !strings.HasPrefix(normalized, version+".") &&
+			command.GetVersion() != version {
+			continue
+		}
+
+		if latest == nil || current.GreaterThan(latest) {
+			latest = current
+			location = command.GetLocation()
+		}
+	}
+
+	if location == "" {
+		return "", errors.Wrapf(core.ErrBinaryNotFound, "go %s is not managed by cmdr", version)
+	}
+
+	return location, nil
+}
+
+func newGoInstallerByConfiguration(cfg core.Configuration, manager core.CommandManager) (*fetcher.GoInstaller, error) {
+	installer := fetcher.NewDefaultGoInstaller()
+	installer.SetOffline(cfg.GetBool(core.CfgKeyCmdrOffline))
+	installer.SetOptions(fetcher.GoInstallOptions{
+		GoFlags:     cfg.GetString(core.CfgKeyXCommandInstallGoFlags),
+		CGOEnabled:  cfg.GetString(core.CfgKeyXCommandInstallGoCGOEnabled),
+		Tags:        cfg.GetStringSlice(core.CfgKeyXCommandInstallGoTags),
+		LDFlags:     cfg.GetString(core.CfgKeyXCommandInstallGoLDFlags),
+		GoPrivate:   cfg.GetString(core.CfgKeyXCommandInstallGoPrivate),
+		AllowLatest: cfg.GetBool(core.CfgKeyXCommandInstallGoAllowLatest),
+	})
+
+	if cfg.GetBool(core.CfgKeyXCommandInstallGoIsolated) {
+		installer.SetIsolatedDir(filepath.Join(cfg.GetString(core.CfgKeyCmdrRootDir), "go"))
+	}
+
+	version := cfg.GetString(core.CfgKeyXCommandInstallGoVersion)
+	if version == "" {
+		return installer, nil
+	}
+
+	goPath, err := findGoToolchain(manager, version)
+	if err != nil {
+		return nil, err
+	}
+
+	installer.SetGoPath(goPath)
+
+	return installer, nil
+}
+
+func newRecipeByConfiguration(cfg core.Configuration) (*fetcher.Recipe, error) {
+	recipe := &fetcher.Recipe{}
+
+	path := cfg.GetString(core.CfgKeyXCommandInstallRecipe)
+	if path != "" {
+		loaded, err := fetcher.LoadRecipe(path)
+		if err != nil {
+			return nil, err
+		}
+
+		recipe = loaded
+	}
+
+	// the flags take precedence over the recipe file
+	commands := cfg.GetStringSlice(core.CfgKeyXCommandInstallBuildCommands)
+	if len(commands) > 0 {
+		recipe.Commands = commands
+	}
+
+	output := cfg.GetString(core.CfgKeyXCommandInstallBuildOutput)
+	if output != "" {
+		recipe.Output = output
+	}
+
+	timeout := cfg.GetInt(core.CfgKeyXCommandInstallBuildTimeout)
+	if timeout > 0 {
+		recipe.Timeout = timeout
+	}
+
+	env := cfg.GetStringMapString(core.CfgKeyXCommandInstallBuildEnv)
+	if len(env) > 0 && recipe.Env == nil {
+		recipe.Env = make(map[string]string, len(env))
+	}
+
+	for key, value := range env {
+		recipe.Env[key] = value
+	}
+
+	return recipe, nil
 }
 
 func NewDownloadManager(
-	manager core.CommandManager, fetchers []core.Fetcher, retries int, replacements utils.Replacements,
+	manager core.CommandManager, fetchers []core.Fetcher, retries int, rewriter *rewrite.Engine,
 ) *DownloadManager {
 	return &DownloadManager{
 		CommandManager: manager,
 		fetchers:       fetchers,
 		retries:        retries,
-		replacements:   replacements,
+		rewriter:       rewriter,
 	}
 }
 
@@ -211,31 +719,98 @@ func init() {
 			utils.ExitOnError("Failed to create command manager", err)
 		}
 
-		var replacements utils.Replacements
-		err = cfg.UnmarshalKey(core.CfgKeyDownloadReplace, &replacements)
+		rewriter, err := rewrite.NewEngineByConfiguration(cfg)
+		if err != nil {
+			utils.ExitOnError("Failed to parse download rewrite rules", err)
+		}
+
+		strategyChain, err := strategy.NewStrategyChainByConfiguration(cfg)
+		if err != nil {
+			utils.ExitOnError("Failed to configure download strategies", err)
+		}
+
+		offline := cfg.GetBool(core.CfgKeyCmdrOffline)
+
+		goInstaller, err := newGoInstallerByConfiguration(cfg, manager)
+		if err != nil {
+			utils.ExitOnError("Failed to choose go toolchain", err)
+		}
+
+		cargoInstaller := fetcher.NewDefaultCargoInstaller()
+		cargoInstaller.SetOffline(offline)
+
+		// the packages must outlive the download dir, so they are kept under the shims dir
+		shimsDir := cfg.GetString(core.CfgKeyCmdrShimsDir)
+
+		npmInstaller := fetcher.NewDefaultNpmInstaller(shimsDir)
+		npmInstaller.SetOffline(offline)
+
+		pipxInstaller := fetcher.NewDefaultPipxInstaller(shimsDir)
+		pipxInstaller.SetOffline(offline)
+
+		recipe, err := newRecipeByConfiguration(cfg)
 		if err != nil {
-			utils.ExitOnError("Failed to parse download replace config", err)
+			utils.ExitOnError("Failed to load build recipe", err)
 		}
 
-		// Create strategy chain
-		strategyChain := strategy.NewStrategyChain(
-			strategy.NewDirectStrategy(),
-			strategy.NewRewriteStrategy(),
-			strategy.NewProxyStrategy(),
+		gitBuilder := fetcher.NewDefaultGitBuilder(
+			cfg.GetString(core.CfgKeyCmdrStagingDir), cfg.GetString(core.CfgKeyCmdrLogDir),
 		)
+		gitBuilder.SetRecipe(recipe)
+		gitBuilder.SetOffline(offline)
 
-		// Configure strategies
-		if err := strategyChain.Configure(cfg); err != nil {
-			utils.ExitOnError("Failed to configure download strategies", err)
+		ociFetcher, err := fetcher.NewDefaultOCIFetcher()
+		if err != nil {
+			utils.ExitOnError("Failed to create oci fetcher", err)
 		}
+		ociFetcher.SetInsecureRegistries(cfg.GetStringSlice(core.CfgKeyDownloadOCIInsecureRegistries))
+		ociFetcher.SetOffline(offline)
+
+		goGetter := fetcher.NewDefaultGoGetter(os.Stderr)
+		goGetter.SetGetters(fetcher.NewResumableGetters(
+			cfg.GetString(core.CfgKeyCmdrStagingDir), cfg.GetDuration(core.CfgKeyCmdrStagingTTL),
+		))
+		goGetter.SetOffline(offline)
+
+		gitHubFetcher := fetcher.NewDefaultGitHubReleaseFetcher(goGetter)
+		gitHubFetcher.SetOffline(offline)
 
 		downloadManager := NewDownloadManager(manager, []core.Fetcher{
-			fetcher.NewDefaultGoInstaller(),
-			fetcher.NewDefaultGoGetter(os.Stderr),
-		}, 3, replacements)
+			goInstaller,
+			cargoInstaller,
+			npmInstaller,
+			pipxInstaller,
+			gitBuilder,
+			ociFetcher, // must be before go-getter, which treats oci:// as a url
+			gitHubFetcher,
+			goGetter,
+		}, 3, rewriter)
 
 		downloadManager.SetStrategyChain(strategyChain)
 
+		reg, err := registry.NewRegistryByConfiguration(cfg)
+		if err != nil {
+			utils.ExitOnError("Failed to parse registries", err)
+		}
+
+		downloadManager.SetRegistry(reg)
+
+		checker, err := signature.NewCheckerByConfiguration(cfg)
+		if err != nil {
+			utils.ExitOnError("Failed to parse signature policies", err)
+		}
+
+		downloadManager.SetSignatureChecker(checker)
+
+		if cfg.GetBool(core.CfgKeyXCommandInstallFrozen) {
+			lock, err := lockfile.Load(cfg.GetString(core.CfgKeyXCommandInstallLockfile))
+			if err != nil {
+				utils.ExitOnError("Failed to load lockfile", err)
+			}
+
+			downloadManager.SetLockfile(lock)
+		}
+
 		return downloadManager, nil
 	})
 }
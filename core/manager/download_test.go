@@ -2,8 +2,21 @@ package manager_test
 
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -12,11 +25,17 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/lockfile"
 	"github.com/mrlyc/cmdr/core/manager"
 	"github.com/mrlyc/cmdr/core/mock"
-	"github.com/mrlyc/cmdr/core/utils"
+	"github.com/mrlyc/cmdr/core/registry"
+	"github.com/mrlyc/cmdr/core/rewrite"
+	"github.com/mrlyc/cmdr/core/signature"
 )
 
+// the digest of an empty file
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
 var _ = Describe("Download", func() {
 	var (
 		ctrl      *gomock.Controller
@@ -61,6 +80,18 @@ var _ = Describe("Download", func() {
 			Expect(downloadManager.Define(name, version, uri)).To(Succeed())
 		})
 
+		It("should be aborted by pre-install handlers", func() {
+			abort := func(event *core.CommandEvent) {
+				Expect(event.Name).To(Equal(name))
+				event.Abort(errors.New("aborted"))
+			}
+			core.SubscribeEvent(core.EventPreInstall, abort)
+			defer core.UnsubscribeEvent(core.EventPreInstall, abort)
+
+			_, err := downloadManager.Define(name, version, uri)
+			Expect(err).To(MatchError(ContainSubstring("aborted")))
+		})
+
 		It("should call with downloaded file", func() {
 			var targetPath string
 
@@ -141,16 +172,291 @@ var _ = Describe("Download", func() {
 			}, "cmdr"),
 		)
 
+		It("should record metadata", func() {
+			metadataFetcher := mock.NewMockMetadataFetcher(ctrl)
+			setter := mock.NewMockCommandMetadataSetter(ctrl)
+			downloadManager = manager.NewDownloadManager(struct {
+				*mock.MockCommandManager
+				*mock.MockCommandMetadataSetter
+			}{baseManager, setter}, []core.Fetcher{metadataFetcher}, 1, nil)
+
+			metadataFetcher.EXPECT().IsSupport(uri).Return(true)
+			metadataFetcher.EXPECT().Fetch(name, version, gomock.Any(), gomock.Any()).DoAndReturn(func(name, version, uri, dir string) error {
+				return os.WriteFile(filepath.Join(dir, "cmdr"), []byte(""), 0755)
+			})
+			metadataFetcher.EXPECT().GetMetadata(name, version).Return(map[string]string{"go.sum": "h1:xxx"})
+			baseManager.EXPECT().Define(name, version, gomock.Any())
+			setter.EXPECT().SetMetadata(name, version, gomock.Any()).DoAndReturn(func(name, version string, metadata map[string]string) error {
+				Expect(metadata).To(HaveKeyWithValue("go.sum", "h1:xxx"))
+				Expect(metadata).To(HaveKeyWithValue(manager.MetadataKeyDownloadEntry, "cmdr"))
+				Expect(metadata).To(HaveKeyWithValue(manager.MetadataKeyDownloadPlatform, lockfile.CurrentPlatform()))
+				Expect(metadata).To(HaveKeyWithValue(manager.MetadataKeyDownloadSHA256, emptySHA256))
+				return nil
+			})
+
+			Expect(downloadManager.Define(name, version, uri)).To(Succeed())
+		})
+
+		Context("frozen", func() {
+			var (
+				lock     *lockfile.Lockfile
+				location = "https://example.com/cmdr"
+				locked   = "https://mirror.example.com/cmdr"
+			)
+
+			BeforeEach(func() {
+				lock = lockfile.New()
+				downloadManager.SetLockfile(lock)
+			})
+
+			It("should fail when not locked", func() {
+				_, err := downloadManager.Define(name, version, location)
+				Expect(errors.Is(err, lockfile.ErrNotLocked)).To(BeTrue())
+			})
+
+			It("should install the locked url", func() {
+				lock.Set(name, version, location, lockfile.CurrentPlatform(), &lockfile.Artifact{
+					URL: locked, SHA256: emptySHA256,
+				})
+
+				fetcher.EXPECT().IsSupport(locked).Return(true)
+				fetcher.EXPECT().Fetch(name, version, locked, gomock.Any()).DoAndReturn(func(name, version, uri, dir string) error {
+					return os.WriteFile(filepath.Join(dir, "cmdr"), []byte(""), 0755)
+				})
+				baseManager.EXPECT().Define(name, version, gomock.Any())
+
+				Expect(downloadManager.Define(name, version, location)).To(Succeed())
+			})
+
+			It("should install the locked entry", func() {
+				lock.Set(name, version, location, lockfile.CurrentPlatform(), &lockfile.Artifact{
+					URL: locked, Entry: "bin/cmdr", SHA256: emptySHA256,
+				})
+
+				fetcher.EXPECT().IsSupport(locked).Return(true)
+				fetcher.EXPECT().Fetch(name, version, locked, gomock.Any()).DoAndReturn(func(name, version, uri, dir string) error {
+					Expect(os.MkdirAll(filepath.Join(dir, "bin"), 0755)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(dir, "cmdr"), []byte("shorter"), 0755)).To(Succeed())
+					return os.WriteFile(filepath.Join(dir, "bin", "cmdr"), []byte(""), 0755)
+				})
+				baseManager.EXPECT().Define(name, version, gomock.Any()).DoAndReturn(func(name, version, location string) (core.Command, error) {
+					Expect(location).To(HaveSuffix(filepath.Join("bin", "cmdr")))
+					return nil, nil
+				})
+
+				Expect(downloadManager.Define(name, version, location)).To(Succeed())
+			})
+
+			It("should fail on digest drift", func() {
+				lock.Set(name, version, location, lockfile.CurrentPlatform(), &lockfile.Artifact{
+					URL: locked, SHA256: emptySHA256,
+				})
+
+				fetcher.EXPECT().IsSupport(locked).Return(true)
+				fetcher.EXPECT().Fetch(name, version, locked, gomock.Any()).DoAndReturn(func(name, version, uri, dir string) error {
+					return os.WriteFile(filepath.Join(dir, "cmdr"), []byte("evil"), 0755)
+				})
+
+				_, err := downloadManager.Define(name, version, location)
+				Expect(errors.Is(err, lockfile.ErrDigestDrift)).To(BeTrue())
+			})
+		})
+
+		Context("signature", func() {
+			var (
+				dir      string
+				key      *ecdsa.PrivateKey
+				location string
+			)
+
+			sign := func(data []byte) {
+				digest := sha256.Sum256(data)
+				sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+				Expect(err).To(BeNil())
+				Expect(os.WriteFile(location+".sig", []byte(base64.StdEncoding.EncodeToString(sig)), 0644)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				var err error
+				dir, err = os.MkdirTemp("", "")
+				Expect(err).To(BeNil())
+
+				key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				Expect(err).To(BeNil())
+
+				der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+				Expect(err).To(BeNil())
+
+				keyPath := filepath.Join(dir, "cosign.pub")
+				Expect(os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644)).To(Succeed())
+
+				location = filepath.Join(dir, "cmdr")
+				Expect(os.WriteFile(location, []byte("#!/bin/sh"), 0755)).To(Succeed())
+
+				checker, err := signature.NewChecker(&signature.Policy{Name: "cmdr", Key: keyPath})
+				Expect(err).To(BeNil())
+				downloadManager.SetSignatureChecker(checker)
+
+				fetcher.EXPECT().IsSupport(location).Return(false)
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(dir)).To(Succeed())
+			})
+
+			It("should install the signed file", func() {
+				sign([]byte("#!/bin/sh"))
+				baseManager.EXPECT().Define(name, version, location)
+
+				Expect(downloadManager.Define(name, version, location)).To(Succeed())
+			})
+
+			It("should abort before defining when the signature mismatches", func() {
+				sign([]byte("evil"))
+
+				_, err := downloadManager.Define(name, version, location)
+				Expect(errors.Is(err, signature.ErrSignatureMismatch)).To(BeTrue())
+			})
+
+			It("should abort before defining when the signature is absent", func() {
+				_, err := downloadManager.Define(name, version, location)
+				Expect(errors.Is(err, signature.ErrSignatureAbsent)).To(BeTrue())
+			})
+		})
+
+		Context("registry", func() {
+			var (
+				dir      string
+				server   *httptest.Server
+				checksum string
+				resolved string
+			)
+
+			BeforeEach(func() {
+				var err error
+				dir, err = os.MkdirTemp("", "")
+				Expect(err).To(BeNil())
+
+				checksum = emptySHA256
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_, _ = fmt.Fprintf(w, "%s  cmdr-1.0.0-%s\n", checksum, runtime.GOOS)
+				}))
+
+				index := filepath.Join(dir, "index.yaml")
+				Expect(os.WriteFile(index, []byte(fmt.Sprintf(`
+tools:
+  cmdr:
+    location: "https://example.com/{{ .Name }}-{{ .Version }}-{{ .GOOS }}"
+    entry: "bin/{{ .Name }}"
+    checksum: "%s/SHA256SUMS"
+`, server.URL)), 0644)).To(Succeed())
+
+				reg := registry.NewRegistry(dir, &registry.Source{Name: "local", URL: index})
+				reg.SetHTTPClient(server.Client())
+				downloadManager.SetRegistry(reg)
+
+				resolved = fmt.Sprintf("https://example.com/cmdr-1.0.0-%s", runtime.GOOS)
+			})
+
+			AfterEach(func() {
+				server.Close()
+				Expect(os.RemoveAll(dir)).To(Succeed())
+			})
+
+			fetchEntry := func(content string) {
+				fetcher.EXPECT().IsSupport(resolved).Return(true)
+				fetcher.EXPECT().Fetch(name, version, resolved, gomock.Any()).DoAndReturn(func(name, version, uri, dir string) error {
+					Expect(os.MkdirAll(filepath.Join(dir, "bin"), 0755)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(dir, "cmdr-shorter"), []byte(""), 0755)).To(Succeed())
+					return os.WriteFile(filepath.Join(dir, "bin", "cmdr"), []byte(content), 0755)
+				})
+			}
+
+			It("should install the entry of the resolved location", func() {
+				fetchEntry("")
+				baseManager.EXPECT().Define(name, version, gomock.Any()).DoAndReturn(func(name, version, location string) (core.Command, error) {
+					Expect(location).To(HaveSuffix(filepath.Join("bin", "cmdr")))
+					return nil, nil
+				})
+
+				Expect(downloadManager.Define(name, version, "")).To(Succeed())
+			})
+
+			It("should fail on checksum mismatch", func() {
+				fetchEntry("evil")
+
+				_, err := downloadManager.Define(name, version, "")
+				Expect(errors.Is(err, registry.ErrChecksumMismatch)).To(BeTrue())
+			})
+
+			It("should fail when the entry is missing", func() {
+				fetcher.EXPECT().IsSupport(resolved).Return(true)
+				fetcher.EXPECT().Fetch(name, version, resolved, gomock.Any()).DoAndReturn(func(name, version, uri, dir string) error {
+					return os.WriteFile(filepath.Join(dir, "cmdr"), []byte(""), 0755)
+				})
+
+				_, err := downloadManager.Define(name, version, "")
+				Expect(errors.Is(err, core.ErrBinaryNotFound)).To(BeTrue())
+			})
+
+			It("should fail when the tool is unknown", func() {
+				_, err := downloadManager.Define("unknown", version, "")
+				Expect(errors.Is(err, registry.ErrToolNotFound)).To(BeTrue())
+			})
+
+			It("should prefer the location", func() {
+				location := "https://example.com/cmdr"
+				fetcher.EXPECT().IsSupport(location).Return(false)
+				baseManager.EXPECT().Define(name, version, location)
+
+				Expect(downloadManager.Define(name, version, location)).To(Succeed())
+			})
+		})
+
+		Context("Resolve", func() {
+			It("should resolve for the current platform", func() {
+				fetcher.EXPECT().IsSupport(uri).Return(true)
+				fetcher.EXPECT().Fetch(name, version, uri, gomock.Any()).DoAndReturn(func(name, version, uri, dir string) error {
+					Expect(os.MkdirAll(filepath.Join(dir, "bin"), 0755)).To(Succeed())
+					return os.WriteFile(filepath.Join(dir, "bin", "cmdr"), []byte(""), 0755)
+				})
+
+				goos, goarch, _ := strings.Cut(lockfile.CurrentPlatform(), "/")
+				artifact, err := downloadManager.Resolve(name, version, uri, goos, goarch)
+				Expect(err).To(BeNil())
+				Expect(artifact.Entry).To(Equal("bin/cmdr"))
+				Expect(artifact.SHA256).To(Equal(emptySHA256))
+			})
+
+			It("should reject platform dependent fetchers for other platforms", func() {
+				rewriter, err := rewrite.NewEngine(&rewrite.Rule{
+					Template: "{{ .URI }}-{{ .GOOS }}-{{ .GOARCH }}",
+				})
+				Expect(err).To(BeNil())
+				downloadManager.SetRewriter(rewriter)
+
+				fetcher.EXPECT().IsSupport(uri + "-plan9-mips").Return(true)
+
+				_, err = downloadManager.Resolve(name, version, uri, "plan9", "mips")
+				Expect(errors.Is(err, core.ErrPlatformNotSupported)).To(BeTrue())
+			})
+
+			It("should reject the uri which does not vary by platform", func() {
+				_, err := downloadManager.Resolve(name, version, "https://example.com/cmdr", "plan9", "mips")
+				Expect(errors.Is(err, core.ErrPlatformNotSupported)).To(BeTrue())
+			})
+		})
+
 		It("should replace url", func() {
 			input := "http://github.com/MrLYC/cmdr"
 			replaced := "mock://github.com/MrLYC/cmdr"
 
-			downloadManager.SetReplacements(utils.Replacements{
-				{
-					Match:    "http://(.*)",
-					Template: "mock://{{ index .group 1 }}",
-				},
+			rewriter, err := rewrite.NewEngine(&rewrite.Rule{
+				Match:    "http://(.*)",
+				Template: "mock://{{ index .group 1 }}",
 			})
+			Expect(err).To(BeNil())
+			downloadManager.SetRewriter(rewriter)
 
 			fetcher.EXPECT().IsSupport(replaced).Return(false)
 			baseManager.EXPECT().Define(name, version, replaced)
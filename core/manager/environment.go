@@ -0,0 +1,233 @@
+package manager
+
+import (
+	"sort"
+	"time"
+
+	"github.com/asdine/storm/v3"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+var (
+	ErrEnvironmentNotFound = errors.New("environment not found")
+)
+
+// HistoryCauseEnvironment marks the activations made by switching environments
+const HistoryCauseEnvironment = "env"
+
+// Environment is a named set of activations, the commands map the names to the versions
+type Environment struct {
+	ID        int               `storm:"increment" json:"id"`
+	Name      string            `storm:"unique" json:"name"`
+	Commands  map[string]string `storm:"" json:"commands"`
+	UpdatedAt time.Time         `storm:"" json:"updated_at"`
+}
+
+// Names returns the sorted names of the commands
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.Commands))
+	for name := range e.Commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+type EnvironmentStore struct {
+	Client storm.TypeStore
+}
+
+func (s *EnvironmentStore) Get(name string) (*Environment, error) {
+	var environment Environment
+	err := s.Client.One("Name", name, &environment)
+	switch errors.Cause(err) {
+	case nil:
+		return &environment, nil
+	case storm.ErrNotFound:
+		return nil, errors.Wrapf(ErrEnvironmentNotFound, "%s", name)
+	default:
+		return nil, errors.Wrapf(err, "get environment %s failed", name)
+	}
+}
+
+// Save creates or replaces the environment with the same name
+func (s *EnvironmentStore) Save(name string, commands map[string]string) (*Environment, error) {
+	environment, err := s.Get(name)
+	switch errors.Cause(err) {
+	case nil:
+	case ErrEnvironmentNotFound:
+		environment = &Environment{Name: name}
+	default:
+		return nil, err
+	}
+
+	environment.Commands = commands
+	environment.UpdatedAt = time.Now()
+
+	err = s.Client.Save(environment)
+	if err != nil {
+		return nil, errors.Wrapf(err, "save environment %s failed", name)
+	}
+
+	return environment, nil
+}
+
+// List returns the environments sorted by name
+func (s *EnvironmentStore) List() ([]*Environment, error) {
+	var environments []*Environment
+	err := s.Client.AllByIndex("Name", &environments)
+	switch errors.Cause(err) {
+	case nil, storm.ErrNotFound:
+	default:
+		return nil, errors.Wrapf(err, "list environments failed")
+	}
+
+	return environments, nil
+}
+
+func NewEnvironmentStore(db storm.TypeStore) *EnvironmentStore {
+	return &EnvironmentStore{
+		Client: db,
+	}
+}
+
+// ActivatedVersions returns the activated version of each command
+func ActivatedVersions(manager core.CommandManager) (map[string]string, error) {
+	query, err := manager.Query()
+	if err != nil {
+		return nil, errors.Wrapf(err, "make query failed")
+	}
+
+	commands, err := query.WithActivated(true).All()
+	if err != nil {
+		return nil, errors.Wrapf(err, "query activated commands failed")
+	}
+
+	versions := make(map[string]string, len(commands))
+	for _, command := range commands {
+		name := command.GetName()
+		versions[name] = resolveVersionFromLocation(name, command.GetVersion(), command.GetLocation())
+	}
+
+	return versions, nil
+}
+
+// SwitchEnvironment activates the commands of the environment, the commands which are not in
+// the environment are kept, the activations are rolled back when any of them failed
+func SwitchEnvironment(manager core.CommandManager, environment *Environment) error {
+	logger := core.GetLogger()
+
+	previous, err := ActivatedVersions(manager)
+	if err != nil {
+		return err
+	}
+
+	recorder, ok := manager.(core.CommandHistoryRecorder)
+	if ok {
+		recorder.SetHistoryCause(HistoryCauseEnvironment)
+	}
+
+	switched := make([]string, 0, len(environment.Commands))
+	for _, name := range environment.Names() {
+		version := environment.Commands[name]
+		activated, ok := previous[name]
+		if ok && versioning.Equal(activated, version) {
+			continue
+		}
+
+		logger.Debug("activating command of environment", map[string]interface{}{
+			"environment": environment.Name,
+			"name":        name,
+			"version":     version,
+		})
+
+		err = manager.Activate(name, version)
+		if err == nil {
+			switched = append(switched, name)
+			continue
+		}
+
+		err = errors.WithMessagef(err, "activate %s(%s) of environment %s failed", name, version, environment.Name)
+
+		// the failed activation may have deactivated the previous version already
+		switched = append(switched, name)
+		rollbackErr := rollbackActivations(manager, switched, previous)
+		if rollbackErr != nil {
+			err = multierror.Append(err, errors.WithMessagef(rollbackErr, "rollback failed"))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// rollbackActivations activates the previous versions again in the reverse order
+func rollbackActivations(manager core.CommandManager, names []string, previous map[string]string) error {
+	var errs error
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		version, ok := previous[name]
+
+		var err error
+		if ok {
+			err = manager.Activate(name, version)
+		} else {
+			err = manager.Deactivate(name)
+		}
+
+		if err != nil {
+			errs = multierror.Append(errs, errors.WithMessagef(err, "restore %s failed", name))
+		}
+	}
+
+	return errs
+}
+
+// EnvironmentDifference is a command whose versions are different in two environments,
+// the version is empty when the command is not in the environment
+type EnvironmentDifference struct {
+	Name     string
+	Version  string
+	Compared string
+}
+
+// DiffEnvironments returns the differences sorted by name
+func DiffEnvironments(environment, compared *Environment) []*EnvironmentDifference {
+	names := make(map[string]bool)
+	for _, env := range []*Environment{environment, compared} {
+		for name := range env.Commands {
+			names[name] = true
+		}
+	}
+
+	differences := make([]*EnvironmentDifference, 0, len(names))
+	for name := range names {
+		version, comparedVersion := environment.Commands[name], compared.Commands[name]
+		if version != "" && comparedVersion != "" && versioning.Equal(version, comparedVersion) {
+			continue
+		}
+
+		differences = append(differences, &EnvironmentDifference{
+			Name:     name,
+			Version:  version,
+			Compared: comparedVersion,
+		})
+	}
+
+	sort.Slice(differences, func(i, j int) bool {
+		return differences[i].Name < differences[j].Name
+	})
+
+	return differences
+}
+
+func init() {
+	core.RegisterDatabaseModel(core.ModelTypeEnvironment, &Environment{})
+}
@@ -0,0 +1,150 @@
+package manager_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/asdine/storm/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/mock"
+)
+
+var _ = Describe("Environment", func() {
+	Context("EnvironmentStore", func() {
+		var (
+			rootDir string
+			db      *storm.DB
+			store   *manager.EnvironmentStore
+		)
+
+		BeforeEach(func() {
+			var err error
+			rootDir, err = os.MkdirTemp("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			db, err = storm.Open(filepath.Join(rootDir, "cmdr.db"))
+			Expect(err).NotTo(HaveOccurred())
+
+			store = manager.NewEnvironmentStore(db)
+		})
+
+		AfterEach(func() {
+			Expect(db.Close()).To(Succeed())
+			Expect(os.RemoveAll(rootDir)).To(Succeed())
+		})
+
+		It("should return an error when not found", func() {
+			_, err := store.Get("client")
+			Expect(errors.Cause(err)).To(Equal(manager.ErrEnvironmentNotFound))
+		})
+
+		It("should replace the environment with the same name", func() {
+			_, err := store.Save("client", map[string]string{"node": "16.20.0"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = store.Save("client", map[string]string{"node": "18.17.0", "go": "1.21.0"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = store.Save("another", map[string]string{"node": "20.0.0"})
+			Expect(err).NotTo(HaveOccurred())
+
+			environment, err := store.Get("client")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(environment.Commands).To(Equal(map[string]string{"node": "18.17.0", "go": "1.21.0"}))
+			Expect(environment.Names()).To(Equal([]string{"go", "node"}))
+
+			environments, err := store.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(environments).To(HaveLen(2))
+		})
+	})
+
+	Context("SwitchEnvironment", func() {
+		var (
+			ctrl        *gomock.Controller
+			mgr         *mock.MockCommandManager
+			query       *mock.MockCommandQuery
+			environment *manager.Environment
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			mgr = mock.NewMockCommandManager(ctrl)
+			query = mock.NewMockCommandQuery(ctrl)
+
+			mgr.EXPECT().Query().Return(query, nil)
+			query.EXPECT().WithActivated(true).Return(query)
+			query.EXPECT().All().Return([]core.Command{
+				&manager.Command{Name: "go", Version: "1.20.0", Activated: true, Location: "shims/go/go_1.20.0"},
+				&manager.Command{Name: "node", Version: "16.20.0", Activated: true, Location: "shims/node/node_16.20.0"},
+			}, nil)
+
+			environment = &manager.Environment{
+				Name: "client",
+				Commands: map[string]string{
+					"go":        "1.21.0",
+					"kubectl":   "1.28.0",
+					"node":      "16.20.0",
+					"terraform": "1.5.0",
+				},
+			}
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should activate the changed commands", func() {
+			gomock.InOrder(
+				mgr.EXPECT().Activate("go", "1.21.0"),
+				mgr.EXPECT().Activate("kubectl", "1.28.0"),
+				mgr.EXPECT().Activate("terraform", "1.5.0"),
+			)
+
+			Expect(manager.SwitchEnvironment(mgr, environment)).To(Succeed())
+		})
+
+		It("should roll back when an activation failed", func() {
+			gomock.InOrder(
+				mgr.EXPECT().Activate("go", "1.21.0"),
+				mgr.EXPECT().Activate("kubectl", "1.28.0"),
+				mgr.EXPECT().Activate("terraform", "1.5.0").Return(core.ErrBinaryNotFound),
+				mgr.EXPECT().Deactivate("terraform"),
+				mgr.EXPECT().Deactivate("kubectl"),
+				mgr.EXPECT().Activate("go", "1.20.0"),
+			)
+
+			err := manager.SwitchEnvironment(mgr, environment)
+			Expect(errors.Cause(err)).To(Equal(core.ErrBinaryNotFound))
+		})
+
+		It("should restore the command whose activation failed after deactivating", func() {
+			gomock.InOrder(
+				mgr.EXPECT().Activate("go", "1.21.0").Return(core.ErrBinaryNotFound),
+				mgr.EXPECT().Activate("go", "1.20.0"),
+			)
+
+			err := manager.SwitchEnvironment(mgr, environment)
+			Expect(errors.Cause(err)).To(Equal(core.ErrBinaryNotFound))
+		})
+	})
+
+	It("should diff environments", func() {
+		differences := manager.DiffEnvironments(
+			&manager.Environment{Commands: map[string]string{"go": "1.21", "node": "18.17.0", "jq": "1.7.1"}},
+			&manager.Environment{Commands: map[string]string{"go": "1.21.0", "node": "16.20.0", "kubectl": "1.28.0"}},
+		)
+
+		Expect(differences).To(Equal([]*manager.EnvironmentDifference{
+			{Name: "jq", Version: "1.7.1"},
+			{Name: "kubectl", Compared: "1.28.0"},
+			{Name: "node", Version: "18.17.0", Compared: "16.20.0"},
+		}))
+	})
+})
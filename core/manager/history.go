@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+// the causes of the operations which are not made by the command itself
+const (
+	HistoryCauseClean  = "clean"
+	HistoryCauseDoctor = "doctor"
+	HistoryCauseUndo   = "undo"
+)
+
+// History is a record of an operation which changed the commands
+type History struct {
+	ID        int    `storm:"increment" json:"id"`
+	Operation string `storm:"index" json:"operation"`
+	Name      string `storm:"index" json:"name"`
+	Version   string `storm:"" json:"version,omitempty"`
+	Location  string `storm:"" json:"location,omitempty"`
+	// Previous is the state before the operation, the activated version for activations,
+	// the location for definitions and the original location for cleaned versions
+	Previous string `storm:"" json:"previous,omitempty"`
+	// Cause is the command which made the operation, like clean, doctor or undo
+	Cause       string    `storm:"" json:"cause,omitempty"`
+	CmdrVersion string    `storm:"" json:"cmdr_version"`
+	Time        time.Time `storm:"" json:"time"`
+	Undone      bool      `storm:"index" json:"undone"`
+}
+
+func (h *History) String() string {
+	return fmt.Sprintf("#%d %s %s(%s)", h.ID, h.Operation, h.Name, h.Version)
+}
+
+type HistoryStore struct {
+	Client storm.TypeStore
+}
+
+func (s *HistoryStore) Record(history *History) error {
+	if history.Time.IsZero() {
+		history.Time = time.Now()
+	}
+
+	if history.CmdrVersion == "" {
+		history.CmdrVersion = core.Version
+	}
+
+	err := s.Client.Save(history)
+	if err != nil {
+		return errors.Wrapf(err, "save history %s failed", history.Operation)
+	}
+
+	return nil
+}
+
+// List returns the records of a command or all commands when the name is empty, the latest comes first
+func (s *HistoryStore) List(name string) ([]*History, error) {
+	var matchers []q.Matcher
+	if name != "" {
+		matchers = append(matchers, q.Eq("Name", name))
+	}
+
+	var histories []*History
+	err := s.Client.Select(matchers...).OrderBy("ID").Reverse().Find(&histories)
+	switch errors.Cause(err) {
+	case nil:
+	case storm.ErrNotFound:
+		return nil, nil
+	default:
+		return nil, errors.Wrapf(err, "select histories failed")
+	}
+
+	return histories, nil
+}
+
+func (s *HistoryStore) MarkUndone(history *History) error {
+	history.Undone = true
+
+	err := s.Client.Save(history)
+	if err != nil {
+		return errors.Wrapf(err, "save history %d failed", history.ID)
+	}
+
+	return nil
+}
+
+func NewHistoryStore(db storm.TypeStore) *HistoryStore {
+	return &HistoryStore{
+		Client: db,
+	}
+}
+
+func init() {
+	core.RegisterDatabaseModel(core.ModelTypeHistory, &History{})
+}
@@ -0,0 +1,136 @@
+package manager_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/asdine/storm/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/mock"
+)
+
+var _ = Describe("History", func() {
+	var (
+		rootDir string
+		db      *storm.DB
+		store   *manager.HistoryStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		rootDir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err = storm.Open(filepath.Join(rootDir, "cmdr.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		store = manager.NewHistoryStore(db)
+	})
+
+	AfterEach(func() {
+		Expect(db.Close()).To(Succeed())
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	Context("HistoryStore", func() {
+		It("should list nothing", func() {
+			Expect(store.List("")).To(BeEmpty())
+		})
+
+		It("should list the latest first", func() {
+			Expect(store.Record(&manager.History{Operation: core.HistoryOperationDefine, Name: "a", Version: "1.0.0"})).To(Succeed())
+			Expect(store.Record(&manager.History{Operation: core.HistoryOperationActivate, Name: "b", Version: "1.0.0"})).To(Succeed())
+			Expect(store.Record(&manager.History{Operation: core.HistoryOperationActivate, Name: "a", Version: "1.0.0"})).To(Succeed())
+
+			histories, err := store.List("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(histories).To(HaveLen(3))
+			Expect(histories[0].ID).To(Equal(3))
+			Expect(histories[0].CmdrVersion).To(Equal(core.Version))
+			Expect(histories[0].Time.IsZero()).To(BeFalse())
+
+			histories, err = store.List("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(histories).To(HaveLen(2))
+			Expect(histories[0].Operation).To(Equal(core.HistoryOperationActivate))
+			Expect(histories[1].Operation).To(Equal(core.HistoryOperationDefine))
+		})
+
+		It("should mark undone", func() {
+			history := &manager.History{Operation: core.HistoryOperationActivate, Name: "a", Version: "1.0.0"}
+			Expect(store.Record(history)).To(Succeed())
+			Expect(store.MarkUndone(history)).To(Succeed())
+
+			histories, err := store.List("a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(histories[0].Undone).To(BeTrue())
+		})
+	})
+
+	Context("DatabaseManager", func() {
+		var (
+			ctrl      *gomock.Controller
+			binaryMgr *mock.MockCommandManager
+			mgr       *manager.DatabaseManager
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			binaryMgr = mock.NewMockCommandManager(ctrl)
+
+			mgr = manager.NewDatabaseManager(db, binaryMgr)
+			mgr.SetHistory(store)
+
+			binaryCommand := mock.NewMockCommand(ctrl)
+			binaryCommand.EXPECT().GetLocation().Return("location").AnyTimes()
+			binaryMgr.EXPECT().Define(gomock.Any(), gomock.Any(), gomock.Any()).Return(binaryCommand, nil).AnyTimes()
+			binaryMgr.EXPECT().Activate(gomock.Any(), gomock.Any()).AnyTimes()
+			binaryMgr.EXPECT().Deactivate(gomock.Any()).AnyTimes()
+			binaryMgr.EXPECT().Undefine(gomock.Any(), gomock.Any()).AnyTimes()
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should record the previous states", func() {
+			_, err := mgr.Define("a", "1.0.0", "a-1")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = mgr.Define("a", "2.0.0", "a-2")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(mgr.Activate("a", "1.0.0")).To(Succeed())
+			Expect(mgr.Activate("a", "2.0.0")).To(Succeed())
+
+			mgr.SetHistoryCause(manager.HistoryCauseDoctor)
+			Expect(mgr.Deactivate("a")).To(Succeed())
+			Expect(mgr.Deactivate("a")).To(Succeed())
+			Expect(mgr.Undefine("a", "1.0.0")).To(Succeed())
+
+			histories, err := store.List("a")
+			Expect(err).NotTo(HaveOccurred())
+
+			type record struct {
+				operation, version, previous, cause string
+			}
+			records := make([]record, 0, len(histories))
+			for _, history := range histories {
+				records = append(records, record{history.Operation, history.Version, history.Previous, history.Cause})
+			}
+
+			Expect(records).To(Equal([]record{
+				{core.HistoryOperationUndefine, "1.0.0", "", manager.HistoryCauseDoctor},
+				{core.HistoryOperationDeactivate, "", "2.0.0", manager.HistoryCauseDoctor},
+				{core.HistoryOperationActivate, "2.0.0", "1.0.0", ""},
+				{core.HistoryOperationActivate, "1.0.0", "", ""},
+				{core.HistoryOperationDefine, "2.0.0", "", ""},
+				{core.HistoryOperationDefine, "1.0.0", "", ""},
+			}))
+		})
+	})
+})
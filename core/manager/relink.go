@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+// MetadataKeyLinkMode is the link mode of the shim, which is set by relinking
+const MetadataKeyLinkMode = "link.mode"
+
+// CommandRelinker converts the existing shims to the link mode of the binary manager
+type CommandRelinker struct {
+	binaryMgr *BinaryManager
+	manager   core.CommandManager
+}
+
+// Relink converts every shim in the shims dir and updates the records of the manager,
+// the shims without a source are skipped when symlinking, and the failures are returned together
+func (r *CommandRelinker) Relink() ([]core.Command, error) {
+	logger := core.GetLogger()
+
+	query, err := r.binaryMgr.Query()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "query shims failed")
+	}
+
+	commands, err := query.All()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "query shims failed")
+	}
+
+	setter, _ := r.manager.(core.CommandMetadataSetter)
+
+	var errs error
+	relinked := make([]core.Command, 0, len(commands))
+	for _, command := range commands {
+		name, version := command.GetName(), command.GetVersion()
+
+		err = r.binaryMgr.Relink(command.GetLocation())
+		if errors.Cause(err) == ErrNoLinkSource {
+			logger.Warn("shim is skipped, there is no source to link", map[string]interface{}{
+				"name":    name,
+				"version": version,
+			})
+			continue
+		} else if err != nil {
+			errs = multierror.Append(errs, errors.WithMessagef(err, "relink %s(%s) failed", name, version))
+			continue
+		}
+
+		relinked = append(relinked, command)
+		if setter == nil {
+			continue
+		}
+
+		// the binary may be moved, so the fingerprint is taken again
+		metadata, err := FingerprintBinary(command.GetLocation())
+		if err != nil {
+			metadata = make(map[string]string, 1)
+		}
+
+		metadata[MetadataKeyLinkMode] = r.binaryMgr.LinkMode()
+
+		err = setter.SetMetadata(name, version, metadata)
+		if errors.Cause(err) == core.ErrBinaryNotFound {
+			logger.Debug("shim is not recorded", map[string]interface{}{
+				"name":    name,
+				"version": version,
+			})
+		} else if err != nil {
+			errs = multierror.Append(errs, errors.WithMessagef(err, "update record of %s(%s) failed", name, version))
+		}
+	}
+
+	return relinked, errs
+}
+
+func NewCommandRelinker(binaryMgr *BinaryManager, manager core.CommandManager) *CommandRelinker {
+	return &CommandRelinker{
+		binaryMgr: binaryMgr,
+		manager:   manager,
+	}
+}
@@ -0,0 +1,194 @@
+package manager_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/mock"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var _ = Describe("Relink", func() {
+	var (
+		rootDir  string
+		binDir   string
+		shimsDir string
+		location string
+		shim     string
+	)
+
+	BeforeEach(func() {
+		var err error
+		rootDir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		rootDir, err = filepath.EvalSymlinks(rootDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		binDir = filepath.Join(rootDir, "bin")
+		shimsDir = filepath.Join(rootDir, "shims")
+		location = filepath.Join(rootDir, "tool")
+		shim = filepath.Join(shimsDir, "tool", "tool_1.0.0")
+
+		Expect(os.WriteFile(location, []byte("#!/bin/sh"), 0755)).To(Succeed())
+
+		mgr := manager.NewBinaryManagerWithLink(binDir, shimsDir, 0755)
+		Expect(mgr.Init(false)).To(Succeed())
+
+		_, err = mgr.Define("tool", "1.0.0", location)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	sameFile := func(path, compared string) bool {
+		info, err := os.Lstat(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		comparedInfo, err := os.Lstat(compared)
+		Expect(err).NotTo(HaveOccurred())
+
+		return os.SameFile(info, comparedInfo)
+	}
+
+	Context("BinaryManager", func() {
+		It("should convert a symlink to a hard link", func() {
+			Expect(manager.NewBinaryManagerWithHardlink(binDir, shimsDir, 0755).Relink(shim)).To(Succeed())
+
+			Expect(sameFile(shim, location)).To(BeTrue())
+			Expect(filepath.Join(shimsDir, "tool", ".tool_1.0.0.relink")).NotTo(BeAnExistingFile())
+
+			By("relinking again")
+			Expect(manager.NewBinaryManagerWithHardlink(binDir, shimsDir, 0755).Relink(shim)).To(Succeed())
+			Expect(sameFile(shim, location)).To(BeTrue())
+			Expect(filepath.Join(shimsDir, "tool", ".tool_1.0.0.relink")).NotTo(BeAnExistingFile())
+		})
+
+		It("should convert a symlink to a file", func() {
+			Expect(manager.NewBinaryManagerWithReflink(binDir, shimsDir, 0755).Relink(shim)).To(Succeed())
+
+			info, err := os.Lstat(shim)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().IsRegular()).To(BeTrue())
+			Expect(sameFile(shim, location)).To(BeFalse())
+			Expect(os.ReadFile(shim)).To(Equal([]byte("#!/bin/sh")))
+		})
+
+		It("should not symlink a file", func() {
+			Expect(manager.NewBinaryManagerWithCopy(binDir, shimsDir, 0755).Relink(shim)).To(Succeed())
+
+			err := manager.NewBinaryManagerWithLink(binDir, shimsDir, 0755).Relink(shim)
+			Expect(errors.Cause(err)).To(Equal(manager.ErrNoLinkSource))
+		})
+
+		It("should convert the wrapped binary", func() {
+			mgr := manager.NewBinaryManagerWithLink(binDir, shimsDir, 0755)
+			mgr.SetWrappers([]*manager.Wrapper{{Args: []string{"-v"}}})
+
+			_, err := mgr.Define("tool", "1.0.0", location)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(manager.NewBinaryManagerWithHardlink(binDir, shimsDir, 0755).Relink(shim)).To(Succeed())
+
+			wrapped := filepath.Join(shimsDir, "tool", manager.WrappedDir, "tool_1.0.0")
+			Expect(sameFile(wrapped, location)).To(BeTrue())
+			Expect(os.ReadFile(shim)).To(ContainSubstring("exec"))
+		})
+	})
+
+	Context("CommandRelinker", func() {
+		var (
+			ctrl   *gomock.Controller
+			mgr    *mock.MockCommandManager
+			setter *mock.MockCommandMetadataSetter
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			mgr = mock.NewMockCommandManager(ctrl)
+			setter = mock.NewMockCommandMetadataSetter(ctrl)
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should update the records", func() {
+			digest, err := utils.SHA256File(location)
+			Expect(err).NotTo(HaveOccurred())
+
+			setter.EXPECT().SetMetadata("tool", "1.0.0", map[string]string{
+				manager.MetadataKeyLinkMode:     manager.LinkModeHardlink,
+				manager.MetadataKeyBinarySHA256: digest,
+				manager.MetadataKeyBinarySize:   "9",
+				manager.MetadataKeyBinarySource: shim,
+			})
+
+			relinker := manager.NewCommandRelinker(
+				manager.NewBinaryManagerWithHardlink(binDir, shimsDir, 0755),
+				struct {
+					*mock.MockCommandManager
+					*mock.MockCommandMetadataSetter
+				}{mgr, setter},
+			)
+
+			relinked, err := relinker.Relink()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(relinked).To(HaveLen(1))
+			Expect(sameFile(shim, location)).To(BeTrue())
+		})
+
+		It("should skip the shims without a source", func() {
+			Expect(manager.NewBinaryManagerWithCopy(binDir, shimsDir, 0755).Relink(shim)).To(Succeed())
+
+			relinker := manager.NewCommandRelinker(manager.NewBinaryManagerWithLink(binDir, shimsDir, 0755), mgr)
+
+			relinked, err := relinker.Relink()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(relinked).To(BeEmpty())
+		})
+
+		It("should return the failures", func() {
+			Expect(os.Remove(location)).To(Succeed())
+
+			relinker := manager.NewCommandRelinker(manager.NewBinaryManagerWithHardlink(binDir, shimsDir, 0755), mgr)
+
+			_, err := relinker.Relink()
+			Expect(err).To(HaveOccurred())
+
+			_, err = os.Lstat(shim)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should keep the unrecorded shims", func() {
+			setter.EXPECT().SetMetadata("tool", "1.0.0", gomock.Any()).Return(core.ErrBinaryNotFound)
+
+			relinker := manager.NewCommandRelinker(
+				manager.NewBinaryManagerWithCopy(binDir, shimsDir, 0755),
+				struct {
+					*mock.MockCommandManager
+					*mock.MockCommandMetadataSetter
+				}{mgr, setter},
+			)
+
+			relinked, err := relinker.Relink()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(relinked).To(HaveLen(1))
+		})
+	})
+
+	It("should return an error because of unknown link mode", func() {
+		_, err := manager.NewBinaryManagerWithLinkMode(binDir, shimsDir, 0755, "symlink")
+		Expect(errors.Cause(err)).To(Equal(manager.ErrUnknownLinkMode))
+	})
+
+})
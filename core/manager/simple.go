@@ -2,10 +2,10 @@ package manager
 
 import (
 	"github.com/hashicorp/go-multierror"
-	ver "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
 type SimpleManager struct {
@@ -66,7 +66,7 @@ func (m *SimpleManager) normalizeVersion(version string) (string, error) {
 		return "", nil
 	}
 
-	v, err := ver.NewVersion(version)
+	v, err := versioning.Parse(version)
 	if err != nil {
 		return "", errors.Wrapf(err, "invalid version %s", version)
 	}
@@ -109,12 +109,34 @@ func (m *SimpleManager) Deactivate(name string) error {
 	})
 }
 
+func (m *SimpleManager) SetHistoryCause(cause string) {
+	_ = m.each(func(mgr core.CommandManager) error {
+		recorder, ok := mgr.(core.CommandHistoryRecorder)
+		if ok {
+			recorder.SetHistoryCause(cause)
+		}
+
+		return nil
+	})
+}
+
+// RecordHistory records by the main manager
+func (m *SimpleManager) RecordHistory(operation, name, version, location, previous string) error {
+	recorder, ok := m.main.(core.CommandHistoryRecorder)
+	if !ok {
+		return nil
+	}
+
+	return recorder.RecordHistory(operation, name, version, location, previous)
+}
+
 func NewSimpleManager(main core.CommandManager, followers []core.CommandManager) *SimpleManager {
 	return &SimpleManager{main: main, followers: followers}
 }
 
 func init() {
 	var _ core.CommandManager = (*SimpleManager)(nil)
+	var _ core.CommandHistoryRecorder = (*SimpleManager)(nil)
 
 	core.RegisterCommandManagerFactory(core.CommandProviderDefault, func(cfg core.Configuration) (core.CommandManager, error) {
 		mainMgr, err := core.NewCommandManager(core.CommandProviderDatabase, cfg)
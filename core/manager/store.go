@@ -0,0 +1,249 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// StoreAlgorithm is the hash algorithm of the digests, which is also the dir of blobs
+const StoreAlgorithm = "sha256"
+
+var (
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrDigestMismatch = errors.New("digest mismatch")
+	ErrStoreDisabled  = errors.New("store is disabled")
+)
+
+// BinaryStore keeps the binaries by their digests as <dir>/sha256/<digest>, the shims are symlinks to the blobs,
+// so the identical binaries are stored once
+type BinaryStore struct {
+	dir     string
+	dirMode os.FileMode
+}
+
+func (s *BinaryStore) blobsHelper() *utils.PathHelper {
+	return utils.NewPathHelper(s.dir).Child(StoreAlgorithm)
+}
+
+func (s *BinaryStore) Init() error {
+	return s.blobsHelper().MkdirAll(s.dirMode)
+}
+
+func (s *BinaryStore) GetDir() string {
+	return s.dir
+}
+
+// Path returns the path of the blob of the digest
+func (s *BinaryStore) Path(digest string) string {
+	return s.blobsHelper().Child(digest).Path()
+}
+
+// DigestOf returns the digest of a blob path, it is false when the path is not a blob of the store
+func (s *BinaryStore) DigestOf(path string) (string, bool) {
+	dir, digest := filepath.Split(filepath.Clean(path))
+	if filepath.Clean(dir) != filepath.Clean(s.blobsHelper().Path()) || strings.HasPrefix(digest, ".") {
+		return "", false
+	}
+
+	return digest, true
+}
+
+// Put links source into the store by linkFn, then returns the path of the blob,
+// the new one is dropped when the intact blob of the same digest exists
+func (s *BinaryStore) Put(
+	source string,
+	linkFn func(shimsHelper *utils.PathHelper, source, shimsName string, mode os.FileMode) error,
+) (string, error) {
+	if digest, ok := s.DigestOf(source); ok {
+		_, err := os.Lstat(source)
+		if err == nil {
+			return s.Path(digest), nil
+		}
+	}
+
+	helper := s.blobsHelper()
+	err := helper.MkdirAll(s.dirMode)
+	if err != nil {
+		return "", err
+	}
+
+	tempName := fmt.Sprintf(".put-%d-%d", os.Getpid(), time.Now().UnixNano())
+	defer func() { _ = helper.EnsureNotExists(tempName) }()
+
+	err = linkFn(helper, source, tempName, 0755)
+	if err != nil {
+		return "", errors.WithMessagef(err, "put %s into store failed", source)
+	}
+
+	digest, err := utils.SHA256File(helper.Child(tempName).Path())
+	if err != nil {
+		return "", err
+	}
+
+	path := s.Path(digest)
+	_, err = os.Lstat(path)
+	if err == nil {
+		// the existing blob is replaced when it is broken
+		err = s.Verify(digest)
+		if err == nil {
+			return path, nil
+		}
+
+		core.GetLogger().Warn("replacing broken blob", map[string]interface{}{
+			"digest": digest,
+			"error":  err,
+		})
+	}
+
+	err = os.Rename(helper.Child(tempName).Path(), path)
+	if err != nil {
+		return "", errors.Wrapf(err, "save blob %s failed", digest)
+	}
+
+	core.GetLogger().Debug("blob saved", map[string]interface{}{
+		"source": source,
+		"digest": digest,
+	})
+
+	return path, nil
+}
+
+// Verify recomputes the digest of the blob
+func (s *BinaryStore) Verify(digest string) error {
+	path := s.Path(digest)
+	actual, err := utils.SHA256File(path)
+	if os.IsNotExist(errors.Cause(err)) {
+		return errors.Wrapf(ErrBlobNotFound, "%s", digest)
+	} else if err != nil {
+		return err
+	}
+
+	if actual != digest {
+		return errors.Wrapf(ErrDigestMismatch, "blob %s has digest %s", digest, actual)
+	}
+
+	return nil
+}
+
+// Digests returns the sorted digests of the blobs
+func (s *BinaryStore) Digests() ([]string, error) {
+	entries, err := os.ReadDir(s.blobsHelper().Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "read store %s failed", s.dir)
+	}
+
+	digests := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			digests = append(digests, entry.Name())
+		}
+	}
+
+	sort.Strings(digests)
+
+	return digests, nil
+}
+
+// References maps the digests to the symlinks in the dirs which refer to them, the hidden dirs are included
+func (s *BinaryStore) References(dirs ...string) (map[string][]string, error) {
+	references := make(map[string][]string)
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			} else if err != nil {
+				return errors.Wrapf(err, "failed to walk %s", path)
+			}
+
+			if info.Mode()&os.ModeSymlink == 0 {
+				return nil
+			}
+
+			target, err := os.Readlink(path)
+			if err != nil {
+				return errors.Wrapf(err, "read link %s failed", path)
+			}
+
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+
+			digest, ok := s.DigestOf(target)
+			if ok {
+				references[digest] = append(references[digest], path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return references, nil
+}
+
+// isStalePut reports whether the put is old enough to be interrupted rather than running
+func isStalePut(entry os.DirEntry) bool {
+	info, err := entry.Info()
+	return err == nil && time.Since(info.ModTime()) > time.Hour
+}
+
+// GC removes the blobs which are not referenced, and the files left by the interrupted puts
+func (s *BinaryStore) GC(references map[string][]string, dryRun bool) ([]string, error) {
+	helper := s.blobsHelper()
+	entries, err := os.ReadDir(helper.Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "read store %s failed", s.dir)
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if len(references[name]) > 0 || (strings.HasPrefix(name, ".") && !isStalePut(entry)) {
+			continue
+		}
+
+		removed = append(removed, name)
+		if dryRun {
+			continue
+		}
+
+		err = helper.EnsureNotExists(name)
+		if err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+func NewBinaryStore(dir string, dirMode os.FileMode) *BinaryStore {
+	return &BinaryStore{
+		dir:     dir,
+		dirMode: dirMode,
+	}
+}
+
+// NewBinaryStoreByConfiguration returns nil when the store dir is not configured
+func NewBinaryStoreByConfiguration(cfg core.Configuration) *BinaryStore {
+	dir := cfg.GetString(core.CfgKeyCmdrStoreDir)
+	if dir == "" {
+		return nil
+	}
+
+	return NewBinaryStore(dir, 0755)
+}
@@ -0,0 +1,201 @@
+package manager_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var _ = Describe("Store", func() {
+	var (
+		rootDir string
+		store   *manager.BinaryStore
+		source  string
+		digest  string
+	)
+
+	copyFn := func(helper *utils.PathHelper, source, name string, mode os.FileMode) error {
+		return helper.CopyFile(name, source, mode)
+	}
+
+	BeforeEach(func() {
+		var err error
+		rootDir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		store = manager.NewBinaryStore(filepath.Join(rootDir, "store"), 0755)
+		Expect(store.Init()).To(Succeed())
+
+		source = filepath.Join(rootDir, "tool")
+		Expect(os.WriteFile(source, []byte("#!/bin/sh"), 0755)).To(Succeed())
+
+		digest, err = utils.SHA256File(source)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	Context("BinaryStore", func() {
+		It("should put the same binary once", func() {
+			path, err := store.Put(source, copyFn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal(filepath.Join(rootDir, "store", manager.StoreAlgorithm, digest)))
+
+			again, err := store.Put(source, copyFn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(path))
+
+			Expect(store.Put(path, copyFn)).To(Equal(path))
+			Expect(store.Digests()).To(Equal([]string{digest}))
+			Expect(store.Verify(digest)).To(Succeed())
+		})
+
+		It("should find the modified blob", func() {
+			path, err := store.Put(source, copyFn)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(path, []byte("#!/bin/bash"), 0755)).To(Succeed())
+			Expect(errors.Cause(store.Verify(digest))).To(Equal(manager.ErrDigestMismatch))
+
+			Expect(os.Remove(path)).To(Succeed())
+			Expect(errors.Cause(store.Verify(digest))).To(Equal(manager.ErrBlobNotFound))
+		})
+
+		It("should replace the modified blob", func() {
+			path, err := store.Put(source, copyFn)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(path, []byte("#!/bin/bash"), 0755)).To(Succeed())
+
+			Expect(store.Put(source, copyFn)).To(Equal(path))
+			Expect(store.Verify(digest)).To(Succeed())
+		})
+
+		It("should return the digest of a blob only", func() {
+			_, ok := store.DigestOf(source)
+			Expect(ok).To(BeFalse())
+
+			_, ok = store.DigestOf(filepath.Join(rootDir, "store", manager.StoreAlgorithm, ".put-1-1"))
+			Expect(ok).To(BeFalse())
+
+			blobDigest, ok := store.DigestOf(store.Path(digest))
+			Expect(ok).To(BeTrue())
+			Expect(blobDigest).To(Equal(digest))
+		})
+
+		It("should remove the unreferenced blobs", func() {
+			path, err := store.Put(source, copyFn)
+			Expect(err).NotTo(HaveOccurred())
+
+			unreferenced := filepath.Join(rootDir, "unreferenced")
+			Expect(os.WriteFile(unreferenced, []byte("unreferenced"), 0755)).To(Succeed())
+			unreferenced, err = store.Put(unreferenced, copyFn)
+			Expect(err).NotTo(HaveOccurred())
+
+			blobsDir := filepath.Dir(path)
+			stale := filepath.Join(blobsDir, ".put-1-1")
+			Expect(os.WriteFile(stale, nil, 0755)).To(Succeed())
+			Expect(os.Chtimes(stale, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour))).To(Succeed())
+
+			running := filepath.Join(blobsDir, ".put-1-2")
+			Expect(os.WriteFile(running, nil, 0755)).To(Succeed())
+
+			shimsDir := filepath.Join(rootDir, "shims", "tool", manager.WrappedDir)
+			Expect(os.MkdirAll(shimsDir, 0755)).To(Succeed())
+			Expect(os.Symlink(path, filepath.Join(shimsDir, "tool_1.0.0"))).To(Succeed())
+
+			references, err := store.References(filepath.Join(rootDir, "shims"), filepath.Join(rootDir, "not-exists"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(references).To(Equal(map[string][]string{
+				digest: {filepath.Join(shimsDir, "tool_1.0.0")},
+			}))
+
+			removed, err := store.GC(references, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removed).To(ConsistOf(filepath.Base(unreferenced), ".put-1-1"))
+			Expect(unreferenced).To(BeAnExistingFile())
+
+			_, err = store.GC(references, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unreferenced).NotTo(BeAnExistingFile())
+			Expect(stale).NotTo(BeAnExistingFile())
+			Expect(running).To(BeAnExistingFile())
+			Expect(path).To(BeAnExistingFile())
+		})
+	})
+
+	Context("BinaryManager", func() {
+		var (
+			binDir   string
+			shimsDir string
+			mgr      *manager.BinaryManager
+		)
+
+		BeforeEach(func() {
+			binDir = filepath.Join(rootDir, "bin")
+			shimsDir = filepath.Join(rootDir, "shims")
+
+			mgr = manager.NewBinaryManagerWithCopy(binDir, shimsDir, 0755)
+			mgr.SetStore(store)
+			Expect(mgr.Init(false)).To(Succeed())
+		})
+
+		It("should refer to the blobs", func() {
+			for _, version := range []string{"1.0.0", "1.0.1"} {
+				command, err := mgr.Define("tool", version, source)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.Readlink(command.GetLocation())).To(Equal(store.Path(digest)))
+			}
+
+			Expect(mgr.Activate("tool", "1.0.0")).To(Succeed())
+
+			query, err := mgr.Query()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(query.WithName("tool").WithActivated(true).Count()).To(Equal(1))
+			Expect(query.Count()).To(Equal(1))
+
+			Expect(mgr.Undefine("tool", "1.0.1")).To(Succeed())
+			Expect(mgr.CollectGarbage(false)).To(BeEmpty())
+
+			Expect(mgr.Undefine("tool", "1.0.0")).To(Succeed())
+			Expect(mgr.CollectGarbage(false)).To(Equal([]string{digest}))
+			Expect(store.Digests()).To(BeEmpty())
+		})
+
+		It("should migrate the shims which are files", func() {
+			shim := filepath.Join(shimsDir, "tool", "tool_1.0.0")
+			Expect(os.MkdirAll(filepath.Dir(shim), 0755)).To(Succeed())
+			Expect(os.WriteFile(shim, []byte("#!/bin/sh"), 0755)).To(Succeed())
+
+			linked := filepath.Join(shimsDir, "tool", "tool_2.0.0")
+			Expect(os.Symlink(source, linked)).To(Succeed())
+
+			Expect(mgr.Init(true)).To(Succeed())
+			Expect(shim).To(BeARegularFile())
+
+			Expect(mgr.MigrateToStore()).To(Succeed())
+			Expect(os.Readlink(shim)).To(Equal(store.Path(digest)))
+			Expect(os.Readlink(linked)).To(Equal(source))
+		})
+
+		It("should not use the store in the link mode", func() {
+			mgr = manager.NewBinaryManagerWithLink(binDir, shimsDir, 0755)
+			mgr.SetStore(store)
+
+			command, err := mgr.Define("tool", "1.0.0", source)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Readlink(command.GetLocation())).To(Equal(source))
+
+			Expect(errors.Cause(mgr.MigrateToStore())).To(Equal(manager.ErrStoreDisabled))
+		})
+	})
+})
@@ -0,0 +1,137 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+// CommandUndoer reverts the activation changes and the cleaned versions in the history
+type CommandUndoer struct {
+	core.CommandManager
+	history *HistoryStore
+}
+
+// undoable returns whether a record can be reverted, the records made by undo are skipped
+func (u *CommandUndoer) undoable(history *History) bool {
+	if history.Undone || history.Cause == HistoryCauseUndo {
+		return false
+	}
+
+	// cmdr itself is replaced by upgrade, deactivating it breaks every command
+	if history.Name == core.Name {
+		return false
+	}
+
+	switch history.Operation {
+	case core.HistoryOperationActivate, core.HistoryOperationDeactivate, core.HistoryOperationClean:
+		return true
+	default:
+		return false
+	}
+}
+
+func (u *CommandUndoer) restore(history *History) error {
+	// the trash keeps the shim, which may link to the installed binary
+	location, err := filepath.EvalSymlinks(history.Location)
+	if err != nil {
+		return errors.Wrapf(err, "cleaned file of %s not found", history)
+	}
+
+	info, err := os.Lstat(history.Location)
+	if err != nil {
+		return errors.Wrapf(err, "stat cleaned file %s failed", history.Location)
+	}
+
+	// clean records the short version which is listed
+	command, err := u.Define(history.Name, versioning.Normalize(history.Version), location)
+	if err != nil {
+		return errors.WithMessagef(err, "define %s(%s) failed", history.Name, history.Version)
+	}
+
+	// the shim links to the cleaned file when the trash is not a link, so it is kept
+	defined, err := filepath.EvalSymlinks(command.GetLocation())
+	if err == nil && defined == location && info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+
+	err = os.Remove(history.Location)
+	if err != nil {
+		core.GetLogger().Warn("failed to remove cleaned file", map[string]interface{}{
+			"location": history.Location,
+			"error":    err,
+		})
+	}
+
+	return nil
+}
+
+func (u *CommandUndoer) revert(history *History) error {
+	switch history.Operation {
+	case core.HistoryOperationActivate:
+		if history.Previous == "" {
+			return u.Deactivate(history.Name)
+		}
+
+		return u.Activate(history.Name, history.Previous)
+	case core.HistoryOperationDeactivate:
+		return u.Activate(history.Name, history.Previous)
+	case core.HistoryOperationClean:
+		return u.restore(history)
+	default:
+		return errors.Errorf("operation %s of %s can not be undone", history.Operation, history)
+	}
+}
+
+// Undo reverts the latest n changes, the reverted records are returned
+func (u *CommandUndoer) Undo(n int) ([]*History, error) {
+	histories, err := u.history.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	recorder, ok := u.CommandManager.(core.CommandHistoryRecorder)
+	if ok {
+		recorder.SetHistoryCause(HistoryCauseUndo)
+	}
+
+	undone := make([]*History, 0, n)
+	for _, history := range histories {
+		if len(undone) >= n {
+			break
+		}
+
+		if !u.undoable(history) {
+			continue
+		}
+
+		core.GetLogger().Debug("undoing history", map[string]interface{}{
+			"history": history,
+		})
+
+		err := u.revert(history)
+		if err != nil {
+			return undone, errors.WithMessagef(err, "undo %s failed", history)
+		}
+
+		err = u.history.MarkUndone(history)
+		if err != nil {
+			return undone, err
+		}
+
+		undone = append(undone, history)
+	}
+
+	return undone, nil
+}
+
+func NewCommandUndoer(manager core.CommandManager, history *HistoryStore) *CommandUndoer {
+	return &CommandUndoer{
+		CommandManager: manager,
+		history:        history,
+	}
+}
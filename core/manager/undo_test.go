@@ -0,0 +1,127 @@
+package manager_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/asdine/storm/v3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/mock"
+)
+
+var _ = Describe("Undo", func() {
+	var (
+		ctrl    *gomock.Controller
+		mgr     *mock.MockCommandManager
+		rootDir string
+		db      *storm.DB
+		store   *manager.HistoryStore
+		undoer  *manager.CommandUndoer
+	)
+
+	record := func(operation, name, version, location, previous string) {
+		Expect(store.Record(&manager.History{
+			Operation: operation,
+			Name:      name,
+			Version:   version,
+			Location:  location,
+			Previous:  previous,
+		})).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mgr = mock.NewMockCommandManager(ctrl)
+
+		var err error
+		rootDir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		db, err = storm.Open(filepath.Join(rootDir, "cmdr.db"))
+		Expect(err).NotTo(HaveOccurred())
+
+		store = manager.NewHistoryStore(db)
+		undoer = manager.NewCommandUndoer(mgr, store)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		Expect(db.Close()).To(Succeed())
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	It("should revert the activation changes", func() {
+		record(core.HistoryOperationActivate, "a", "1.0.0", "", "")
+		record(core.HistoryOperationDefine, "a", "2.0.0", "", "")
+		record(core.HistoryOperationActivate, "a", "2.0.0", "", "1.0.0")
+		record(core.HistoryOperationDeactivate, "a", "", "", "2.0.0")
+
+		gomock.InOrder(
+			mgr.EXPECT().Activate("a", "2.0.0"),
+			mgr.EXPECT().Activate("a", "1.0.0"),
+		)
+
+		undone, err := undoer.Undo(2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(undone).To(HaveLen(2))
+
+		mgr.EXPECT().Deactivate("a")
+
+		undone, err = undoer.Undo(2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(undone).To(HaveLen(1))
+		Expect(undone[0].ID).To(Equal(1))
+
+		undone, err = undoer.Undo(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(undone).To(BeEmpty())
+	})
+
+	It("should skip the records of undo and cmdr", func() {
+		Expect(store.Record(&manager.History{
+			Operation: core.HistoryOperationActivate,
+			Name:      "a",
+			Version:   "1.0.0",
+			Cause:     manager.HistoryCauseUndo,
+		})).To(Succeed())
+		record(core.HistoryOperationActivate, core.Name, "1.0.0", "", "")
+
+		undone, err := undoer.Undo(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(undone).To(BeEmpty())
+	})
+
+	It("should restore a cleaned version", func() {
+		trash := filepath.Join(rootDir, "a_1.0.0")
+		Expect(os.WriteFile(trash, []byte("#!/bin/sh"), 0755)).To(Succeed())
+		trash, err := filepath.EvalSymlinks(trash)
+		Expect(err).NotTo(HaveOccurred())
+
+		record(core.HistoryOperationClean, "a", "1", trash, "shims/a/a_1.0.0")
+
+		command := mock.NewMockCommand(ctrl)
+		command.EXPECT().GetLocation().Return(filepath.Join(rootDir, "shim"))
+		mgr.EXPECT().Define("a", "1.0.0", trash).Return(command, nil)
+
+		undone, err := undoer.Undo(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(undone).To(HaveLen(1))
+		Expect(trash).NotTo(BeAnExistingFile())
+	})
+
+	It("should keep the history when the revert failed", func() {
+		record(core.HistoryOperationClean, "a", "1.0.0", filepath.Join(rootDir, "not-exists"), "")
+
+		_, err := undoer.Undo(1)
+		Expect(err).To(HaveOccurred())
+
+		histories, err := store.List("a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(histories[0].Undone).To(BeFalse())
+	})
+})
@@ -0,0 +1,286 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+// metadata keys of the binary which is defined, they are verified later
+const (
+	MetadataKeyBinarySHA256 = "binary.sha256"
+	MetadataKeyBinarySize   = "binary.size"
+	MetadataKeyBinarySource = "binary.source"
+)
+
+// verify statuses of the binaries
+const (
+	VerifyStatusOK         = "ok"
+	VerifyStatusMissing    = "missing"
+	VerifyStatusModified   = "modified"
+	VerifyStatusTruncated  = "truncated"
+	VerifyStatusReplaced   = "replaced"
+	VerifyStatusUnrecorded = "unrecorded"
+)
+
+var (
+	ErrBinaryBroken = errors.New("binary is broken")
+	ErrNotFixable   = errors.New("no recorded location to reinstall")
+)
+
+// binaryOf returns the binary which a shim runs, which is the wrapped one of a wrapper shim
+func binaryOf(shim string) string {
+	wrapping, err := LoadWrapping(shim)
+	if err == nil {
+		return wrapping.Location
+	}
+
+	return shim
+}
+
+// FingerprintBinary returns the metadata which identifies the binary of a shim
+func FingerprintBinary(shim string) (map[string]string, error) {
+	source, err := filepath.EvalSymlinks(binaryOf(shim))
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolve binary of %s failed", shim)
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s failed", source)
+	}
+
+	digest, err := utils.SHA256File(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		MetadataKeyBinarySHA256: digest,
+		MetadataKeyBinarySize:   strconv.FormatInt(info.Size(), 10),
+		MetadataKeyBinarySource: source,
+	}, nil
+}
+
+// VerifyResult is the integrity of a shim, the expected values are recorded when it is installed
+type VerifyResult struct {
+	Name     string
+	Version  string
+	Location string
+	Status   string
+	Expected string
+	Actual   string
+	// Source is the file which the shim runs, Recorded is the one when it is defined
+	Source   string
+	Recorded string
+	Metadata map[string]string
+}
+
+func (r *VerifyResult) Broken() bool {
+	switch r.Status {
+	case VerifyStatusOK, VerifyStatusUnrecorded:
+		return false
+	default:
+		return true
+	}
+}
+
+// CommandVerifier recomputes the digests of the shims and compares them to the records
+type CommandVerifier struct {
+	binaryMgr *BinaryManager
+	manager   core.CommandManager
+}
+
+func (v *CommandVerifier) metadataOf(name, version string) map[string]string {
+	query, err := v.manager.Query()
+	if err != nil {
+		return nil
+	}
+
+	command, err := query.WithName(name).WithVersion(version).One()
+	if err != nil {
+		return nil
+	}
+
+	getter, ok := command.(core.CommandMetadataGetter)
+	if !ok {
+		return nil
+	}
+
+	return getter.GetMetadata()
+}
+
+// isManaged reports whether the path is kept by cmdr, which is moved by relinking and migrating
+func (v *CommandVerifier) isManaged(path string) bool {
+	dirs := []string{v.binaryMgr.GetShimsDir()}
+	if store := v.binaryMgr.GetStore(); store != nil {
+		dirs = append(dirs, store.GetDir())
+	}
+
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *CommandVerifier) verify(command core.Command) *VerifyResult {
+	name, version := command.GetName(), command.GetVersion()
+	metadata := v.metadataOf(name, version)
+	result := &VerifyResult{
+		Name:     name,
+		Version:  version,
+		Location: command.GetLocation(),
+		Recorded: metadata[MetadataKeyBinarySource],
+		Metadata: metadata,
+	}
+
+	source, err := filepath.EvalSymlinks(binaryOf(result.Location))
+	if err != nil {
+		result.Status = VerifyStatusMissing
+		return result
+	}
+
+	result.Source = source
+
+	info, err := os.Stat(source)
+	if err != nil {
+		result.Status = VerifyStatusMissing
+		return result
+	}
+
+	result.Actual, err = utils.SHA256File(source)
+	if err != nil {
+		result.Status = VerifyStatusMissing
+		return result
+	}
+
+	// the blob of the store is named by its digest
+	var blobDigest string
+	if store := v.binaryMgr.GetStore(); store != nil {
+		blobDigest, _ = store.DigestOf(source)
+	}
+
+	for _, expected := range []string{metadata[MetadataKeyBinarySHA256], metadata[MetadataKeyDownloadSHA256], blobDigest} {
+		if expected != "" {
+			result.Expected = expected
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(metadata[MetadataKeyBinarySize], 10, 64)
+	if err != nil {
+		size = -1
+	}
+
+	switch {
+	case result.Expected == "":
+		result.Status = VerifyStatusUnrecorded
+	case result.Recorded != "" && !v.isManaged(result.Recorded) && source != result.Recorded:
+		// the source of a symlink shim points at another file
+		result.Status = VerifyStatusReplaced
+	case info.Size() < size:
+		result.Status = VerifyStatusTruncated
+	case result.Actual != result.Expected, blobDigest != "" && result.Actual != blobDigest:
+		result.Status = VerifyStatusModified
+	default:
+		result.Status = VerifyStatusOK
+	}
+
+	return result
+}
+
+// Verify checks every shim found by the binary manager
+func (v *CommandVerifier) Verify() ([]*VerifyResult, error) {
+	query, err := v.binaryMgr.Query()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "query shims failed")
+	}
+
+	commands, err := query.All()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "query shims failed")
+	}
+
+	results := make([]*VerifyResult, 0, len(commands))
+	for _, command := range commands {
+		result := v.verify(command)
+		core.GetLogger().Debug("binary verified", map[string]interface{}{
+			"name":    result.Name,
+			"version": result.Version,
+			"status":  result.Status,
+		})
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// reinstallLocation returns the location to reinstall a version from, a version installed from a registry
+// records no location, so it is reinstalled by the empty location, which the download manager resolves
+// by the registries again
+func reinstallLocation(metadata map[string]string) (string, bool) {
+	location := metadata[MetadataKeyDownloadLocation]
+	if location != "" {
+		return location, true
+	}
+
+	return "", metadata[MetadataKeyDownloadRegistry] != ""
+}
+
+// Fix reinstalls the broken versions from their recorded locations by the installer,
+// which is the download manager usually, then it returns the fixed ones
+func (v *CommandVerifier) Fix(results []*VerifyResult, installer core.CommandManager) ([]*VerifyResult, error) {
+	var (
+		errs  error
+		fixed []*VerifyResult
+	)
+
+	for _, result := range results {
+		if !result.Broken() {
+			continue
+		}
+
+		location, ok := reinstallLocation(result.Metadata)
+		if !ok {
+			errs = multierror.Append(errs, errors.Wrapf(ErrNotFixable, "%s(%s) is %s", result.Name, result.Version, result.Status))
+			continue
+		}
+
+		core.GetLogger().Info("reinstalling broken binary", map[string]interface{}{
+			"name":     result.Name,
+			"version":  result.Version,
+			"status":   result.Status,
+			"location": location,
+			"registry": result.Metadata[MetadataKeyDownloadRegistry],
+		})
+
+		_, err := installer.Define(result.Name, result.Version, location)
+		if err != nil {
+			errs = multierror.Append(errs, errors.WithMessagef(err, "reinstall %s(%s) failed", result.Name, result.Version))
+			continue
+		}
+
+		fixed = append(fixed, result)
+	}
+
+	return fixed, errs
+}
+
+func NewCommandVerifier(binaryMgr *BinaryManager, manager core.CommandManager) *CommandVerifier {
+	return &CommandVerifier{
+		binaryMgr: binaryMgr,
+		manager:   manager,
+	}
+}
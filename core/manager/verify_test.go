@@ -0,0 +1,269 @@
+package manager_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+	"github.com/mrlyc/cmdr/core/mock"
+)
+
+var _ = Describe("Verify", func() {
+	var (
+		ctrl      *gomock.Controller
+		mgr       *mock.MockCommandManager
+		query     *mock.MockCommandQuery
+		rootDir   string
+		binDir    string
+		shimsDir  string
+		location  string
+		shim      string
+		binaryMgr *manager.BinaryManager
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mgr = mock.NewMockCommandManager(ctrl)
+		query = mock.NewMockCommandQuery(ctrl)
+
+		var err error
+		rootDir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		rootDir, err = filepath.EvalSymlinks(rootDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		binDir = filepath.Join(rootDir, "bin")
+		shimsDir = filepath.Join(rootDir, "shims")
+		location = filepath.Join(rootDir, "tool")
+		shim = filepath.Join(shimsDir, "tool", "tool_1.0.0")
+
+		Expect(os.WriteFile(location, []byte("#!/bin/sh"), 0755)).To(Succeed())
+
+		binaryMgr = manager.NewBinaryManagerWithCopy(binDir, shimsDir, 0755)
+		binaryMgr.SetStore(manager.NewBinaryStore(filepath.Join(rootDir, "store"), 0755))
+		Expect(binaryMgr.Init(false)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	record := func(metadata map[string]string) {
+		mgr.EXPECT().Query().Return(query, nil).AnyTimes()
+		query.EXPECT().WithName("tool").Return(query).AnyTimes()
+		query.EXPECT().WithVersion("1.0.0").Return(query).AnyTimes()
+		query.EXPECT().One().Return(&manager.Command{
+			Name:     "tool",
+			Version:  "1.0.0",
+			Location: shim,
+			Metadata: metadata,
+		}, nil).AnyTimes()
+	}
+
+	define := func() map[string]string {
+		_, err := binaryMgr.Define("tool", "1.0.0", location)
+		Expect(err).NotTo(HaveOccurred())
+
+		metadata, err := manager.FingerprintBinary(shim)
+		Expect(err).NotTo(HaveOccurred())
+
+		return metadata
+	}
+
+	verify := func() *manager.VerifyResult {
+		results, err := manager.NewCommandVerifier(binaryMgr, mgr).Verify()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+
+		return results[0]
+	}
+
+	blobOf := func() string {
+		blob, err := os.Readlink(shim)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(blob, 0644)).To(Succeed())
+
+		return blob
+	}
+
+	It("should fingerprint the binary of the shim", func() {
+		metadata := define()
+
+		Expect(metadata[manager.MetadataKeyBinarySize]).To(Equal("9"))
+		Expect(metadata[manager.MetadataKeyBinarySource]).To(Equal(blobOf()))
+		Expect(metadata[manager.MetadataKeyBinarySHA256]).To(Equal(filepath.Base(blobOf())))
+	})
+
+	It("should verify an intact binary", func() {
+		record(define())
+
+		result := verify()
+		Expect(result.Status).To(Equal(manager.VerifyStatusOK))
+		Expect(result.Actual).To(Equal(result.Expected))
+		Expect(result.Broken()).To(BeFalse())
+	})
+
+	It("should find the modified binary", func() {
+		record(define())
+		Expect(os.WriteFile(blobOf(), []byte("#!/bin/bash"), 0755)).To(Succeed())
+
+		result := verify()
+		Expect(result.Status).To(Equal(manager.VerifyStatusModified))
+		Expect(result.Broken()).To(BeTrue())
+	})
+
+	It("should find the truncated binary", func() {
+		record(define())
+		Expect(os.Truncate(blobOf(), 2)).To(Succeed())
+
+		Expect(verify().Status).To(Equal(manager.VerifyStatusTruncated))
+	})
+
+	It("should find the missing binary", func() {
+		record(define())
+		Expect(os.Remove(blobOf())).To(Succeed())
+
+		Expect(verify().Status).To(Equal(manager.VerifyStatusMissing))
+	})
+
+	It("should use the digest of the blob when the binary is unrecorded", func() {
+		define()
+		record(nil)
+
+		Expect(verify().Status).To(Equal(manager.VerifyStatusOK))
+
+		Expect(os.WriteFile(blobOf(), []byte("#!/bin/bash"), 0755)).To(Succeed())
+		Expect(verify().Status).To(Equal(manager.VerifyStatusModified))
+	})
+
+	It("should not verify an unrecorded binary without the store", func() {
+		binaryMgr.SetStore(nil)
+		define()
+		record(nil)
+
+		result := verify()
+		Expect(result.Status).To(Equal(manager.VerifyStatusUnrecorded))
+		Expect(result.Broken()).To(BeFalse())
+	})
+
+	It("should find the replaced binary", func() {
+		current := filepath.Join(rootDir, "current")
+		Expect(os.Symlink(location, current)).To(Succeed())
+
+		binaryMgr = manager.NewBinaryManagerWithLink(binDir, shimsDir, 0755)
+		_, err := binaryMgr.Define("tool", "1.0.0", current)
+		Expect(err).NotTo(HaveOccurred())
+
+		metadata, err := manager.FingerprintBinary(shim)
+		Expect(err).NotTo(HaveOccurred())
+		record(metadata)
+
+		Expect(verify().Status).To(Equal(manager.VerifyStatusOK))
+
+		replaced := filepath.Join(rootDir, "replaced")
+		Expect(os.WriteFile(replaced, []byte("#!/bin/sh"), 0755)).To(Succeed())
+		Expect(os.Remove(current)).To(Succeed())
+		Expect(os.Symlink(replaced, current)).To(Succeed())
+
+		result := verify()
+		Expect(result.Status).To(Equal(manager.VerifyStatusReplaced))
+		Expect(result.Source).To(Equal(replaced))
+		Expect(result.Recorded).To(Equal(location))
+	})
+
+	Context("Fix", func() {
+		var installer *mock.MockCommandManager
+
+		BeforeEach(func() {
+			installer = mock.NewMockCommandManager(ctrl)
+		})
+
+		It("should reinstall the broken binaries", func() {
+			results := []*manager.VerifyResult{
+				{Name: "tool", Version: "1.0.0", Status: manager.VerifyStatusOK},
+				{
+					Name:    "tool",
+					Version: "2.0.0",
+					Status:  manager.VerifyStatusModified,
+					Metadata: map[string]string{
+						manager.MetadataKeyDownloadURI:      "https://example.com/tool",
+						manager.MetadataKeyDownloadLocation: "https://example.com/tool",
+					},
+				},
+			}
+
+			installer.EXPECT().Define("tool", "2.0.0", "https://example.com/tool")
+
+			fixed, err := manager.NewCommandVerifier(binaryMgr, mgr).Fix(results, installer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fixed).To(Equal(results[1:]))
+		})
+
+		It("should return an error when the binary is not downloaded", func() {
+			results := []*manager.VerifyResult{
+				{Name: "tool", Version: "1.0.0", Status: manager.VerifyStatusMissing},
+			}
+
+			fixed, err := manager.NewCommandVerifier(binaryMgr, mgr).Fix(results, installer)
+			Expect(fixed).To(BeEmpty())
+			Expect(err).To(MatchError(ContainSubstring(manager.ErrNotFixable.Error())))
+		})
+
+		It("should reinstall the binaries of the registries by resolving them again", func() {
+			results := []*manager.VerifyResult{
+				{
+					Name:    "tool",
+					Version: "1.0.0",
+					Status:  manager.VerifyStatusMissing,
+					Metadata: map[string]string{
+						manager.MetadataKeyDownloadURI:      "https://example.com/tool-1.0.0",
+						manager.MetadataKeyDownloadRegistry: "official",
+					},
+				},
+			}
+
+			installer.EXPECT().Define("tool", "1.0.0", "")
+
+			fixed, err := manager.NewCommandVerifier(binaryMgr, mgr).Fix(results, installer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fixed).To(Equal(results))
+		})
+
+		It("should not reinstall the binaries without location", func() {
+			results := []*manager.VerifyResult{
+				{
+					Name:     "tool",
+					Version:  "1.0.0",
+					Status:   manager.VerifyStatusMissing,
+					Metadata: map[string]string{manager.MetadataKeyDownloadURI: "https://example.com/tool"},
+				},
+			}
+
+			_, err := manager.NewCommandVerifier(binaryMgr, mgr).Fix(results, installer)
+			Expect(err).To(MatchError(ContainSubstring(manager.ErrNotFixable.Error())))
+		})
+
+		It("should return the failures of the installer", func() {
+			results := []*manager.VerifyResult{
+				{
+					Name:     "tool",
+					Version:  "1.0.0",
+					Status:   manager.VerifyStatusMissing,
+					Metadata: map[string]string{manager.MetadataKeyDownloadLocation: "tool"},
+				},
+			}
+
+			installer.EXPECT().Define("tool", "1.0.0", "tool").Return(nil, core.ErrBinaryNotFound)
+
+			_, err := manager.NewCommandVerifier(binaryMgr, mgr).Fix(results, installer)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
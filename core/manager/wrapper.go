@@ -0,0 +1,304 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+// WrappedDir is the hidden dir of the shims dir of a command, which keeps the wrapped binaries
+const WrappedDir = ".wrapped"
+
+var (
+	ErrInvalidWrapper = errors.New("invalid wrapper")
+	ErrNotWrapped     = errors.New("command is not wrapped")
+)
+
+// Wrapper makes the shims of the matched commands scripts, which set the env and prepend the args,
+// the values are templates of WrapperVariables
+type Wrapper struct {
+	// Command is a glob of command names, the wrapper is used by every command when empty
+	Command string `mapstructure:"command"`
+	// Env is a list of KEY=VALUE, the variables like $PATH or ${PATH} are expanded when the command runs
+	Env  []string `mapstructure:"env"`
+	Args []string `mapstructure:"args"`
+}
+
+func (w *Wrapper) match(name string) bool {
+	if w.Command == "" {
+		return true
+	}
+
+	matched, err := path.Match(w.Command, name)
+	return err == nil && matched
+}
+
+// WrapperVariables are available in the templates of wrappers
+type WrapperVariables struct {
+	Name    string
+	Version string
+	// ShimDir is the shims dir of the command
+	ShimDir string
+	// Location is the wrapped binary, Source is the location which is defined
+	Location string
+	Source   string
+}
+
+var wrapperFuncs = template.FuncMap{
+	"dir":  filepath.Dir,
+	"base": filepath.Base,
+}
+
+func renderWrapperValue(text string, vars *WrapperVariables) (string, error) {
+	tmpl, err := template.New(vars.Name).Funcs(wrapperFuncs).Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse template %s failed", text)
+	}
+
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, vars)
+	if err != nil {
+		return "", errors.Wrapf(err, "render template %s failed", text)
+	}
+
+	return buffer.String(), nil
+}
+
+// Wrapping is the rendered wrappers of a command, which is kept beside the wrapped binary
+type Wrapping struct {
+	Location string   `json:"location"`
+	Env      []string `json:"env,omitempty"`
+	Args     []string `json:"args,omitempty"`
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// shellExpandableQuote keeps the $VAR and ${VAR} expansions only, the other $ like $(...) are escaped
+func shellExpandableQuote(value string) string {
+	var builder strings.Builder
+	builder.WriteString(`"`)
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\', '"', '`':
+			builder.WriteByte('\\')
+		case '$':
+			n := shellParameterLength(value[i+1:])
+			if n > 0 {
+				builder.WriteString(value[i : i+1+n])
+				i += n
+				continue
+			}
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(value[i])
+	}
+	builder.WriteString(`"`)
+
+	return builder.String()
+}
+
+// shellParameterLength returns the length of the parameter name or braced name at the beginning of text, 0 if none
+func shellParameterLength(text string) int {
+	if strings.HasPrefix(text, "{") {
+		n := shellNameLength(text[1:])
+		if n > 0 && strings.HasPrefix(text[1+n:], "}") {
+			return n + 2
+		}
+
+		return 0
+	}
+
+	return shellNameLength(text)
+}
+
+func shellNameLength(text string) int {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+
+		return i
+	}
+
+	return len(text)
+}
+
+// Script returns the sh script which runs the wrapped binary
+func (w *Wrapping) Script() string {
+	var builder strings.Builder
+	builder.WriteString("#!/bin/sh\n# generated by cmdr, do not edit\n")
+
+	for _, env := range w.Env {
+		key, value, _ := strings.Cut(env, "=")
+		fmt.Fprintf(&builder, "export %s=%s\n", key, shellExpandableQuote(value))
+	}
+
+	builder.WriteString("exec ")
+	builder.WriteString(shellQuote(w.Location))
+	for _, arg := range w.Args {
+		builder.WriteString(" ")
+		builder.WriteString(shellQuote(arg))
+	}
+	builder.WriteString(" \"$@\"\n")
+
+	return builder.String()
+}
+
+// Environ returns the env, the later values of the same key win
+func (w *Wrapping) Environ() map[string]string {
+	environ := make(map[string]string, len(w.Env))
+	for _, env := range w.Env {
+		key, value, _ := strings.Cut(env, "=")
+		environ[key] = value
+	}
+
+	return environ
+}
+
+// wrappingPath returns the path of the wrapping of a shim
+func wrappingPath(shim string) string {
+	return filepath.Join(filepath.Dir(shim), WrappedDir, filepath.Base(shim)+".json")
+}
+
+// LoadWrapping loads the wrapping of a shim
+func LoadWrapping(shim string) (*Wrapping, error) {
+	content, err := os.ReadFile(wrappingPath(shim))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrapf(ErrNotWrapped, "%s", shim)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "read wrapping of %s failed", shim)
+	}
+
+	var wrapping Wrapping
+	err = json.Unmarshal(content, &wrapping)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse wrapping of %s failed", shim)
+	}
+
+	return &wrapping, nil
+}
+
+func saveWrapping(shim string, wrapping *Wrapping) error {
+	content, err := json.MarshalIndent(wrapping, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "encode wrapping of %s failed", shim)
+	}
+
+	err = os.WriteFile(wrappingPath(shim), content, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "write wrapping of %s failed", shim)
+	}
+
+	return nil
+}
+
+// renderWrappers merges the matched wrappers in order, it returns nil when nothing matched
+func renderWrappers(wrappers []*Wrapper, vars *WrapperVariables) (*Wrapping, error) {
+	var wrapping *Wrapping
+	for _, wrapper := range wrappers {
+		if !wrapper.match(vars.Name) {
+			continue
+		}
+
+		if wrapping == nil {
+			wrapping = &Wrapping{Location: vars.Location}
+		}
+
+		for _, env := range wrapper.Env {
+			key, value, _ := strings.Cut(env, "=")
+			rendered, err := renderWrapperValue(value, vars)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "render env %s of %s failed", key, vars.Name)
+			}
+
+			wrapping.Env = append(wrapping.Env, key+"="+rendered)
+		}
+
+		for _, arg := range wrapper.Args {
+			rendered, err := renderWrapperValue(arg, vars)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "render args of %s failed", vars.Name)
+			}
+
+			wrapping.Args = append(wrapping.Args, rendered)
+		}
+	}
+
+	return wrapping, nil
+}
+
+func isEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateWrappers checks the globs and the env of wrappers
+func ValidateWrappers(wrappers []*Wrapper) error {
+	for index, wrapper := range wrappers {
+		if _, err := path.Match(wrapper.Command, ""); err != nil {
+			return errors.Wrapf(ErrInvalidWrapper, "command pattern %q of wrapper %d: %v", wrapper.Command, index, err)
+		}
+
+		for _, env := range wrapper.Env {
+			key, _, ok := strings.Cut(env, "=")
+			if !ok || !isEnvKey(key) {
+				return errors.Wrapf(ErrInvalidWrapper, "env %q of wrapper %d, expected KEY=VALUE", env, index)
+			}
+		}
+	}
+
+	return nil
+}
+
+// NewWrappersByConfiguration returns the configured wrappers, then the one of the define and install flags
+func NewWrappersByConfiguration(cfg core.Configuration) ([]*Wrapper, error) {
+	var wrappers []*Wrapper
+	err := cfg.UnmarshalKey(core.CfgKeyWrappers, &wrappers)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", core.CfgKeyWrappers)
+	}
+
+	flagged := &Wrapper{}
+	for _, keys := range [][2]string{
+		{core.CfgKeyXCommandDefineEnv, core.CfgKeyXCommandDefineArgs},
+		{core.CfgKeyXCommandInstallEnv, core.CfgKeyXCommandInstallArgs},
+	} {
+		flagged.Env = append(flagged.Env, cfg.GetStringSlice(keys[0])...)
+		flagged.Args = append(flagged.Args, cfg.GetStringSlice(keys[1])...)
+	}
+
+	if len(flagged.Env) > 0 || len(flagged.Args) > 0 {
+		wrappers = append(wrappers, flagged)
+	}
+
+	err = ValidateWrappers(wrappers)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrappers, nil
+}
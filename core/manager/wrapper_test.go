@@ -0,0 +1,120 @@
+package manager_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/manager"
+)
+
+var _ = Describe("Wrapper", func() {
+	Context("Wrapping", func() {
+		It("should run the wrapped binary", func() {
+			if runtime.GOOS == "windows" {
+				Skip("wrapper shims are sh scripts")
+			}
+
+			rootDir, err := os.MkdirTemp("", "")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(rootDir)
+
+			binary := filepath.Join(rootDir, "binary")
+			Expect(os.WriteFile(binary, []byte("#!/bin/sh\necho \"$CMDR_TEST_HOME|$CMDR_TEST_QUOTED|$*\"\n"), 0755)).To(Succeed())
+
+			wrapping := &manager.Wrapping{
+				Location: binary,
+				Env:      []string{"CMDR_TEST_HOME=$CMDR_TEST_ROOT/home", "CMDR_TEST_QUOTED=\"`x`\\"},
+				Args:     []string{"--name", "it's"},
+			}
+
+			shim := filepath.Join(rootDir, "shim")
+			Expect(os.WriteFile(shim, []byte(wrapping.Script()), 0755)).To(Succeed())
+
+			command := exec.Command(shim, "extra")
+			command.Env = append(os.Environ(), "CMDR_TEST_ROOT=/root")
+			output, err := command.Output()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(output)).To(Equal("/root/home|\"`x`\\|--name it's extra\n"))
+		})
+
+		It("should not run the command substitutions of the env", func() {
+			if runtime.GOOS == "windows" {
+				Skip("wrapper shims are sh scripts")
+			}
+
+			rootDir, err := os.MkdirTemp("", "")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(rootDir)
+
+			binary := filepath.Join(rootDir, "binary")
+			Expect(os.WriteFile(binary, []byte("#!/bin/sh\necho \"$CMDR_TEST_VALUE\"\n"), 0755)).To(Succeed())
+
+			wrapping := &manager.Wrapping{
+				Location: binary,
+				Env:      []string{"CMDR_TEST_VALUE=$(echo x) ${CMDR_TEST_ROOT}/$CMDR_TEST_ROOT ${CMDR_TEST_ROOT:-y} $$ $1 $"},
+			}
+
+			shim := filepath.Join(rootDir, "shim")
+			Expect(os.WriteFile(shim, []byte(wrapping.Script()), 0755)).To(Succeed())
+
+			command := exec.Command(shim)
+			command.Env = append(os.Environ(), "CMDR_TEST_ROOT=/root")
+			output, err := command.Output()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(output)).To(Equal("$(echo x) /root//root ${CMDR_TEST_ROOT:-y} $$ $1 $\n"))
+		})
+
+		It("should let the later env win", func() {
+			wrapping := &manager.Wrapping{Env: []string{"A=1", "B=2", "A=3"}}
+			Expect(wrapping.Environ()).To(Equal(map[string]string{"A": "3", "B": "2"}))
+		})
+	})
+
+	Context("Configuration", func() {
+		var cfg core.Configuration
+
+		BeforeEach(func() {
+			cfg = viper.New()
+		})
+
+		It("should append the wrapper of flags", func() {
+			cfg.Set(core.CfgKeyWrappers, []map[string]interface{}{
+				{"command": "java", "env": []string{"JAVA_HOME={{ dir .Source }}"}},
+			})
+			cfg.Set(core.CfgKeyXCommandInstallEnv, []string{"GOROOT={{ .ShimDir }}"})
+			cfg.Set(core.CfgKeyXCommandInstallArgs, []string{"-v"})
+
+			wrappers, err := manager.NewWrappersByConfiguration(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wrappers).To(Equal([]*manager.Wrapper{
+				{Command: "java", Env: []string{"JAVA_HOME={{ dir .Source }}"}},
+				{Env: []string{"GOROOT={{ .ShimDir }}"}, Args: []string{"-v"}},
+			}))
+		})
+
+		It("should return nothing", func() {
+			wrappers, err := manager.NewWrappersByConfiguration(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wrappers).To(BeEmpty())
+		})
+
+		It("should validate wrappers", func() {
+			for _, wrapper := range []*manager.Wrapper{
+				{Command: "[", Env: []string{"A=1"}},
+				{Env: []string{"1A=1"}},
+				{Env: []string{"A-B=1"}},
+			} {
+				err := manager.ValidateWrappers([]*manager.Wrapper{wrapper})
+				Expect(errors.Cause(err)).To(Equal(manager.ErrInvalidWrapper))
+			}
+		})
+	})
+})
@@ -336,3 +336,126 @@ func (mr *MockCommandManagerMockRecorder) Undefine(name, version interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Undefine", reflect.TypeOf((*MockCommandManager)(nil).Undefine), name, version)
 }
+
+// MockCommandMetadataSetter is a mock of CommandMetadataSetter interface.
+type MockCommandMetadataSetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandMetadataSetterMockRecorder
+}
+
+// MockCommandMetadataSetterMockRecorder is the mock recorder for MockCommandMetadataSetter.
+type MockCommandMetadataSetterMockRecorder struct {
+	mock *MockCommandMetadataSetter
+}
+
+// NewMockCommandMetadataSetter creates a new mock instance.
+func NewMockCommandMetadataSetter(ctrl *gomock.Controller) *MockCommandMetadataSetter {
+	mock := &MockCommandMetadataSetter{ctrl: ctrl}
+	mock.recorder = &MockCommandMetadataSetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandMetadataSetter) EXPECT() *MockCommandMetadataSetterMockRecorder {
+	return m.recorder
+}
+
+// SetMetadata mocks base method.
+func (m *MockCommandMetadataSetter) SetMetadata(name, version string, metadata map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMetadata", name, version, metadata)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMetadata indicates an expected call of SetMetadata.
+func (mr *MockCommandMetadataSetterMockRecorder) SetMetadata(name, version, metadata interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetadata", reflect.TypeOf((*MockCommandMetadataSetter)(nil).SetMetadata), name, version, metadata)
+}
+
+// MockCommandMetadataGetter is a mock of CommandMetadataGetter interface.
+type MockCommandMetadataGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandMetadataGetterMockRecorder
+}
+
+// MockCommandMetadataGetterMockRecorder is the mock recorder for MockCommandMetadataGetter.
+type MockCommandMetadataGetterMockRecorder struct {
+	mock *MockCommandMetadataGetter
+}
+
+// NewMockCommandMetadataGetter creates a new mock instance.
+func NewMockCommandMetadataGetter(ctrl *gomock.Controller) *MockCommandMetadataGetter {
+	mock := &MockCommandMetadataGetter{ctrl: ctrl}
+	mock.recorder = &MockCommandMetadataGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandMetadataGetter) EXPECT() *MockCommandMetadataGetterMockRecorder {
+	return m.recorder
+}
+
+// GetMetadata mocks base method.
+func (m *MockCommandMetadataGetter) GetMetadata() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockCommandMetadataGetterMockRecorder) GetMetadata() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockCommandMetadataGetter)(nil).GetMetadata))
+}
+
+// MockCommandHistoryRecorder is a mock of CommandHistoryRecorder interface.
+type MockCommandHistoryRecorder struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandHistoryRecorderMockRecorder
+}
+
+// MockCommandHistoryRecorderMockRecorder is the mock recorder for MockCommandHistoryRecorder.
+type MockCommandHistoryRecorderMockRecorder struct {
+	mock *MockCommandHistoryRecorder
+}
+
+// NewMockCommandHistoryRecorder creates a new mock instance.
+func NewMockCommandHistoryRecorder(ctrl *gomock.Controller) *MockCommandHistoryRecorder {
+	mock := &MockCommandHistoryRecorder{ctrl: ctrl}
+	mock.recorder = &MockCommandHistoryRecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandHistoryRecorder) EXPECT() *MockCommandHistoryRecorderMockRecorder {
+	return m.recorder
+}
+
+// RecordHistory mocks base method.
+func (m *MockCommandHistoryRecorder) RecordHistory(operation, name, version, location, previous string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordHistory", operation, name, version, location, previous)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordHistory indicates an expected call of RecordHistory.
+func (mr *MockCommandHistoryRecorderMockRecorder) RecordHistory(operation, name, version, location, previous interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordHistory", reflect.TypeOf((*MockCommandHistoryRecorder)(nil).RecordHistory), operation, name, version, location, previous)
+}
+
+// SetHistoryCause mocks base method.
+func (m *MockCommandHistoryRecorder) SetHistoryCause(cause string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetHistoryCause", cause)
+}
+
+// SetHistoryCause indicates an expected call of SetHistoryCause.
+func (mr *MockCommandHistoryRecorderMockRecorder) SetHistoryCause(cause interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHistoryCause", reflect.TypeOf((*MockCommandHistoryRecorder)(nil).SetHistoryCause), cause)
+}
@@ -60,3 +60,68 @@ func (mr *MockFetcherMockRecorder) IsSupport(uri interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSupport", reflect.TypeOf((*MockFetcher)(nil).IsSupport), uri)
 }
+
+// MockMetadataFetcher is a mock of MetadataFetcher interface.
+type MockMetadataFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetadataFetcherMockRecorder
+}
+
+// MockMetadataFetcherMockRecorder is the mock recorder for MockMetadataFetcher.
+type MockMetadataFetcherMockRecorder struct {
+	mock *MockMetadataFetcher
+}
+
+// NewMockMetadataFetcher creates a new mock instance.
+func NewMockMetadataFetcher(ctrl *gomock.Controller) *MockMetadataFetcher {
+	mock := &MockMetadataFetcher{ctrl: ctrl}
+	mock.recorder = &MockMetadataFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetadataFetcher) EXPECT() *MockMetadataFetcherMockRecorder {
+	return m.recorder
+}
+
+// Fetch mocks base method.
+func (m *MockMetadataFetcher) Fetch(name, version, uri, dir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fetch", name, version, uri, dir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fetch indicates an expected call of Fetch.
+func (mr *MockMetadataFetcherMockRecorder) Fetch(name, version, uri, dir interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockMetadataFetcher)(nil).Fetch), name, version, uri, dir)
+}
+
+// GetMetadata mocks base method.
+func (m *MockMetadataFetcher) GetMetadata(name, version string) map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata", name, version)
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockMetadataFetcherMockRecorder) GetMetadata(name, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockMetadataFetcher)(nil).GetMetadata), name, version)
+}
+
+// IsSupport mocks base method.
+func (m *MockMetadataFetcher) IsSupport(uri string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSupport", uri)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsSupport indicates an expected call of IsSupport.
+func (mr *MockMetadataFetcherMockRecorder) IsSupport(uri interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSupport", reflect.TypeOf((*MockMetadataFetcher)(nil).IsSupport), uri)
+}
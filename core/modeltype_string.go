@@ -10,11 +10,13 @@ func _() {
 	var x [1]struct{}
 	_ = x[ModelTypeUnknown-0]
 	_ = x[ModelTypeCommand-1]
+	_ = x[ModelTypeHistory-2]
+	_ = x[ModelTypeEnvironment-3]
 }
 
-const _ModelType_name = "ModelTypeUnknownModelTypeCommand"
+const _ModelType_name = "ModelTypeUnknownModelTypeCommandModelTypeHistoryModelTypeEnvironment"
 
-var _ModelType_index = [...]uint8{0, 16, 32}
+var _ModelType_index = [...]uint8{0, 16, 32, 48, 68}
 
 func (i ModelType) String() string {
 	if i < 0 || i >= ModelType(len(_ModelType_index)-1) {
@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrChecksumNotFound = fmt.Errorf("checksum not found")
+	ErrChecksumMismatch = fmt.Errorf("checksum mismatch")
+)
+
+// ParseChecksum finds the sha256 of a file in a checksum file like SHA256SUMS,
+// a file which contains a single digest is accepted too
+func ParseChecksum(data []byte, filename string) (string, error) {
+	var digests []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		digest := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != 64 {
+			continue
+		}
+
+		digests = append(digests, digest)
+		if len(fields) == 1 {
+			continue
+		}
+
+		// the binary mode of sha256sum prefixes the file name with *
+		name := strings.TrimPrefix(fields[len(fields)-1], "*")
+		if name == filename || path.Base(name) == filename {
+			return digest, nil
+		}
+	}
+
+	if len(digests) == 1 {
+		return digests[0], nil
+	}
+
+	return "", errors.Wrapf(ErrChecksumNotFound, "%s", filename)
+}
+
+// FetchChecksum downloads the checksum file and returns the sha256 of the artifact of location
+func FetchChecksum(ctx context.Context, client *http.Client, checksumURL, location string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checksumURL, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "create request of %s failed", checksumURL)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "fetch checksum %s failed", checksumURL)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("fetch checksum %s failed: bad response code %d", checksumURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", errors.Wrapf(err, "read checksum %s failed", checksumURL)
+	}
+
+	filename := location
+	if idx := strings.IndexAny(filename, "?#"); idx >= 0 {
+		filename = filename[:idx]
+	}
+
+	return ParseChecksum(data, path.Base(filename))
+}
@@ -0,0 +1,73 @@
+package registry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mrlyc/cmdr/core/registry"
+)
+
+var _ = Describe("Checksum", func() {
+	var (
+		digestA = strings.Repeat("a", 64)
+		digestB = strings.Repeat("b", 64)
+	)
+
+	DescribeTable("ParseChecksum", func(content, filename, expected string) {
+		digest, err := ParseChecksum([]byte(content), filename)
+		Expect(err).To(BeNil())
+		Expect(digest).To(Equal(expected))
+	},
+		Entry("sha256sum", fmt.Sprintf("%s  jq-linux-amd64\n%s  jq-macos-arm64\n", digestA, digestB), "jq-macos-arm64", digestB),
+		Entry("binary mode", fmt.Sprintf("%s *jq-linux-amd64\n%s *jq-macos-arm64\n", digestA, digestB), "jq-linux-amd64", digestA),
+		Entry("path", fmt.Sprintf("%s  dist/jq-linux-amd64\n%s  dist/jq-macos-arm64\n", digestA, digestB), "jq-linux-amd64", digestA),
+		Entry("single digest", digestA+"\n", "jq-linux-amd64", digestA),
+		Entry("upper case", strings.ToUpper(digestA)+"  jq\n", "jq", digestA),
+	)
+
+	It("should fail when not found", func() {
+		_, err := ParseChecksum([]byte(fmt.Sprintf("%s  a\n%s  b\n", digestA, digestB)), "c")
+		Expect(errors.Is(err, ErrChecksumNotFound)).To(BeTrue())
+	})
+
+	Context("FetchChecksum", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/SHA256SUMS" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				_, _ = fmt.Fprintf(w, "%s  jq-linux-amd64\n%s  jq-macos-arm64\n", digestA, digestB)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should find the digest by the file name of location", func() {
+			digest, err := FetchChecksum(
+				context.Background(), server.Client(), server.URL+"/SHA256SUMS",
+				"https://example.com/download/jq-macos-arm64?raw=true",
+			)
+			Expect(err).To(BeNil())
+			Expect(digest).To(Equal(digestB))
+		})
+
+		It("should fail with bad response", func() {
+			_, err := FetchChecksum(context.Background(), server.Client(), server.URL+"/missing", "jq")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,195 @@
+package registry
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Platform overrides the templates of a tool for an os, like darwin, or an os/arch, like darwin/arm64
+type Platform struct {
+	Location string `yaml:"location" json:"location"`
+	Entry    string `yaml:"entry" json:"entry"`
+	Checksum string `yaml:"checksum" json:"checksum"`
+}
+
+// VersionDiscovery tells where to list the versions of a tool
+type VersionDiscovery struct {
+	// GitHub is the repository like jqlang/jq, the versions are the tags of releases
+	GitHub    string `yaml:"github" json:"github"`
+	TagPrefix string `yaml:"tag_prefix" json:"tag_prefix"`
+}
+
+// Tool describes how to install a tool, the fields are templates of Variables
+type Tool struct {
+	Description string `yaml:"description" json:"description"`
+	Location    string `yaml:"location" json:"location"`
+	// Entry is the path of the binary in the archive
+	Entry string `yaml:"entry" json:"entry"`
+	// Checksum is the url of a sha256 checksum file, like SHA256SUMS
+	Checksum  string               `yaml:"checksum" json:"checksum"`
+	OS        map[string]string    `yaml:"os" json:"os"`
+	Arch      map[string]string    `yaml:"arch" json:"arch"`
+	Platforms map[string]*Platform `yaml:"platforms" json:"platforms"`
+	Versions  *VersionDiscovery    `yaml:"versions" json:"versions"`
+}
+
+// Variables are available in the templates of tools
+type Variables struct {
+	Name    string
+	Version string
+	GOOS    string
+	GOARCH  string
+	// OS and Arch are mapped by the os and arch of the tool, like macos for darwin
+	OS   string
+	Arch string
+}
+
+// Resolution is a tool rendered for a version and a platform
+type Resolution struct {
+	Registry string
+	Location string
+	Entry    string
+	Checksum string
+}
+
+func render(name, text string, vars *Variables) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse template of %s failed", name)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, vars)
+	if err != nil {
+		return "", errors.Wrapf(err, "render template of %s failed", name)
+	}
+
+	return buf.String(), nil
+}
+
+// Render returns the location, entry and checksum url of a version for a platform
+func (t *Tool) Render(name, version, goos, goarch string) (*Resolution, error) {
+	vars := &Variables{
+		Name:    name,
+		Version: version,
+		GOOS:    goos,
+		GOARCH:  goarch,
+		OS:      goos,
+		Arch:    goarch,
+	}
+
+	if value, ok := t.OS[goos]; ok {
+		vars.OS = value
+	}
+
+	if value, ok := t.Arch[goarch]; ok {
+		vars.Arch = value
+	}
+
+	platform := &Platform{Location: t.Location, Entry: t.Entry, Checksum: t.Checksum}
+	// the os/arch overrides take precedence over the os ones
+	for _, key := range []string{goos, goos + "/" + goarch} {
+		override, ok := t.Platforms[key]
+		if !ok || override == nil {
+			continue
+		}
+
+		if override.Location != "" {
+			platform.Location = override.Location
+		}
+
+		if override.Entry != "" {
+			platform.Entry = override.Entry
+		}
+
+		if override.Checksum != "" {
+			platform.Checksum = override.Checksum
+		}
+	}
+
+	if platform.Location == "" {
+		return nil, errors.Wrapf(ErrPlatformNotSupported, "%s on %s/%s", name, goos, goarch)
+	}
+
+	resolution := &Resolution{}
+	for _, field := range []struct {
+		text   string
+		result *string
+	}{
+		{platform.Location, &resolution.Location},
+		{platform.Entry, &resolution.Entry},
+		{platform.Checksum, &resolution.Checksum},
+	} {
+		value, err := render(name, field.text, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		*field.result = value
+	}
+
+	return resolution, nil
+}
+
+// Index is a registry file, the yaml and json formats are both accepted
+type Index struct {
+	Tools map[string]*Tool `yaml:"tools" json:"tools"`
+}
+
+// findIndex returns the index.yaml, index.yml or index.json of a dir, or the only file in it
+func findIndex(dir string) (string, error) {
+	for _, name := range []string{"index.yaml", "index.yml", "index.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name), nil
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "read dir %s failed", dir)
+	}
+
+	if len(entries) == 1 && !entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+
+	return "", errors.Wrapf(ErrIndexNotFound, "%s", dir)
+}
+
+// LoadIndex loads an index file, or the index file of a dir
+func LoadIndex(path string) (*Index, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat index %s failed", path)
+	}
+
+	if info.IsDir() {
+		path, err = findIndex(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read index %s failed", path)
+	}
+
+	// json is a subset of yaml
+	index := &Index{}
+	err = yaml.Unmarshal(data, index)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse index %s failed", path)
+	}
+
+	return index, nil
+}
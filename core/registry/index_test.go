@@ -0,0 +1,119 @@
+package registry_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mrlyc/cmdr/core/registry"
+)
+
+var _ = Describe("Index", func() {
+	Context("Render", func() {
+		var tool *Tool
+
+		BeforeEach(func() {
+			tool = &Tool{
+				Location: "https://github.com/jqlang/jq/releases/download/jq-{{ .Version }}/jq-{{ .OS }}-{{ .Arch }}",
+				Checksum: "https://github.com/jqlang/jq/releases/download/jq-{{ .Version }}/sha256sum.txt",
+				OS:       map[string]string{"darwin": "macos"},
+				Arch:     map[string]string{"amd64": "x86_64"},
+				Platforms: map[string]*Platform{
+					"windows":       {Location: "https://example.com/{{ .Name }}-{{ .Version }}.zip", Entry: "{{ .Name }}.exe"},
+					"windows/arm64": {Entry: "arm64/{{ .Name }}.exe"},
+				},
+			}
+		})
+
+		It("should map os and arch", func() {
+			resolution, err := tool.Render("jq", "1.7.1", "darwin", "amd64")
+			Expect(err).To(BeNil())
+			Expect(resolution.Location).To(Equal("https://github.com/jqlang/jq/releases/download/jq-1.7.1/jq-macos-x86_64"))
+			Expect(resolution.Checksum).To(Equal("https://github.com/jqlang/jq/releases/download/jq-1.7.1/sha256sum.txt"))
+			Expect(resolution.Entry).To(Equal(""))
+		})
+
+		It("should keep the go names when not mapped", func() {
+			resolution, err := tool.Render("jq", "1.7.1", "linux", "arm64")
+			Expect(err).To(BeNil())
+			Expect(resolution.Location).To(HaveSuffix("jq-linux-arm64"))
+		})
+
+		It("should apply platform overrides", func() {
+			resolution, err := tool.Render("jq", "1.7.1", "windows", "amd64")
+			Expect(err).To(BeNil())
+			Expect(resolution.Location).To(Equal("https://example.com/jq-1.7.1.zip"))
+			Expect(resolution.Entry).To(Equal("jq.exe"))
+
+			resolution, err = tool.Render("jq", "1.7.1", "windows", "arm64")
+			Expect(err).To(BeNil())
+			Expect(resolution.Location).To(Equal("https://example.com/jq-1.7.1.zip"))
+			Expect(resolution.Entry).To(Equal("arm64/jq.exe"))
+		})
+
+		It("should fail without location", func() {
+			tool.Location = ""
+			_, err := tool.Render("jq", "1.7.1", "linux", "amd64")
+			Expect(errors.Is(err, ErrPlatformNotSupported)).To(BeTrue())
+		})
+
+		It("should fail with unknown variables", func() {
+			tool.Location = "{{ .Unknown }}"
+			_, err := tool.Render("jq", "1.7.1", "linux", "amd64")
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("LoadIndex", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "")
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should load yaml", func() {
+			path := filepath.Join(dir, "index.yaml")
+			Expect(os.WriteFile(path, []byte(`
+tools:
+  jq:
+    description: json processor
+    location: https://example.com/jq-{{ .Version }}
+    versions:
+      github: jqlang/jq
+      tag_prefix: jq-
+`), 0644)).To(Succeed())
+
+			index, err := LoadIndex(dir)
+			Expect(err).To(BeNil())
+			Expect(index.Tools).To(HaveKey("jq"))
+			Expect(index.Tools["jq"].Description).To(Equal("json processor"))
+			Expect(index.Tools["jq"].Versions.TagPrefix).To(Equal("jq-"))
+		})
+
+		It("should load json", func() {
+			path := filepath.Join(dir, "tools.json")
+			Expect(os.WriteFile(path, []byte(`{"tools": {"jq": {"location": "https://example.com/jq", "os": {"darwin": "macos"}}}}`), 0644)).To(Succeed())
+
+			index, err := LoadIndex(dir)
+			Expect(err).To(BeNil())
+			Expect(index.Tools["jq"].OS).To(Equal(map[string]string{"darwin": "macos"}))
+		})
+
+		It("should fail when the index is ambiguous", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("tools: {}"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("tools: {}"), 0644)).To(Succeed())
+
+			_, err := LoadIndex(dir)
+			Expect(errors.Is(err, ErrIndexNotFound)).To(BeTrue())
+		})
+	})
+})
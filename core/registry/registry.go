@@ -0,0 +1,339 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-getter"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+var (
+	ErrToolNotFound         = fmt.Errorf("tool not found in registries")
+	ErrIndexNotFound        = fmt.Errorf("registry index not found")
+	ErrPlatformNotSupported = fmt.Errorf("platform not supported by registry")
+	ErrRegistryUnavailable  = fmt.Errorf("registry unavailable")
+	ErrInvalidRegistryName  = fmt.Errorf("invalid registry name")
+)
+
+// Source is where a registry index is loaded from, a local path, a git repository or a http url
+type Source struct {
+	Name string `mapstructure:"name" yaml:"name"`
+	URL  string `mapstructure:"url" yaml:"url"`
+	// Path is the index file in a git repository or an archive
+	Path string `mapstructure:"path" yaml:"path"`
+	// Priority decides which registry wins when a tool is defined by many, the higher the better
+	Priority int `mapstructure:"priority" yaml:"priority"`
+	// Fallback lets the lower priority registries resolve the tools when this one fails to load,
+	// otherwise the lookups fail, so a tool can't be taken from a registry it is not meant to come from
+	Fallback bool `mapstructure:"fallback" yaml:"fallback"`
+}
+
+// validateName makes sure the name is a single path element, it names the cache dir
+func (s *Source) validateName() error {
+	if s.Name == "" || s.Name == "." || s.Name == ".." || strings.ContainsAny(s.Name, `/\`) || filepath.Base(s.Name) != s.Name {
+		return errors.Wrapf(ErrInvalidRegistryName, "%q", s.Name)
+	}
+
+	return nil
+}
+
+// isLocal returns true when the url is a local path, which is read in place instead of cached
+func (s *Source) isLocal() bool {
+	if filepath.IsAbs(s.URL) {
+		return true
+	}
+
+	parsed, err := url.Parse(s.URL)
+	return err == nil && parsed.Scheme == "file"
+}
+
+func (s *Source) localPath() string {
+	return strings.TrimPrefix(s.URL, "file://")
+}
+
+// Found is a tool with the registry which defines it
+type Found struct {
+	Tool     *Tool
+	Registry string
+	rank     int
+}
+
+// loadFailure is a source failed to load, rank is its position in the sources
+type loadFailure struct {
+	source *Source
+	rank   int
+	err    error
+}
+
+// Registry layers the indexes of sources by priority
+type Registry struct {
+	sources  []*Source
+	cacheDir string
+	offline  bool
+	client   *http.Client
+
+	loadOnce sync.Once
+	tools    map[string]*Found
+	failures []*loadFailure
+}
+
+func (r *Registry) SetOffline(offline bool) {
+	r.offline = offline
+}
+
+func (r *Registry) SetHTTPClient(client *http.Client) {
+	r.client = client
+}
+
+func (r *Registry) Sources() []*Source {
+	return r.sources
+}
+
+func (r *Registry) cachePath(source *Source) string {
+	return filepath.Join(r.cacheDir, source.Name)
+}
+
+// fetch downloads the source into the cache dir, the old cache is kept when failed
+func (r *Registry) fetch(source *Source) error {
+	err := source.validateName()
+	if err != nil {
+		return err
+	}
+
+	if r.offline {
+		return errors.Wrapf(core.ErrOffline, "fetch registry %s", source.Name)
+	}
+
+	err = os.MkdirAll(r.cacheDir, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "create registry cache dir %s failed", r.cacheDir)
+	}
+
+	dst, err := os.MkdirTemp(r.cacheDir, "."+source.Name)
+	if err != nil {
+		return errors.Wrapf(err, "create temp dir failed")
+	}
+	defer os.RemoveAll(dst)
+
+	pwd, _ := os.Getwd()
+	client := &getter.Client{
+		Src:  source.URL,
+		Dst:  filepath.Join(dst, "index"),
+		Pwd:  pwd,
+		Mode: getter.ClientModeAny,
+	}
+
+	err = client.Get()
+	if err != nil {
+		return errors.Wrapf(err, "fetch registry %s failed", source.Name)
+	}
+
+	cachePath := r.cachePath(source)
+	err = os.RemoveAll(cachePath)
+	if err != nil {
+		return errors.Wrapf(err, "remove registry cache %s failed", cachePath)
+	}
+
+	err = os.Rename(filepath.Join(dst, "index"), cachePath)
+	if err != nil {
+		return errors.Wrapf(err, "save registry cache %s failed", cachePath)
+	}
+
+	return nil
+}
+
+func (r *Registry) loadSource(source *Source) (*Index, error) {
+	path := r.cachePath(source)
+	if source.isLocal() {
+		path = source.localPath()
+	} else if _, err := os.Stat(path); err != nil {
+		err = r.fetch(source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if source.Path != "" {
+		path = filepath.Join(path, source.Path)
+	}
+
+	return LoadIndex(path)
+}
+
+func (r *Registry) load() map[string]*Found {
+	r.loadOnce.Do(func() {
+		logger := core.GetLogger()
+		r.tools = make(map[string]*Found)
+
+		// the sources are sorted by priority, so the first one wins
+		for rank, source := range r.sources {
+			index, err := r.loadSource(source)
+			if err != nil {
+				logger.Warn("failed to load registry", map[string]interface{}{
+					"registry": source.Name,
+					"error":    err,
+				})
+				r.failures = append(r.failures, &loadFailure{source: source, rank: rank, err: err})
+				continue
+			}
+
+			for name, tool := range index.Tools {
+				if _, ok := r.tools[name]; !ok && tool != nil {
+					r.tools[name] = &Found{Tool: tool, Registry: source.Name, rank: rank}
+				}
+			}
+		}
+	})
+
+	return r.tools
+}
+
+// Update refreshes the caches of remote registries
+func (r *Registry) Update() error {
+	var errs []string
+	for _, source := range r.sources {
+		if source.isLocal() {
+			continue
+		}
+
+		err := r.fetch(source)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Tools returns all the tools by name, the tools of lower priority registries are hidden
+func (r *Registry) Tools() map[string]*Found {
+	return r.load()
+}
+
+// Lookup fails when a registry which has a higher priority than the found one failed to load,
+// unless the failed registry allows to fall back
+func (r *Registry) Lookup(name string) (*Found, error) {
+	found, ok := r.load()[name]
+	for _, failure := range r.failures {
+		if ok && failure.rank > found.rank {
+			break
+		}
+
+		if !failure.source.Fallback {
+			return nil, errors.Wrapf(ErrRegistryUnavailable, "registry %s may define %s: %v", failure.source.Name, name, failure.err)
+		}
+	}
+
+	if !ok {
+		return nil, errors.Wrapf(ErrToolNotFound, "%s", name)
+	}
+
+	return found, nil
+}
+
+// Resolve renders the tool for a version and a platform
+func (r *Registry) Resolve(name, version, goos, goarch string) (*Resolution, error) {
+	found, err := r.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution, err := found.Tool.Render(name, version, goos, goarch)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution.Registry = found.Registry
+	return resolution, nil
+}
+
+// Checksum returns the sha256 of the artifact which is published by the checksum url of the resolution,
+// an empty digest is returned when there is no checksum url
+func (r *Registry) Checksum(ctx context.Context, resolution *Resolution) (string, error) {
+	if resolution.Checksum == "" {
+		return "", nil
+	}
+
+	if r.offline {
+		return "", errors.Wrapf(core.ErrOffline, "fetch checksum %s", resolution.Checksum)
+	}
+
+	return FetchChecksum(ctx, r.client, resolution.Checksum, resolution.Location)
+}
+
+// Versions lists the versions of a tool by its version discovery settings
+func (r *Registry) Versions(ctx context.Context, name string, lister ReleaseLister) ([]string, error) {
+	found, err := r.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.offline {
+		return nil, errors.Wrapf(core.ErrOffline, "list versions of %s", name)
+	}
+
+	return ListVersions(ctx, lister, found.Tool.Versions)
+}
+
+func NewRegistry(cacheDir string, sources ...*Source) *Registry {
+	sorted := append([]*Source{}, sources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+
+	return &Registry{
+		sources:  sorted,
+		cacheDir: cacheDir,
+		client:   http.DefaultClient,
+	}
+}
+
+// NewRegistryByConfiguration creates a registry with registries, the indexes are loaded on demand
+func NewRegistryByConfiguration(cfg core.Configuration) (*Registry, error) {
+	var sources []*Source
+	err := cfg.UnmarshalKey(core.CfgKeyRegistries, &sources)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", core.CfgKeyRegistries)
+	}
+
+	names := make(map[string]bool, len(sources))
+	for index, source := range sources {
+		if source.URL == "" {
+			return nil, errors.Errorf("url of registry %d is required", index)
+		}
+
+		if source.Name == "" {
+			source.Name = fmt.Sprintf("registry-%d", index)
+		}
+
+		err = source.validateName()
+		if err != nil {
+			return nil, err
+		}
+
+		if names[source.Name] {
+			return nil, errors.Errorf("registry %s is duplicated", source.Name)
+		}
+
+		names[source.Name] = true
+	}
+
+	registry := NewRegistry(cfg.GetString(core.CfgKeyCmdrRegistryDir), sources...)
+	registry.SetOffline(cfg.GetBool(core.CfgKeyCmdrOffline))
+
+	return registry, nil
+}
@@ -0,0 +1,244 @@
+package registry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	. "github.com/mrlyc/cmdr/core/registry"
+)
+
+var _ = Describe("Registry", func() {
+	var dir string
+
+	writeIndex := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should layer registries by priority", func() {
+		official := writeIndex("official.yaml", `
+tools:
+  jq: {location: "https://official/jq-{{ .Version }}"}
+  yq: {location: "https://official/yq-{{ .Version }}"}
+`)
+		local := writeIndex("local.yaml", `
+tools:
+  jq: {location: "https://local/jq-{{ .Version }}", entry: "bin/jq"}
+`)
+
+		registry := NewRegistry(filepath.Join(dir, "cache"),
+			&Source{Name: "official", URL: official},
+			&Source{Name: "local", URL: "file://" + local, Priority: 10},
+			&Source{Name: "broken", URL: filepath.Join(dir, "missing.yaml"), Priority: 20, Fallback: true},
+		)
+
+		resolution, err := registry.Resolve("jq", "1.7.1", "linux", "amd64")
+		Expect(err).To(BeNil())
+		Expect(resolution.Registry).To(Equal("local"))
+		Expect(resolution.Location).To(Equal("https://local/jq-1.7.1"))
+		Expect(resolution.Entry).To(Equal("bin/jq"))
+
+		resolution, err = registry.Resolve("yq", "4.0.0", "linux", "amd64")
+		Expect(err).To(BeNil())
+		Expect(resolution.Registry).To(Equal("official"))
+
+		_, err = registry.Resolve("fzf", "1.0.0", "linux", "amd64")
+		Expect(errors.Is(err, ErrToolNotFound)).To(BeTrue())
+
+		Expect(registry.Tools()).To(HaveLen(2))
+	})
+
+	It("should not fall back when a higher priority registry failed to load", func() {
+		public := writeIndex("public.yaml", `
+tools:
+  internal-cli: {location: "https://public/internal-cli"}
+`)
+
+		registry := NewRegistry(filepath.Join(dir, "cache"),
+			&Source{Name: "public", URL: public},
+			&Source{Name: "private", URL: filepath.Join(dir, "missing.yaml"), Priority: 10},
+		)
+
+		_, err := registry.Lookup("internal-cli")
+		Expect(errors.Is(err, ErrRegistryUnavailable)).To(BeTrue())
+
+		_, err = registry.Lookup("missing")
+		Expect(errors.Is(err, ErrRegistryUnavailable)).To(BeTrue())
+	})
+
+	It("should ignore the failed registries with a lower priority", func() {
+		private := writeIndex("private.yaml", `
+tools:
+  internal-cli: {location: "https://private/internal-cli"}
+`)
+
+		registry := NewRegistry(filepath.Join(dir, "cache"),
+			&Source{Name: "private", URL: private, Priority: 10},
+			&Source{Name: "public", URL: filepath.Join(dir, "missing.yaml")},
+		)
+
+		found, err := registry.Lookup("internal-cli")
+		Expect(err).To(BeNil())
+		Expect(found.Registry).To(Equal("private"))
+	})
+
+	Context("remote", func() {
+		var (
+			server   *httptest.Server
+			requests int
+			content  string
+		)
+
+		BeforeEach(func() {
+			requests = 0
+			content = `tools: {jq: {location: "https://remote/jq"}}`
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				_, _ = fmt.Fprint(w, content)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should cache the index", func() {
+			source := &Source{Name: "remote", URL: server.URL + "/index.yaml"}
+
+			registry := NewRegistry(filepath.Join(dir, "cache"), source)
+			_, err := registry.Lookup("jq")
+			Expect(err).To(BeNil())
+			Expect(requests).NotTo(BeZero())
+			fetched := requests
+
+			registry = NewRegistry(filepath.Join(dir, "cache"), source)
+			registry.SetOffline(true)
+			_, err = registry.Lookup("jq")
+			Expect(err).To(BeNil())
+			Expect(requests).To(Equal(fetched))
+		})
+
+		It("should update the cache", func() {
+			source := &Source{Name: "remote", URL: server.URL + "/index.yaml"}
+
+			registry := NewRegistry(filepath.Join(dir, "cache"), source)
+			Expect(registry.Update()).To(Succeed())
+
+			content = `tools: {yq: {location: "https://remote/yq"}}`
+			Expect(registry.Update()).To(Succeed())
+
+			_, err := registry.Lookup("yq")
+			Expect(err).To(BeNil())
+		})
+
+		It("should not fetch into a path outside of the cache dir", func() {
+			registry := NewRegistry(filepath.Join(dir, "cache"), &Source{Name: "..", URL: server.URL + "/index.yaml"})
+
+			err := registry.Update()
+			Expect(err).NotTo(BeNil())
+			Expect(requests).To(Equal(0))
+			Expect(dir).To(BeADirectory())
+		})
+
+		It("should not fetch in offline mode", func() {
+			registry := NewRegistry(filepath.Join(dir, "cache"), &Source{Name: "remote", URL: server.URL + "/index.yaml"})
+			registry.SetOffline(true)
+
+			err := registry.Update()
+			Expect(err).NotTo(BeNil())
+			Expect(requests).To(Equal(0))
+		})
+
+		It("should fetch the checksum", func() {
+			digest := strings.Repeat("a", 64)
+			content = fmt.Sprintf("%s  jq-linux-amd64\n", digest)
+
+			registry := NewRegistry(filepath.Join(dir, "cache"))
+			registry.SetHTTPClient(server.Client())
+
+			result, err := registry.Checksum(context.Background(), &Resolution{
+				Location: "https://remote/jq-linux-amd64",
+				Checksum: server.URL + "/SHA256SUMS",
+			})
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(digest))
+
+			result, err = registry.Checksum(context.Background(), &Resolution{Location: "https://remote/jq"})
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(""))
+		})
+	})
+
+	Context("NewRegistryByConfiguration", func() {
+		var cfg core.Configuration
+
+		BeforeEach(func() {
+			cfg = viper.New()
+			cfg.Set(core.CfgKeyCmdrRegistryDir, filepath.Join(dir, "cache"))
+		})
+
+		It("should create registry", func() {
+			path := writeIndex("index.yaml", `tools: {jq: {location: "https://example.com/jq"}}`)
+			cfg.Set(core.CfgKeyRegistries, []map[string]interface{}{
+				{"url": path},
+				{"name": "other", "url": "https://example.com/index.yaml", "priority": 1},
+			})
+
+			registry, err := NewRegistryByConfiguration(cfg)
+			Expect(err).To(BeNil())
+
+			sources := registry.Sources()
+			Expect(sources).To(HaveLen(2))
+			Expect(sources[0].Name).To(Equal("other"))
+			Expect(sources[1].Name).To(Equal("registry-0"))
+		})
+
+		It("should fail without url", func() {
+			cfg.Set(core.CfgKeyRegistries, []map[string]interface{}{{"name": "empty"}})
+
+			_, err := NewRegistryByConfiguration(cfg)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should fail with names which are not a single path element", func() {
+			for _, name := range []string{"..", ".", "a/b", `a\b`} {
+				cfg.Set(core.CfgKeyRegistries, []map[string]interface{}{{"name": name, "url": "/a"}})
+
+				_, err := NewRegistryByConfiguration(cfg)
+				Expect(errors.Is(err, ErrInvalidRegistryName)).To(BeTrue(), name)
+			}
+		})
+
+		It("should fail with duplicated names", func() {
+			cfg.Set(core.CfgKeyRegistries, []map[string]interface{}{
+				{"name": "a", "url": "/a"},
+				{"name": "a", "url": "/b"},
+			})
+
+			_, err := NewRegistryByConfiguration(cfg)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+var ErrVersionDiscoveryNotConfigured = errors.New("version discovery not configured")
+
+// ReleaseLister is implemented by the repositories service of github
+type ReleaseLister interface {
+	ListReleases(ctx context.Context, owner, repo string, opts *github.ListOptions) ([]*github.RepositoryRelease, *github.Response, error)
+}
+
+// ListVersions lists the versions of a tool by the releases of github, the latest comes first
+func ListVersions(ctx context.Context, lister ReleaseLister, discovery *VersionDiscovery) ([]string, error) {
+	if discovery == nil || discovery.GitHub == "" {
+		return nil, ErrVersionDiscoveryNotConfigured
+	}
+
+	owner, repo, found := strings.Cut(discovery.GitHub, "/")
+	if !found {
+		return nil, errors.Errorf("invalid github repository %s", discovery.GitHub)
+	}
+
+	releases, _, err := lister.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, errors.Wrapf(err, "list releases of %s failed", discovery.GitHub)
+	}
+
+	versions := make([]string, 0, len(releases))
+	for _, release := range releases {
+		tag := release.GetTagName()
+		if release.GetDraft() || !strings.HasPrefix(tag, discovery.TagPrefix) {
+			continue
+		}
+
+		versions = append(versions, strings.TrimPrefix(tag, discovery.TagPrefix))
+	}
+
+	sortVersions(versions)
+	return versions, nil
+}
+
+func sortVersions(versions []string) {
+	for i := 1; i < len(versions); i++ {
+		for j := i; j > 0 && versioning.Compare(versions[j-1], versions[j]) < 0; j-- {
+			versions[j-1], versions[j] = versions[j], versions[j-1]
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package registry_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v39/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/mrlyc/cmdr/core/registry"
+)
+
+type fakeReleaseLister struct {
+	owner, repo string
+	tags        []string
+}
+
+func (f *fakeReleaseLister) ListReleases(
+	ctx context.Context, owner, repo string, opts *github.ListOptions,
+) ([]*github.RepositoryRelease, *github.Response, error) {
+	f.owner, f.repo = owner, repo
+
+	releases := make([]*github.RepositoryRelease, 0, len(f.tags))
+	for _, tag := range f.tags {
+		releases = append(releases, &github.RepositoryRelease{TagName: github.String(tag)})
+	}
+
+	return releases, nil, nil
+}
+
+var _ = Describe("Versions", func() {
+	It("should list versions by tags", func() {
+		lister := &fakeReleaseLister{tags: []string{"jq-1.6", "jq-1.7.1", "nightly", "jq-1.7"}}
+
+		versions, err := ListVersions(context.Background(), lister, &VersionDiscovery{GitHub: "jqlang/jq", TagPrefix: "jq-"})
+		Expect(err).To(BeNil())
+		Expect(versions).To(Equal([]string{"1.7.1", "1.7", "1.6"}))
+		Expect(lister.owner).To(Equal("jqlang"))
+		Expect(lister.repo).To(Equal("jq"))
+	})
+
+	It("should fail without discovery", func() {
+		_, err := ListVersions(context.Background(), &fakeReleaseLister{}, nil)
+		Expect(errors.Is(err, ErrVersionDiscoveryNotConfigured)).To(BeTrue())
+	})
+})
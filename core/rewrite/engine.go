@@ -0,0 +1,187 @@
+package rewrite
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+// Step records how a rule handled the uri
+type Step struct {
+	Rule    string `json:"rule"`
+	Input   string `json:"input"`
+	Output  string `json:"output,omitempty"`
+	Matched bool   `json:"matched"`
+	Stop    bool   `json:"stop,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type Trace struct {
+	Input  string  `json:"input"`
+	Output string  `json:"output"`
+	Steps  []*Step `json:"steps"`
+}
+
+// Engine applies the rules in order, the output of a rule is the input of the next one
+// until a matched rule asks to stop
+type Engine struct {
+	rules []*Rule
+}
+
+func (e *Engine) Rules() []*Rule {
+	if e == nil {
+		return nil
+	}
+
+	return e.rules
+}
+
+func (e *Engine) AddRule(rule *Rule) error {
+	if rule.Name == "" {
+		rule.Name = fmt.Sprintf("rule[%d]", len(e.rules))
+	}
+
+	err := rule.Compile()
+	if err != nil {
+		return err
+	}
+
+	e.rules = append(e.rules, rule)
+	return nil
+}
+
+func (e *Engine) Explain(name, version, uri string) *Trace {
+	return e.ExplainFor(name, version, uri, runtime.GOOS, runtime.GOARCH)
+}
+
+// ExplainFor explains the rules for another platform, the templates see the given GOOS and GOARCH
+func (e *Engine) ExplainFor(name, version, uri, goos, goarch string) *Trace {
+	trace := &Trace{Input: uri, Output: uri}
+	vars := NewVariables(name, version, uri)
+	vars.GOOS = goos
+	vars.GOARCH = goarch
+
+	for _, rule := range e.Rules() {
+		step := &Step{Rule: rule.Name, Input: vars.URI}
+		trace.Steps = append(trace.Steps, step)
+
+		group, reason := rule.match(vars)
+		if group == nil {
+			step.Reason = reason
+			continue
+		}
+
+		output, err := rule.render(vars, group)
+		if err != nil {
+			step.Reason = err.Error()
+			continue
+		}
+
+		if output == "" {
+			step.Reason = "empty result"
+			continue
+		}
+
+		step.Matched = true
+		step.Output = output
+		step.Stop = rule.Stop
+		vars.setURI(output)
+
+		if rule.Stop {
+			break
+		}
+	}
+
+	trace.Output = vars.URI
+	return trace
+}
+
+// Rewrite returns the final uri of all rules
+func (e *Engine) Rewrite(name, version, uri string) string {
+	return e.RewriteFor(name, version, uri, runtime.GOOS, runtime.GOARCH)
+}
+
+// RewriteFor returns the final uri of all rules for another platform
+func (e *Engine) RewriteFor(name, version, uri, goos, goarch string) string {
+	logger := core.GetLogger()
+	trace := e.ExplainFor(name, version, uri, goos, goarch)
+
+	for _, step := range trace.Steps {
+		if step.Matched {
+			logger.Debug("uri rewritten", map[string]interface{}{
+				"rule":   step.Rule,
+				"input":  step.Input,
+				"output": step.Output,
+			})
+		}
+	}
+
+	if trace.Output != uri {
+		logger.Info("uri rewritten", map[string]interface{}{
+			"original":  uri,
+			"rewritten": trace.Output,
+		})
+	}
+
+	return trace.Output
+}
+
+func NewEngine(rules ...*Rule) (*Engine, error) {
+	engine := &Engine{}
+	for _, rule := range rules {
+		err := engine.AddRule(rule)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return engine, nil
+}
+
+type legacyReplacement struct {
+	Match    string
+	Template string
+}
+
+// NewEngineByConfiguration creates an engine with download.rules, the legacy download.rewrite
+// and download.replace configurations are converted to rules and appended in order
+func NewEngineByConfiguration(cfg core.Configuration) (*Engine, error) {
+	var rules []*Rule
+	err := cfg.UnmarshalKey(core.CfgKeyDownloadRules, &rules)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", core.CfgKeyDownloadRules)
+	}
+
+	rewriteRule := cfg.GetString(core.CfgKeyDownloadRewriteRule)
+	if rewriteRule != "" {
+		rules = append(rules, &Rule{
+			Name:    "download.rewrite",
+			Schemes: cfg.GetStringSlice(core.CfgKeyDownloadRewriteConditionSchemes),
+			// the hosts and the patterns were both required
+			Hosts:    cfg.GetStringSlice(core.CfgKeyDownloadRewriteConditionHosts),
+			Patterns: cfg.GetStringSlice(core.CfgKeyDownloadRewriteConditionPatterns),
+			Template: rewriteRule,
+		})
+	}
+
+	var replacements []*legacyReplacement
+	err = cfg.UnmarshalKey(core.CfgKeyDownloadReplace, &replacements)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", core.CfgKeyDownloadReplace)
+	}
+
+	// only the first matched replacement was applied
+	for idx, replacement := range replacements {
+		rules = append(rules, &Rule{
+			Name:     fmt.Sprintf("download.replace[%d]", idx),
+			Match:    replacement.Match,
+			Template: replacement.Template,
+			Stop:     true,
+		})
+	}
+
+	return NewEngine(rules...)
+}
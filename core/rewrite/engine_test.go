@@ -0,0 +1,148 @@
+package rewrite_test
+
+import (
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/rewrite"
+)
+
+var _ = Describe("Engine", func() {
+	DescribeTable("legacy replacement", func(match, template, input, output string) {
+		engine, err := rewrite.NewEngine(&rewrite.Rule{
+			Match:    match,
+			Template: template,
+			Stop:     true,
+		})
+		Expect(err).To(BeNil())
+
+		Expect(engine.Rewrite("", "", input)).To(Equal(output))
+	},
+		Entry("concat", "", "hello {{ .input }}", "world", "hello world"),
+		Entry("replace", "hi (.*)", "hello {{ index .group 1 }}", "hi world", "hello world"),
+		Entry("urlencode", "", "{{ .input | urlquery }}", " ", "+"),
+		Entry("nothing", "noting", "fail", "ok", "ok"),
+	)
+
+	DescribeTable("variables", func(template, input, output string) {
+		engine, err := rewrite.NewEngine(&rewrite.Rule{Template: template})
+		Expect(err).To(BeNil())
+
+		Expect(engine.Rewrite("cmdr", "1.0.0", input)).To(Equal(output))
+	},
+		Entry("path", "https://mirror.com{{ .Path }}", "https://github.com/a/b?c=d#e", "https://mirror.com/a/b"),
+		Entry("query", "{{ .Query }}#{{ .Fragment }}", "https://github.com/a/b?c=d#e", "c=d#e"),
+		Entry("host", "{{ .Scheme }}://{{ .Host }}", "git::https://github.com/a/b", "git::https://github.com"),
+		Entry("name", "{{ .Name }}-{{ .Version }}-{{ .GOOS }}-{{ .GOARCH }}", "x",
+			"cmdr-1.0.0-"+runtime.GOOS+"-"+runtime.GOARCH),
+		Entry("empty result", "", "https://github.com", "https://github.com"),
+	)
+
+	It("should chain the rules until stop", func() {
+		engine, err := rewrite.NewEngine(
+			&rewrite.Rule{Name: "mirror", Hosts: []string{"github.com"}, Template: "https://mirror.com{{ .Path }}"},
+			&rewrite.Rule{Name: "skip", Hosts: []string{"gitlab.com"}, Template: "https://gitlab.mirror.com{{ .Path }}"},
+			&rewrite.Rule{Name: "scheme", Schemes: []string{"https"}, Template: "{{ .URI }}?v=1", Stop: true},
+			&rewrite.Rule{Name: "never", Template: "never"},
+		)
+		Expect(err).To(BeNil())
+
+		trace := engine.Explain("", "", "https://api.github.com/file")
+		Expect(trace.Output).To(Equal("https://mirror.com/file?v=1"))
+		Expect(trace.Steps).To(HaveLen(3))
+		Expect(trace.Steps[0].Matched).To(BeTrue())
+		Expect(trace.Steps[1].Matched).To(BeFalse())
+		Expect(trace.Steps[1].Reason).To(Equal("host not matched"))
+		Expect(trace.Steps[2].Input).To(Equal("https://mirror.com/file"))
+		Expect(trace.Steps[2].Stop).To(BeTrue())
+	})
+
+	It("should match os and arch", func() {
+		engine, err := rewrite.NewEngine(
+			&rewrite.Rule{OS: []string{"plan9"}, Template: "plan9"},
+			&rewrite.Rule{OS: []string{runtime.GOOS}, Arch: []string{runtime.GOARCH}, Template: "native"},
+		)
+		Expect(err).To(BeNil())
+
+		Expect(engine.Rewrite("", "", "https://github.com")).To(Equal("native"))
+	})
+
+	It("should rewrite for another platform", func() {
+		engine, err := rewrite.NewEngine(
+			&rewrite.Rule{OS: []string{"plan9"}, Template: "{{ .URI }}/{{ .GOOS }}-{{ .GOARCH }}"},
+		)
+		Expect(err).To(BeNil())
+
+		Expect(engine.RewriteFor("", "", "https://github.com", "plan9", "arm")).To(Equal("https://github.com/plan9-arm"))
+		Expect(engine.RewriteFor("", "", "https://github.com", "linux", "arm")).To(Equal("https://github.com"))
+	})
+
+	It("should reject invalid rule", func() {
+		_, err := rewrite.NewEngine(&rewrite.Rule{Match: "("})
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should do nothing without engine", func() {
+		var engine *rewrite.Engine
+		Expect(engine.Rewrite("", "", "https://github.com")).To(Equal("https://github.com"))
+	})
+
+	Context("configuration", func() {
+		var cfg core.Configuration
+
+		BeforeEach(func() {
+			cfg = viper.New()
+		})
+
+		It("should convert legacy configurations", func() {
+			cfg.Set(core.CfgKeyDownloadRules, []map[string]interface{}{
+				{"name": "first", "hosts": []string{"example.com"}, "template": "https://github.com{{ .Path }}"},
+			})
+			cfg.Set(core.CfgKeyDownloadRewriteRule, "https://mirror.com{{ .Path }}")
+			cfg.Set(core.CfgKeyDownloadRewriteConditionHosts, []string{"github.com"})
+			cfg.Set(core.CfgKeyDownloadReplace, []map[string]interface{}{
+				{"match": "^https://mirror.com/(.*)$", "template": "https://proxy.com/{{ index .group 1 }}"},
+				{"match": "^https://proxy.com/(.*)$", "template": "never"},
+			})
+
+			engine, err := rewrite.NewEngineByConfiguration(cfg)
+			Expect(err).To(BeNil())
+
+			var names []string
+			for _, rule := range engine.Rules() {
+				names = append(names, rule.Name)
+			}
+			Expect(names).To(Equal([]string{"first", "download.rewrite", "download.replace[0]", "download.replace[1]"}))
+			Expect(engine.Rewrite("", "", "https://example.com/file")).To(Equal("https://proxy.com/file"))
+		})
+
+		It("should match legacy rewrite condition", func() {
+			cfg.Set(core.CfgKeyDownloadRewriteRule, "https://mirror.com{{ .Path }}")
+			cfg.Set(core.CfgKeyDownloadRewriteConditionSchemes, []string{"https"})
+
+			engine, err := rewrite.NewEngineByConfiguration(cfg)
+			Expect(err).To(BeNil())
+
+			Expect(engine.Rewrite("", "", "https://github.com/file")).To(Equal("https://mirror.com/file"))
+			Expect(engine.Rewrite("", "", "http://github.com/file")).To(Equal("http://github.com/file"))
+		})
+
+		It("should require both the legacy hosts and patterns", func() {
+			cfg.Set(core.CfgKeyDownloadRewriteRule, "https://mirror.com{{ .Path }}")
+			cfg.Set(core.CfgKeyDownloadRewriteConditionHosts, []string{"github.com"})
+			cfg.Set(core.CfgKeyDownloadRewriteConditionPatterns, []string{"*.github.com"})
+
+			engine, err := rewrite.NewEngineByConfiguration(cfg)
+			Expect(err).To(BeNil())
+
+			Expect(engine.Rewrite("", "", "https://objects.github.com/file")).To(Equal("https://mirror.com/file"))
+			Expect(engine.Rewrite("", "", "https://github.com/file")).To(Equal("https://github.com/file"))
+			Expect(engine.Rewrite("", "", "https://objects.example.com/file")).To(Equal("https://objects.example.com/file"))
+		})
+	})
+})
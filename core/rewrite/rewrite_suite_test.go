@@ -0,0 +1,13 @@
+package rewrite_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRewrite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rewrite Suite")
+}
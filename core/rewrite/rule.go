@@ -0,0 +1,119 @@
+package rewrite
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// Rule rewrites the uri by Template when all of the conditions are matched
+type Rule struct {
+	Name    string   `mapstructure:"name" yaml:"name"`
+	Match   string   `mapstructure:"match" yaml:"match"`
+	Schemes []string `mapstructure:"schemes" yaml:"schemes"`
+	Hosts   []string `mapstructure:"hosts" yaml:"hosts"`
+	// Patterns are globs of the host, they are required besides Hosts
+	Patterns []string `mapstructure:"patterns" yaml:"patterns"`
+	OS       []string `mapstructure:"os" yaml:"os"`
+	Arch     []string `mapstructure:"arch" yaml:"arch"`
+	Template string   `mapstructure:"template" yaml:"template"`
+	Stop     bool     `mapstructure:"stop" yaml:"stop"`
+
+	regex *regexp.Regexp
+	tmpl  *template.Template
+}
+
+func (r *Rule) Compile() error {
+	regex, err := regexp.Compile(r.Match)
+	if err != nil {
+		return errors.Wrapf(err, "compile match of rule %s failed", r.Name)
+	}
+
+	tmpl, err := template.New(r.Name).Parse(r.Template)
+	if err != nil {
+		return errors.Wrapf(err, "parse template of rule %s failed", r.Name)
+	}
+
+	r.regex = regex
+	r.tmpl = tmpl
+
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchPattern(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, host); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+
+		if matched, _ := filepath.Match(h, host); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match returns the regex groups of the uri, or the reason why the rule is not matched
+func (r *Rule) match(vars *Variables) ([]string, string) {
+	if len(r.Schemes) > 0 && !containsFold(r.Schemes, vars.Scheme) {
+		return nil, "scheme not matched"
+	}
+
+	if len(r.Hosts) > 0 && !matchHost(r.Hosts, vars.Host) {
+		return nil, "host not matched"
+	}
+
+	if len(r.Patterns) > 0 && !matchPattern(r.Patterns, vars.Host) {
+		return nil, "host pattern not matched"
+	}
+
+	if len(r.OS) > 0 && !containsFold(r.OS, vars.GOOS) {
+		return nil, "os not matched"
+	}
+
+	if len(r.Arch) > 0 && !containsFold(r.Arch, vars.GOARCH) {
+		return nil, "arch not matched"
+	}
+
+	group := r.regex.FindStringSubmatch(vars.URI)
+	if group == nil {
+		return nil, "regex not matched"
+	}
+
+	return group, ""
+}
+
+func (r *Rule) render(vars *Variables, group []string) (string, error) {
+	var buf bytes.Buffer
+	err := r.tmpl.Execute(&buf, vars.data(group))
+	if err != nil {
+		return "", errors.Wrapf(err, "render template of rule %s failed", r.Name)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,89 @@
+package rewrite
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Variables are shared by the templates of all rules
+type Variables struct {
+	URI      string
+	Scheme   string
+	Host     string
+	Path     string
+	Query    string
+	Fragment string
+	Name     string
+	Version  string
+	GOOS     string
+	GOARCH   string
+}
+
+func (v *Variables) data(group []string) map[string]interface{} {
+	return map[string]interface{}{
+		"URI":      v.URI,
+		"Scheme":   v.Scheme,
+		"Host":     v.Host,
+		"Path":     v.Path,
+		"Query":    v.Query,
+		"Fragment": v.Fragment,
+		"Name":     v.Name,
+		"Version":  v.Version,
+		"GOOS":     v.GOOS,
+		"GOARCH":   v.GOARCH,
+		// compatible with download.replace
+		"input": v.URI,
+		"group": group,
+	}
+}
+
+// setURI splits uri by hand, the go-getter forms like git::https://... are not valid urls
+func (v *Variables) setURI(uri string) {
+	v.URI = uri
+	v.Scheme = ""
+	v.Host = ""
+	v.Path = ""
+	v.Query = ""
+	v.Fragment = ""
+
+	idx := strings.Index(uri, "://")
+	if idx <= 0 {
+		return
+	}
+
+	v.Scheme = uri[:idx]
+	rest := uri[idx+3:]
+
+	idx = strings.IndexAny(rest, "/?#")
+	if idx < 0 {
+		v.Host = rest
+		return
+	}
+
+	v.Host = rest[:idx]
+	rest = rest[idx:]
+
+	if idx = strings.Index(rest, "#"); idx >= 0 {
+		v.Fragment = rest[idx+1:]
+		rest = rest[:idx]
+	}
+
+	if idx = strings.Index(rest, "?"); idx >= 0 {
+		v.Query = rest[idx+1:]
+		rest = rest[:idx]
+	}
+
+	v.Path = rest
+}
+
+func NewVariables(name, version, uri string) *Variables {
+	vars := &Variables{
+		Name:    name,
+		Version: version,
+		GOOS:    runtime.GOOS,
+		GOARCH:  runtime.GOARCH,
+	}
+	vars.setURI(uri)
+
+	return vars
+}
@@ -0,0 +1,96 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// CosignVerifier checks the blob signatures of cosign made by a key pair, like `cosign sign-blob --key`,
+// the keyless signatures need the transparency log so they are not supported
+type CosignVerifier struct {
+	publicKey crypto.PublicKey
+}
+
+// decodeCosignSignature accepts the base64 signatures written by cosign and the raw ones
+func decodeCosignSignature(signature []byte) []byte {
+	decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil {
+		return signature
+	}
+
+	return decoded
+}
+
+func (v *CosignVerifier) Verify(message io.Reader, signature []byte) error {
+	signature = decodeCosignSignature(signature)
+
+	var verified bool
+	switch key := v.publicKey.(type) {
+	case ed25519.PublicKey:
+		data, err := io.ReadAll(message)
+		if err != nil {
+			return errors.Wrapf(err, "read message failed")
+		}
+
+		verified = ed25519.Verify(key, data, signature)
+	case *ecdsa.PublicKey:
+		hash := sha256.New()
+		_, err := io.Copy(hash, message)
+		if err != nil {
+			return errors.Wrapf(err, "read message failed")
+		}
+
+		verified = ecdsa.VerifyASN1(key, hash.Sum(nil), signature)
+	case *rsa.PublicKey:
+		hash := sha256.New()
+		_, err := io.Copy(hash, message)
+		if err != nil {
+			return errors.Wrapf(err, "read message failed")
+		}
+
+		verified = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash.Sum(nil), signature) == nil
+	}
+
+	if !verified {
+		return errors.Wrapf(ErrSignatureMismatch, "cosign")
+	}
+
+	return nil
+}
+
+// NewCosignVerifier parses a pem encoded public key, like cosign.pub
+func NewCosignVerifier(key []byte) (Verifier, error) {
+	block, _ := pem.Decode(key)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.Wrapf(ErrInvalidKey, "malformed cosign key")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidKey, "malformed cosign key: %v", err)
+	}
+
+	switch publicKey.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+	default:
+		return nil, errors.Wrapf(ErrInvalidKey, "unsupported cosign key %T", publicKey)
+	}
+
+	return &CosignVerifier{
+		publicKey: publicKey,
+	}, nil
+}
+
+func init() {
+	registerVerifierFactory(KindCosign, NewCosignVerifier)
+}
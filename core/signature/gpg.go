@@ -0,0 +1,59 @@
+package signature
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/pkg/errors"
+)
+
+var armorPrefix = []byte("-----BEGIN ")
+
+type GPGVerifier struct {
+	keyring openpgp.EntityList
+}
+
+// Verify accepts both the armored signatures, like .asc, and the binary ones
+func (v *GPGVerifier) Verify(message io.Reader, signature []byte) error {
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(signature), armorPrefix) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+
+	signer, err := check(v.keyring, message, bytes.NewReader(signature), nil)
+	if err != nil {
+		return errors.Wrapf(ErrSignatureMismatch, "gpg: %v", err)
+	}
+
+	if signer == nil {
+		return errors.Wrapf(ErrSignatureMismatch, "gpg: unknown signer")
+	}
+
+	return nil
+}
+
+// NewGPGVerifier parses an armored or a binary keyring
+func NewGPGVerifier(key []byte) (Verifier, error) {
+	var (
+		keyring openpgp.EntityList
+		err     error
+	)
+	if bytes.HasPrefix(bytes.TrimSpace(key), armorPrefix) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(key))
+	}
+
+	if err != nil || len(keyring) == 0 {
+		return nil, errors.Wrapf(ErrInvalidKey, "malformed gpg keyring: %v", err)
+	}
+
+	return &GPGVerifier{
+		keyring: keyring,
+	}, nil
+}
+
+func init() {
+	registerVerifierFactory(KindGPG, NewGPGVerifier)
+}
@@ -0,0 +1,118 @@
+package signature
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+// the algorithms of minisign, the message is hashed by blake2b-512 before signed by the prehashed one
+const (
+	minisignAlgorithm          = "Ed"
+	minisignPrehashedAlgorithm = "ED"
+	minisignTrustedPrefix      = "trusted comment: "
+)
+
+type MinisignVerifier struct {
+	keyID     uint64
+	publicKey ed25519.PublicKey
+}
+
+// minisignLines returns the lines which are not untrusted comments
+func minisignLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func (v *MinisignVerifier) Verify(message io.Reader, signature []byte) error {
+	lines := minisignLines(signature)
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], minisignTrustedPrefix) {
+		return errors.Wrapf(ErrInvalidSignature, "malformed minisign signature")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(decoded) != 2+8+ed25519.SignatureSize {
+		return errors.Wrapf(ErrInvalidSignature, "malformed minisign signature")
+	}
+
+	algorithm, signature := string(decoded[:2]), decoded[10:]
+	keyID := binary.LittleEndian.Uint64(decoded[2:10])
+	if keyID != v.keyID {
+		return errors.Wrapf(ErrSignatureMismatch, "signed by key %016X, expected %016X", keyID, v.keyID)
+	}
+
+	var signed []byte
+	switch algorithm {
+	case minisignAlgorithm:
+		signed, err = io.ReadAll(message)
+		if err != nil {
+			return errors.Wrapf(err, "read message failed")
+		}
+	case minisignPrehashedAlgorithm:
+		hash, _ := blake2b.New512(nil)
+		_, err = io.Copy(hash, message)
+		if err != nil {
+			return errors.Wrapf(err, "read message failed")
+		}
+
+		signed = hash.Sum(nil)
+	default:
+		return errors.Wrapf(ErrInvalidSignature, "unknown minisign algorithm %q", algorithm)
+	}
+
+	if !ed25519.Verify(v.publicKey, signed, signature) {
+		return errors.Wrapf(ErrSignatureMismatch, "minisign key %016X", v.keyID)
+	}
+
+	// the global signature covers the trusted comment
+	globalSignature, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(globalSignature) != ed25519.SignatureSize {
+		return errors.Wrapf(ErrInvalidSignature, "malformed minisign global signature")
+	}
+
+	trusted := append(append([]byte{}, signature...), strings.TrimPrefix(lines[1], minisignTrustedPrefix)...)
+	if !ed25519.Verify(v.publicKey, trusted, globalSignature) {
+		return errors.Wrapf(ErrSignatureMismatch, "trusted comment of minisign key %016X", v.keyID)
+	}
+
+	return nil
+}
+
+// NewMinisignVerifier parses a minisign public key, with or without the untrusted comment
+func NewMinisignVerifier(key []byte) (Verifier, error) {
+	lines := minisignLines(key)
+	if len(lines) != 1 {
+		return nil, errors.Wrapf(ErrInvalidKey, "malformed minisign key")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(decoded) != 2+8+ed25519.PublicKeySize || string(decoded[:2]) != minisignAlgorithm {
+		return nil, errors.Wrapf(ErrInvalidKey, "malformed minisign key")
+	}
+
+	return &MinisignVerifier{
+		keyID:     binary.LittleEndian.Uint64(decoded[2:10]),
+		publicKey: ed25519.PublicKey(decoded[10:]),
+	}, nil
+}
+
+func init() {
+	registerVerifierFactory(KindMinisign, NewMinisignVerifier)
+}
@@ -0,0 +1,369 @@
+package signature
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/strategy"
+)
+
+// FlagPolicyName is the name of the policy made by the key of the install flags
+const FlagPolicyName = "flag"
+
+var (
+	ErrNoTrustedKey    = errors.New("no trusted key")
+	ErrInvalidPolicy   = errors.New("invalid signature policy")
+	ErrSignatureAbsent = errors.New("signature not found")
+)
+
+var defaultSuffixes = map[string]string{
+	KindMinisign: ".minisig",
+	KindGPG:      ".sig",
+	KindCosign:   ".sig",
+}
+
+// Policy requires the downloads from the hosts or the registries to be signed by the key,
+// a policy without hosts and registries is required by every download
+type Policy struct {
+	Name string `mapstructure:"name"`
+	// Hosts are globs of the hosts of the locations, like *.hashicorp.com
+	Hosts      []string `mapstructure:"hosts"`
+	Registries []string `mapstructure:"registries"`
+	// Kind is detected by the key when empty
+	Kind string `mapstructure:"kind"`
+	Key  string `mapstructure:"key"`
+	// Suffix is appended to the url of the downloaded file to fetch the signature, like .minisig
+	Suffix string `mapstructure:"suffix"`
+}
+
+// hostOf returns the host of a location, the forced getters like git:: are ignored
+func hostOf(location string) string {
+	if idx := strings.Index(location, "::"); idx >= 0 {
+		location = location[idx+2:]
+	}
+
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Hostname()
+}
+
+func (p *Policy) Match(location, registry string) bool {
+	if len(p.Hosts) == 0 && len(p.Registries) == 0 {
+		return true
+	}
+
+	for _, name := range p.Registries {
+		if registry != "" && name == registry {
+			return true
+		}
+	}
+
+	host := hostOf(location)
+	if host == "" {
+		return false
+	}
+
+	for _, pattern := range p.Hosts {
+		matched, err := path.Match(pattern, host)
+		if err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// signatureOf returns the sibling url of the signature of a downloaded url or a local path
+func (p *Policy) signatureOf(location, kind string) string {
+	suffix := p.Suffix
+	if suffix == "" {
+		suffix = defaultSuffixes[kind]
+	}
+
+	parsed, err := url.Parse(location)
+	if err != nil || parsed.Host == "" {
+		return location + suffix
+	}
+
+	parsed.Path += suffix
+	parsed.RawPath = ""
+
+	return parsed.String()
+}
+
+// Subject is a downloaded file to verify
+type Subject struct {
+	// Location and Registry decide the policies
+	Location string
+	Registry string
+	// URL is where the file is downloaded from, the signature is its sibling
+	URL  string
+	Path string
+}
+
+// Checker verifies the downloads by the matched policies, a signature given explicitly replaces the sibling
+// of the flag policy, or the siblings of all the policies when there is no flag policy
+type Checker struct {
+	policies  []*Policy
+	signature string
+	offline   bool
+	client    *http.Client
+	strategy  *strategy.StrategyChain
+}
+
+func (c *Checker) SetSignature(signature string) {
+	c.signature = signature
+}
+
+func (c *Checker) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+func (c *Checker) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
+// SetStrategyChain makes the checker fetch the signatures like the downloads do, by the strategies
+func (c *Checker) SetStrategyChain(chain *strategy.StrategyChain) {
+	c.strategy = chain
+}
+
+func (c *Checker) Policies() []*Policy {
+	return c.policies
+}
+
+// Match returns the policies which are required by a location
+func (c *Checker) Match(location, registry string) []*Policy {
+	var matched []*Policy
+	for _, policy := range c.policies {
+		if policy.Match(location, registry) {
+			matched = append(matched, policy)
+		}
+	}
+
+	return matched
+}
+
+// fetch reads the signature from a local path or a http url
+func (c *Checker) fetch(location string) ([]byte, error) {
+	parsed, err := url.Parse(location)
+	if err != nil || parsed.Host == "" || parsed.Scheme == "file" {
+		localPath := strings.TrimPrefix(location, "file://")
+		data, err := os.ReadFile(localPath)
+		if os.IsNotExist(err) {
+			return nil, errors.Wrapf(ErrSignatureAbsent, "%s", localPath)
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "read signature %s failed", localPath)
+		}
+
+		return data, nil
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, errors.Wrapf(ErrSignatureAbsent, "unsupported signature url %s", parsed.Redacted())
+	}
+
+	if c.offline {
+		return nil, errors.Wrapf(core.ErrOffline, "fetch signature %s", parsed.Redacted())
+	}
+
+	if c.strategy == nil {
+		return c.get(context.Background(), location)
+	}
+
+	var (
+		data   []byte
+		absent error
+	)
+	err = c.strategy.ExecuteContext(context.Background(), location, func(ctx context.Context, uri string) error {
+		var err error
+		data, err = c.get(ctx, uri)
+		if errors.Is(err, ErrSignatureAbsent) {
+			absent = err
+		}
+
+		return err
+	})
+	if absent != nil && err != nil {
+		return nil, absent
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "fetch signature %s failed", parsed.Redacted())
+	}
+
+	return data, nil
+}
+
+func (c *Checker) get(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create request of %s failed", location)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch signature %s failed", req.URL.Redacted())
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.Wrapf(ErrSignatureAbsent, "%s", req.URL.Redacted())
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetch signature %s failed: bad response code %d", req.URL.Redacted(), resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read signature %s failed", req.URL.Redacted())
+	}
+
+	return data, nil
+}
+
+func (c *Checker) verify(policy *Policy, subject *Subject, explicit string) error {
+	key, err := os.ReadFile(policy.Key)
+	if err != nil {
+		return errors.Wrapf(err, "read key %s failed", policy.Key)
+	}
+
+	kind := policy.Kind
+	if kind == "" {
+		kind, err = DetectKind(key)
+		if err != nil {
+			return errors.WithMessagef(err, "key %s", policy.Key)
+		}
+	}
+
+	verifier, err := NewVerifier(kind, key)
+	if err != nil {
+		return errors.WithMessagef(err, "load key %s failed", policy.Key)
+	}
+
+	location := explicit
+	if location == "" {
+		location = policy.signatureOf(subject.URL, kind)
+	}
+
+	signature, err := c.fetch(location)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(subject.Path)
+	if err != nil {
+		return errors.Wrapf(err, "open %s failed", subject.Path)
+	}
+	defer func() { _ = file.Close() }()
+
+	return verifier.Verify(file, signature)
+}
+
+// Check verifies the subject by every matched policy, then returns them
+func (c *Checker) Check(subject *Subject) ([]*Policy, error) {
+	policies := c.Match(subject.Location, subject.Registry)
+	if len(policies) == 0 {
+		if c.signature != "" {
+			return nil, errors.Wrapf(ErrNoTrustedKey, "signature %s is given", c.signature)
+		}
+
+		return nil, nil
+	}
+
+	// the signature given explicitly is signed by the key given with it
+	flagged := false
+	for _, policy := range policies {
+		flagged = flagged || policy.Name == FlagPolicyName
+	}
+
+	for _, policy := range policies {
+		core.GetLogger().Debug("verifying signature", map[string]interface{}{
+			"policy": policy.Name,
+			"file":   subject.Path,
+		})
+
+		explicit := c.signature
+		if flagged && policy.Name != FlagPolicyName {
+			explicit = ""
+		}
+
+		err := c.verify(policy, subject, explicit)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "signature policy %s", policy.Name)
+		}
+	}
+
+	return policies, nil
+}
+
+func NewChecker(policies ...*Policy) (*Checker, error) {
+	for index, policy := range policies {
+		if policy.Name == "" {
+			policy.Name = fmt.Sprintf("signature-%d", index)
+		}
+
+		if policy.Key == "" {
+			return nil, errors.Wrapf(ErrInvalidPolicy, "key of %s is required", policy.Name)
+		}
+
+		if _, ok := verifierFactories[policy.Kind]; !ok && policy.Kind != "" {
+			return nil, errors.Wrapf(ErrUnknownKind, "%q of %s", policy.Kind, policy.Name)
+		}
+
+		for _, pattern := range policy.Hosts {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, errors.Wrapf(ErrInvalidPolicy, "host pattern %q of %s: %v", pattern, policy.Name, err)
+			}
+		}
+	}
+
+	return &Checker{
+		policies: policies,
+		client:   http.DefaultClient,
+	}, nil
+}
+
+// NewCheckerByConfiguration creates a checker with the configured policies, the key of the install flags
+// is required by every download
+func NewCheckerByConfiguration(cfg core.Configuration) (*Checker, error) {
+	var policies []*Policy
+	err := cfg.UnmarshalKey(core.CfgKeySignatures, &policies)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", core.CfgKeySignatures)
+	}
+
+	key := cfg.GetString(core.CfgKeyXCommandInstallSignatureKey)
+	if key != "" {
+		policies = append(policies, &Policy{
+			Name: FlagPolicyName,
+			Key:  key,
+		})
+	}
+
+	checker, err := NewChecker(policies...)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := strategy.NewStrategyChainByConfiguration(cfg)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "configure download strategies failed")
+	}
+
+	checker.SetSignature(cfg.GetString(core.CfgKeyXCommandInstallSignature))
+	checker.SetOffline(cfg.GetBool(core.CfgKeyCmdrOffline))
+	checker.SetHTTPClient(&http.Client{Transport: strategy.NewTransport(http.DefaultTransport)})
+	checker.SetStrategyChain(chain)
+
+	return checker, nil
+}
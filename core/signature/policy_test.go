@@ -0,0 +1,202 @@
+package signature_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/signature"
+)
+
+var _ = Describe("Policy", func() {
+	var (
+		dir     string
+		key     *minisignKey
+		keyPath string
+		file    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		key = newMinisignKey(0x1234)
+		keyPath = filepath.Join(dir, "minisign.pub")
+		Expect(os.WriteFile(keyPath, key.Public(), 0644)).To(Succeed())
+
+		file = filepath.Join(dir, "tool.tar.gz")
+		Expect(os.WriteFile(file, message, 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should match the hosts and the registries", func() {
+		policy := &signature.Policy{Hosts: []string{"*.hashicorp.com"}, Registries: []string{"official"}}
+
+		Expect(policy.Match("https://releases.hashicorp.com/terraform.zip", "")).To(BeTrue())
+		Expect(policy.Match("git::https://releases.hashicorp.com/terraform.git", "")).To(BeTrue())
+		Expect(policy.Match("https://github.com/hashicorp/terraform", "")).To(BeFalse())
+		Expect(policy.Match("https://github.com/jqlang/jq", "official")).To(BeTrue())
+		Expect(policy.Match("/tmp/terraform", "")).To(BeFalse())
+
+		Expect((&signature.Policy{}).Match("/tmp/terraform", "")).To(BeTrue())
+	})
+
+	Context("Checker", func() {
+		var checker *signature.Checker
+
+		BeforeEach(func() {
+			var err error
+			checker, err = signature.NewChecker(&signature.Policy{Name: "tools", Key: keyPath})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		subject := func() *signature.Subject {
+			return &signature.Subject{Location: file, URL: file, Path: file}
+		}
+
+		It("should verify the sibling signature", func() {
+			Expect(os.WriteFile(file+".minisig", key.Sign(message, true, "file:tool"), 0644)).To(Succeed())
+
+			policies, err := checker.Check(subject())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policies).To(HaveLen(1))
+			Expect(policies[0].Name).To(Equal("tools"))
+		})
+
+		It("should verify the signature given explicitly", func() {
+			sig := filepath.Join(dir, "signature")
+			Expect(os.WriteFile(sig, key.Sign(message, false, "file:tool"), 0644)).To(Succeed())
+			checker.SetSignature(sig)
+
+			Expect(checker.Check(subject())).To(HaveLen(1))
+		})
+
+		It("should give the explicit signature to the flag policy only", func() {
+			flagKey := newMinisignKey(0x5678)
+			flagKeyPath := filepath.Join(dir, "flag.pub")
+			Expect(os.WriteFile(flagKeyPath, flagKey.Public(), 0644)).To(Succeed())
+
+			checker, err := signature.NewChecker(
+				&signature.Policy{Name: "tools", Key: keyPath},
+				&signature.Policy{Name: signature.FlagPolicyName, Key: flagKeyPath},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			sig := filepath.Join(dir, "signature")
+			Expect(os.WriteFile(sig, flagKey.Sign(message, false, "file:tool"), 0644)).To(Succeed())
+			Expect(os.WriteFile(file+".minisig", key.Sign(message, true, "file:tool"), 0644)).To(Succeed())
+			checker.SetSignature(sig)
+
+			Expect(checker.Check(subject())).To(HaveLen(2))
+		})
+
+		It("should fetch the signature by the download strategies", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Host != "releases.invalid" || r.URL.Path != "/tool.tar.gz.minisig" {
+					http.NotFound(w, r)
+					return
+				}
+
+				_, _ = w.Write(key.Sign(message, true, "file:tool"))
+			}))
+			defer server.Close()
+
+			cfg := viper.New()
+			cfg.Set("download.proxy.enabled", true)
+			cfg.Set("download.proxy.type", "http")
+			cfg.Set("download.proxy.address", server.URL)
+			cfg.Set(core.CfgKeyXCommandInstallSignatureKey, keyPath)
+
+			// the host is only reachable by the proxy, which is the test server
+			checker, err := signature.NewCheckerByConfiguration(cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checker.Check(&signature.Subject{URL: "http://releases.invalid/tool.tar.gz", Path: file})).To(HaveLen(1))
+
+			_, err = checker.Check(&signature.Subject{URL: "http://releases.invalid/missing.tar.gz", Path: file})
+			Expect(errors.Is(err, signature.ErrSignatureAbsent)).To(BeTrue())
+		})
+
+		It("should fail when the signature is absent", func() {
+			_, err := checker.Check(subject())
+			Expect(errors.Is(err, signature.ErrSignatureAbsent)).To(BeTrue())
+		})
+
+		It("should fail when the file is modified", func() {
+			Expect(os.WriteFile(file+".minisig", key.Sign(message, true, "file:tool"), 0644)).To(Succeed())
+			Expect(os.WriteFile(file, []byte("evil"), 0644)).To(Succeed())
+
+			_, err := checker.Check(subject())
+			Expect(errors.Is(err, signature.ErrSignatureMismatch)).To(BeTrue())
+		})
+
+		It("should fetch the signature from the sibling url", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/tool.tar.gz.minisig" {
+					http.NotFound(w, r)
+					return
+				}
+
+				_, _ = w.Write(key.Sign(message, true, "file:tool"))
+			}))
+			defer server.Close()
+
+			checker.SetHTTPClient(server.Client())
+			Expect(checker.Check(&signature.Subject{URL: server.URL + "/tool.tar.gz?archive=tgz", Path: file})).To(HaveLen(1))
+
+			checker.SetOffline(true)
+			_, err := checker.Check(&signature.Subject{URL: server.URL + "/tool.tar.gz", Path: file})
+			Expect(errors.Is(err, core.ErrOffline)).To(BeTrue())
+		})
+
+		It("should require a key for the signature given explicitly", func() {
+			checker, err := signature.NewChecker(&signature.Policy{Key: keyPath, Registries: []string{"official"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(checker.Check(subject())).To(BeEmpty())
+
+			checker.SetSignature(file + ".minisig")
+			_, err = checker.Check(subject())
+			Expect(errors.Is(err, signature.ErrNoTrustedKey)).To(BeTrue())
+		})
+	})
+
+	It("should reject the invalid policies", func() {
+		_, err := signature.NewChecker(&signature.Policy{Name: "tools"})
+		Expect(errors.Is(err, signature.ErrInvalidPolicy)).To(BeTrue())
+
+		_, err = signature.NewChecker(&signature.Policy{Key: keyPath, Kind: "ssh"})
+		Expect(errors.Is(err, signature.ErrUnknownKind)).To(BeTrue())
+
+		_, err = signature.NewChecker(&signature.Policy{Key: keyPath, Hosts: []string{"["}})
+		Expect(errors.Is(err, signature.ErrInvalidPolicy)).To(BeTrue())
+	})
+
+	It("should create a checker by configuration", func() {
+		cfg := viper.New()
+		cfg.Set(core.CfgKeySignatures, []map[string]interface{}{
+			{"hosts": []string{"releases.hashicorp.com"}, "kind": signature.KindGPG, "key": "hashicorp.asc"},
+		})
+		cfg.Set(core.CfgKeyXCommandInstallSignatureKey, keyPath)
+
+		checker, err := signature.NewCheckerByConfiguration(cfg)
+		Expect(err).NotTo(HaveOccurred())
+
+		policies := checker.Policies()
+		Expect(policies).To(HaveLen(2))
+		Expect(policies[0].Name).To(Equal("signature-0"))
+		Expect(policies[0].Hosts).To(Equal([]string{"releases.hashicorp.com"}))
+		Expect(policies[1].Name).To(Equal(signature.FlagPolicyName))
+		Expect(checker.Match(file, "")).To(Equal(policies[1:]))
+	})
+})
@@ -0,0 +1,72 @@
+package signature
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// kinds of the signatures, which are verified by local keys
+const (
+	KindMinisign = "minisign"
+	KindGPG      = "gpg"
+	KindCosign   = "cosign"
+)
+
+var (
+	ErrUnknownKind       = errors.New("unknown signature kind")
+	ErrInvalidKey        = errors.New("invalid public key")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrSignatureMismatch = errors.New("signature mismatch")
+)
+
+// Verifier checks a detached signature of a message by a public key
+type Verifier interface {
+	Verify(message io.Reader, signature []byte) error
+}
+
+type verifierFactory func(key []byte) (Verifier, error)
+
+var verifierFactories = map[string]verifierFactory{}
+
+func registerVerifierFactory(kind string, factory verifierFactory) {
+	verifierFactories[kind] = factory
+}
+
+// DetectKind guesses the kind by the format of a public key
+func DetectKind(key []byte) (string, error) {
+	trimmed := bytes.TrimSpace(key)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("untrusted comment:")), bytes.HasPrefix(trimmed, []byte("RW")):
+		return KindMinisign, nil
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----")):
+		return KindGPG, nil
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN PUBLIC KEY-----")):
+		return KindCosign, nil
+	case len(trimmed) > 0 && trimmed[0]&0x80 != 0:
+		// the binary keyrings start with an openpgp packet tag
+		return KindGPG, nil
+	default:
+		return "", errors.Wrapf(ErrUnknownKind, "unrecognized key format")
+	}
+}
+
+// NewVerifier parses the public key of a kind, the kind is detected when empty
+func NewVerifier(kind string, key []byte) (Verifier, error) {
+	if kind == "" {
+		detected, err := DetectKind(key)
+		if err != nil {
+			return nil, err
+		}
+
+		kind = detected
+	}
+
+	factory, ok := verifierFactories[kind]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownKind, "%s", kind)
+	}
+
+	return factory(key)
+}
@@ -0,0 +1,214 @@
+package signature_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/mrlyc/cmdr/core/signature"
+)
+
+var message = []byte("#!/bin/sh\necho cmdr\n")
+
+type minisignKey struct {
+	id      uint64
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+func newMinisignKey(id uint64) *minisignKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	return &minisignKey{id: id, public: public, private: private}
+}
+
+func (k *minisignKey) encode(algorithm string, data []byte) string {
+	buf := append([]byte(algorithm), make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(buf[2:], k.id)
+
+	return base64.StdEncoding.EncodeToString(append(buf, data...))
+}
+
+func (k *minisignKey) Public() []byte {
+	return []byte("untrusted comment: minisign public key\n" + k.encode("Ed", k.public) + "\n")
+}
+
+func (k *minisignKey) Sign(data []byte, prehashed bool, comment string) []byte {
+	algorithm := "Ed"
+	if prehashed {
+		algorithm = "ED"
+		digest := blake2b.Sum512(data)
+		data = digest[:]
+	}
+
+	sig := ed25519.Sign(k.private, data)
+	global := ed25519.Sign(k.private, append(append([]byte{}, sig...), comment...))
+
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		k.encode(algorithm, sig) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func newGPGEntity() *openpgp.Entity {
+	entity, err := openpgp.NewEntity("cmdr", "", "cmdr@example.com", nil)
+	Expect(err).NotTo(HaveOccurred())
+
+	return entity
+}
+
+func gpgPublicKey(entity *openpgp.Entity) []byte {
+	var buf bytes.Buffer
+	writer, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(entity.Serialize(writer)).To(Succeed())
+	Expect(writer.Close()).To(Succeed())
+
+	return buf.Bytes()
+}
+
+func pemPublicKey(key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func cosignSign(key *ecdsa.PrivateKey, data []byte) []byte {
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	Expect(err).NotTo(HaveOccurred())
+
+	return []byte(base64.StdEncoding.EncodeToString(sig))
+}
+
+func newCosignKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	return key
+}
+
+var _ = Describe("Signature", func() {
+	verify := func(kind string, key, data, sig []byte) error {
+		verifier, err := signature.NewVerifier(kind, key)
+		Expect(err).NotTo(HaveOccurred())
+
+		return verifier.Verify(bytes.NewReader(data), sig)
+	}
+
+	Context("minisign", func() {
+		var key *minisignKey
+
+		BeforeEach(func() {
+			key = newMinisignKey(0x1234)
+		})
+
+		It("should verify the signatures", func() {
+			Expect(verify(signature.KindMinisign, key.Public(), message, key.Sign(message, false, "file:cmdr"))).To(Succeed())
+			Expect(verify(signature.KindMinisign, key.Public(), message, key.Sign(message, true, "file:cmdr"))).To(Succeed())
+		})
+
+		It("should reject the modified message", func() {
+			err := verify(signature.KindMinisign, key.Public(), []byte("evil"), key.Sign(message, true, "file:cmdr"))
+			Expect(errors.Is(err, signature.ErrSignatureMismatch)).To(BeTrue())
+		})
+
+		It("should reject the signature of another key", func() {
+			another := newMinisignKey(0x5678)
+			err := verify(signature.KindMinisign, key.Public(), message, another.Sign(message, true, "file:cmdr"))
+			Expect(errors.Is(err, signature.ErrSignatureMismatch)).To(BeTrue())
+
+			another.id = key.id
+			err = verify(signature.KindMinisign, key.Public(), message, another.Sign(message, true, "file:cmdr"))
+			Expect(errors.Is(err, signature.ErrSignatureMismatch)).To(BeTrue())
+		})
+
+		It("should reject the modified trusted comment", func() {
+			sig := bytes.Replace(key.Sign(message, true, "file:cmdr"), []byte("file:cmdr"), []byte("file:evil"), 1)
+			err := verify(signature.KindMinisign, key.Public(), message, sig)
+			Expect(errors.Is(err, signature.ErrSignatureMismatch)).To(BeTrue())
+		})
+
+		It("should reject the malformed signature", func() {
+			err := verify(signature.KindMinisign, key.Public(), message, []byte("RWQ"))
+			Expect(errors.Is(err, signature.ErrInvalidSignature)).To(BeTrue())
+		})
+	})
+
+	Context("gpg", func() {
+		var entity *openpgp.Entity
+
+		BeforeEach(func() {
+			entity = newGPGEntity()
+		})
+
+		It("should verify the armored and the binary signatures", func() {
+			var armored, raw bytes.Buffer
+			Expect(openpgp.ArmoredDetachSign(&armored, entity, bytes.NewReader(message), nil)).To(Succeed())
+			Expect(openpgp.DetachSign(&raw, entity, bytes.NewReader(message), nil)).To(Succeed())
+
+			Expect(verify(signature.KindGPG, gpgPublicKey(entity), message, armored.Bytes())).To(Succeed())
+			Expect(verify(signature.KindGPG, gpgPublicKey(entity), message, raw.Bytes())).To(Succeed())
+		})
+
+		It("should reject the modified message", func() {
+			var sig bytes.Buffer
+			Expect(openpgp.ArmoredDetachSign(&sig, entity, bytes.NewReader(message), nil)).To(Succeed())
+
+			err := verify(signature.KindGPG, gpgPublicKey(entity), []byte("evil"), sig.Bytes())
+			Expect(errors.Is(err, signature.ErrSignatureMismatch)).To(BeTrue())
+		})
+	})
+
+	Context("cosign", func() {
+		It("should verify the ecdsa signatures", func() {
+			key := newCosignKey()
+
+			Expect(verify(signature.KindCosign, pemPublicKey(&key.PublicKey), message, cosignSign(key, message))).To(Succeed())
+
+			err := verify(signature.KindCosign, pemPublicKey(&key.PublicKey), []byte("evil"), cosignSign(key, message))
+			Expect(errors.Is(err, signature.ErrSignatureMismatch)).To(BeTrue())
+		})
+
+		It("should verify the ed25519 signatures", func() {
+			public, private, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+
+			sig := ed25519.Sign(private, message)
+			Expect(verify(signature.KindCosign, pemPublicKey(public), message, sig)).To(Succeed())
+		})
+	})
+
+	DescribeTable("detect the kinds", func(key func() []byte, expected string) {
+		Expect(signature.DetectKind(key())).To(Equal(expected))
+	},
+		Entry("minisign", func() []byte { return newMinisignKey(1).Public() }, signature.KindMinisign),
+		Entry("gpg", func() []byte { return gpgPublicKey(newGPGEntity()) }, signature.KindGPG),
+		Entry("cosign", func() []byte { return pemPublicKey(&newCosignKey().PublicKey) }, signature.KindCosign),
+	)
+
+	It("should reject the unknown keys", func() {
+		_, err := signature.NewVerifier("", []byte("ssh-ed25519 AAAA"))
+		Expect(errors.Is(err, signature.ErrUnknownKind)).To(BeTrue())
+
+		_, err = signature.NewVerifier(signature.KindCosign, newMinisignKey(1).Public())
+		Expect(errors.Is(err, signature.ErrInvalidKey)).To(BeTrue())
+	})
+})
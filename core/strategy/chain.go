@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -10,6 +11,9 @@ import (
 type StrategyChain struct {
 	strategies []DownloadStrategy
 	config     *StrategyConfig
+	raceConfig *RaceConfig
+	prober     Prober
+	offline    bool
 }
 
 func (c *StrategyChain) Strategies() []DownloadStrategy {
@@ -37,15 +41,57 @@ func (c *StrategyChain) GetEnabledStrategies(uri string) []DownloadStrategy {
 	return enabled
 }
 
+// GetActiveStrategies returns the strategies used for uri, in the order they are tried without race mode
+func (c *StrategyChain) GetActiveStrategies(uri string) []DownloadStrategy {
+	enabledStrategies := c.GetEnabledStrategies(uri)
+	if len(enabledStrategies) == 0 {
+		// No strategy enabled, try all strategies (backward compatibility)
+		return c.strategies
+	}
+
+	return enabledStrategies
+}
+
+// SetOffline makes the chain try only the first strategy once, the fetchers are expected
+// to fail fast with core.ErrOffline when they need the network
+func (c *StrategyChain) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+func (c *StrategyChain) IsOffline() bool {
+	return c.offline
+}
+
+func (c *StrategyChain) SetRaceConfig(config *RaceConfig) {
+	c.raceConfig = config
+}
+
+func (c *StrategyChain) SetProber(prober Prober) {
+	c.prober = prober
+}
+
 func (c *StrategyChain) Execute(uri string, downloadFunc func(string) error) error {
+	return c.ExecuteContext(context.Background(), uri, func(ctx context.Context, uri string) error {
+		return downloadFunc(uri)
+	})
+}
+
+// ExecuteContext is like Execute, the context passed to downloadFunc carries the current strategy
+func (c *StrategyChain) ExecuteContext(ctx context.Context, uri string, downloadFunc func(context.Context, string) error) error {
 	logger := core.GetLogger()
 	var lastErr error
 
 	// Get strategies that are enabled for this URI
-	enabledStrategies := c.GetEnabledStrategies(uri)
+	enabledStrategies := c.GetActiveStrategies(uri)
 	if len(enabledStrategies) == 0 {
-		// No strategy enabled, try all strategies (backward compatibility)
-		enabledStrategies = c.strategies
+		return fmt.Errorf("%w: %s", ErrNoStrategyEnabled, uri)
+	}
+
+	if c.offline {
+		// other strategies only differ in the way of accessing the network
+		enabledStrategies = enabledStrategies[:1]
+	} else if c.raceConfig != nil && c.raceConfig.Enabled && len(enabledStrategies) > 1 {
+		enabledStrategies = c.race(ctx, uri, enabledStrategies)
 	}
 
 	for strategyIdx, strategy := range enabledStrategies {
@@ -71,7 +117,7 @@ func (c *StrategyChain) Execute(uri string, downloadFunc func(string) error) err
 		maxRetries := c.getStrategyMaxRetries(strategy)
 
 		for retryCount < maxRetries {
-			err = downloadFunc(preparedURI)
+			err = downloadFunc(WithStrategy(ctx, strategy), preparedURI)
 
 			if err == nil {
 				logger.Info("download succeeded", map[string]interface{}{
@@ -84,6 +130,14 @@ func (c *StrategyChain) Execute(uri string, downloadFunc func(string) error) err
 			retryCount++
 			lastErr = err
 
+			if errors.Is(err, core.ErrOffline) {
+				logger.Error("download failed in offline mode", map[string]interface{}{
+					"strategy": strategyName,
+					"error":    err.Error(),
+				})
+				return err
+			}
+
 			// Check if we should retry with same strategy
 			if retryCount < maxRetries && strategy.ShouldRetry(err) {
 				logger.Warn("download failed, retrying with same strategy", map[string]interface{}{
@@ -133,6 +187,10 @@ func (c *StrategyChain) Execute(uri string, downloadFunc func(string) error) err
 }
 
 func (c *StrategyChain) getStrategyMaxRetries(strategy DownloadStrategy) int {
+	if c.offline {
+		return 1
+	}
+
 	// Default to 3 retries if not configured
 	if c.config != nil && c.config.MaxRetries > 0 {
 		return c.config.MaxRetries
@@ -141,16 +199,62 @@ func (c *StrategyChain) getStrategyMaxRetries(strategy DownloadStrategy) int {
 }
 
 func (c *StrategyChain) Configure(cfg core.Configuration) error {
+	c.offline = cfg.GetBool(core.CfgKeyCmdrOffline)
+
 	for _, strategy := range c.strategies {
 		if err := strategy.Configure(cfg); err != nil {
 			return fmt.Errorf("failed to configure strategy %s: %w", strategy.Name(), err)
 		}
 	}
+
+	if !cfg.GetBool(core.CfgKeyDownloadRaceEnabled) {
+		c.raceConfig = nil
+		return nil
+	}
+
+	raceConfig := &RaceConfig{
+		Enabled:     true,
+		Concurrency: cfg.GetInt(core.CfgKeyDownloadRaceConcurrency),
+		ProbeBytes:  cfg.GetInt64(core.CfgKeyDownloadRaceProbeBytes),
+		Timeout:     cfg.GetInt(core.CfgKeyDownloadRaceTimeout),
+	}
+
+	if raceConfig.Concurrency == 0 {
+		raceConfig.Concurrency = 2
+	}
+	if raceConfig.ProbeBytes == 0 {
+		raceConfig.ProbeBytes = 256 * 1024 // limit the wasted bandwidth of each probe
+	}
+	if raceConfig.Timeout == 0 {
+		raceConfig.Timeout = 10
+	}
+
+	if err := raceConfig.Validate(); err != nil {
+		return fmt.Errorf("failed to configure race mode: %w", err)
+	}
+
+	c.raceConfig = raceConfig
 	return nil
 }
 
 func NewStrategyChain(strategies ...DownloadStrategy) *StrategyChain {
 	return &StrategyChain{
 		strategies: strategies,
+		prober:     probeByRange,
 	}
 }
+
+// NewStrategyChainByConfiguration creates the default strategy chain configured by cfg
+func NewStrategyChainByConfiguration(cfg core.Configuration) (*StrategyChain, error) {
+	chain := NewStrategyChain(
+		NewDirectStrategy(),
+		NewProxyStrategy(),
+	)
+
+	err := chain.Configure(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
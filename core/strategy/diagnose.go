@@ -0,0 +1,282 @@
+package strategy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ProbeDNS  = "dns"
+	ProbeTCP  = "tcp"
+	ProbeTLS  = "tls"
+	ProbeHEAD = "head"
+)
+
+const (
+	ErrorClassDNS                = "dns"
+	ErrorClassTimeout            = "timeout"
+	ErrorClassConnectionRefused  = "connection_refused"
+	ErrorClassConnectionReset    = "connection_reset"
+	ErrorClassNetworkUnreachable = "network_unreachable"
+	ErrorClassTLS                = "tls"
+	ErrorClassProxy              = "proxy"
+	ErrorClassHTTP               = "http"
+	ErrorClassUnknown            = "unknown"
+)
+
+var (
+	ErrBadStatusCode = errors.New("bad status code")
+)
+
+type ProbeResult struct {
+	Probe    string        `json:"probe"`
+	Target   string        `json:"target"`
+	Duration time.Duration `json:"duration"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Class    string        `json:"class,omitempty"`
+}
+
+type StrategyDiagnosis struct {
+	Strategy    string         `json:"strategy"`
+	Enabled     bool           `json:"enabled"`
+	Active      bool           `json:"active"`
+	PreparedURI string         `json:"prepared_uri,omitempty"`
+	Proxy       string         `json:"proxy,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Probes      []*ProbeResult `json:"probes,omitempty"`
+}
+
+// ClassifyError maps a download error to a short class name, so the users can tell
+// network problems from server problems at a glance
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	errMsg := err.Error()
+	var (
+		dnsErr     *net.DNSError
+		netErr     net.Error
+		certErr    *tls.CertificateVerificationError
+		unknownErr x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+	)
+
+	switch {
+	case strings.Contains(errMsg, "proxyconnect"):
+		return ErrorClassProxy
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.As(err, &certErr), errors.As(err, &unknownErr), errors.As(err, &hostErr),
+		strings.Contains(errMsg, "tls:"), strings.Contains(errMsg, "x509:"):
+		return ErrorClassTLS
+	case errors.Is(err, ErrBadStatusCode):
+		return ErrorClassHTTP
+	case errors.As(err, &netErr) && netErr.Timeout(), isTimeoutError(err):
+		return ErrorClassTimeout
+	case strings.Contains(errMsg, "connection refused"):
+		return ErrorClassConnectionRefused
+	case strings.Contains(errMsg, "connection reset"):
+		return ErrorClassConnectionReset
+	case strings.Contains(errMsg, "network is unreachable"):
+		return ErrorClassNetworkUnreachable
+	case strings.Contains(errMsg, "no such host"):
+		return ErrorClassDNS
+	}
+
+	return ErrorClassUnknown
+}
+
+// Diagnoser runs the network probes of each strategy of a chain
+type Diagnoser struct {
+	chain    *StrategyChain
+	timeout  time.Duration
+	resolver *net.Resolver
+}
+
+func (d *Diagnoser) probe(ctx context.Context, name, target string, fn func(ctx context.Context) (string, error)) *ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	detail, err := fn(ctx)
+	result := &ProbeResult{
+		Probe:    name,
+		Target:   target,
+		Duration: time.Since(startedAt),
+		Detail:   detail,
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		result.Class = ClassifyError(err)
+	}
+
+	return result
+}
+
+func (d *Diagnoser) skip(name, target, reason string) *ProbeResult {
+	return &ProbeResult{
+		Probe:   name,
+		Target:  target,
+		Skipped: true,
+		Detail:  reason,
+	}
+}
+
+// proxyOf returns the proxy used by transport for request, nil means a direct connection
+func (d *Diagnoser) proxyOf(transport http.RoundTripper, request *http.Request) (*url.URL, error) {
+	httpTransport, ok := transport.(*http.Transport)
+	if !ok || httpTransport.Proxy == nil {
+		return nil, nil
+	}
+
+	return httpTransport.Proxy(request)
+}
+
+func (d *Diagnoser) runProbes(ctx context.Context, strategy DownloadStrategy, uri string, diagnosis *StrategyDiagnosis) {
+	parsed, err := url.Parse(uri)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		diagnosis.Probes = append(diagnosis.Probes, d.skip(ProbeHEAD, uri, "only http and https are probed"))
+		return
+	}
+
+	transport := GetTransport(strategy, http.DefaultTransport)
+	request, err := http.NewRequest(http.MethodHead, uri, nil)
+	if err != nil {
+		diagnosis.Error = err.Error()
+		return
+	}
+
+	proxyURL, err := d.proxyOf(transport, request)
+	if err != nil {
+		diagnosis.Error = err.Error()
+		return
+	}
+
+	// the connection goes to the proxy when there is one
+	address := canonicalAddress(parsed)
+	if proxyURL != nil {
+		diagnosis.Proxy = proxyURL.Redacted()
+		address = canonicalAddress(proxyURL)
+	}
+
+	host, _, _ := net.SplitHostPort(address)
+	dnsResult := d.probe(ctx, ProbeDNS, host, func(ctx context.Context) (string, error) {
+		addrs, err := d.resolver.LookupHost(ctx, host)
+		return strings.Join(addrs, ","), err
+	})
+	diagnosis.Probes = append(diagnosis.Probes, dnsResult)
+
+	tcpResult := d.probe(ctx, ProbeTCP, address, func(ctx context.Context) (string, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = conn.Close() }()
+
+		return conn.RemoteAddr().String(), nil
+	})
+	diagnosis.Probes = append(diagnosis.Probes, tcpResult)
+
+	switch {
+	case parsed.Scheme != "https":
+		diagnosis.Probes = append(diagnosis.Probes, d.skip(ProbeTLS, address, "not a https uri"))
+	case proxyURL != nil:
+		diagnosis.Probes = append(diagnosis.Probes, d.skip(ProbeTLS, address, "tunneled by proxy, see head probe"))
+	default:
+		diagnosis.Probes = append(diagnosis.Probes, d.probe(ctx, ProbeTLS, address, func(ctx context.Context) (string, error) {
+			dialer := &tls.Dialer{Config: &tls.Config{ServerName: parsed.Hostname()}}
+			conn, err := dialer.DialContext(ctx, "tcp", address)
+			if err != nil {
+				return "", err
+			}
+			defer func() { _ = conn.Close() }()
+
+			return tls.VersionName(conn.(*tls.Conn).ConnectionState().Version), nil
+		}))
+	}
+
+	diagnosis.Probes = append(diagnosis.Probes, d.probe(ctx, ProbeHEAD, uri, func(ctx context.Context) (string, error) {
+		client := &http.Client{Transport: transport}
+		response, err := client.Do(request.WithContext(ctx))
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = response.Body.Close() }()
+
+		if response.StatusCode >= http.StatusBadRequest {
+			return response.Status, fmt.Errorf("%w: %s", ErrBadStatusCode, response.Status)
+		}
+
+		return response.Status, nil
+	}))
+}
+
+// Diagnose reports how each strategy handles uri and probes the resulting uri
+func (d *Diagnoser) Diagnose(ctx context.Context, uri string) []*StrategyDiagnosis {
+	active := make(map[DownloadStrategy]bool)
+	for _, strategy := range d.chain.GetActiveStrategies(uri) {
+		active[strategy] = true
+	}
+
+	results := make([]*StrategyDiagnosis, 0, len(d.chain.Strategies()))
+	for _, strategy := range d.chain.Strategies() {
+		diagnosis := &StrategyDiagnosis{
+			Strategy: strategy.Name(),
+			Enabled:  strategy.IsEnabled(uri),
+			Active:   active[strategy],
+		}
+		results = append(results, diagnosis)
+
+		preparedURI, err := strategy.Prepare(uri)
+		if err != nil {
+			diagnosis.Error = err.Error()
+			continue
+		}
+
+		diagnosis.PreparedURI = preparedURI
+		if diagnosis.Active && d.chain.IsOffline() {
+			diagnosis.Probes = append(diagnosis.Probes, d.skip(ProbeHEAD, preparedURI, "offline mode"))
+		} else if diagnosis.Active {
+			d.runProbes(ctx, strategy, preparedURI, diagnosis)
+		}
+	}
+
+	return results
+}
+
+func canonicalAddress(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "socks5":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+func NewDiagnoser(chain *StrategyChain, timeout time.Duration) *Diagnoser {
+	return &Diagnoser{
+		chain:    chain,
+		timeout:  timeout,
+		resolver: net.DefaultResolver,
+	}
+}
@@ -0,0 +1,125 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+var _ = Describe("Diagnoser", func() {
+	var (
+		cfg       core.Configuration
+		server    *httptest.Server
+		status    int
+		diagnoser *Diagnoser
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		cfg = viper.New()
+	})
+
+	JustBeforeEach(func() {
+		chain, err := NewStrategyChainByConfiguration(cfg)
+		Expect(err).To(BeNil())
+		diagnoser = NewDiagnoser(chain, 5*time.Second)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	probesOf := func(diagnosis *StrategyDiagnosis) map[string]*ProbeResult {
+		probes := make(map[string]*ProbeResult)
+		for _, probe := range diagnosis.Probes {
+			probes[probe.Probe] = probe
+		}
+		return probes
+	}
+
+	It("should probe the active strategies", func() {
+		results := diagnoser.Diagnose(context.Background(), server.URL+"/cmdr")
+		Expect(results).To(HaveLen(2))
+
+		direct := results[0]
+		Expect(direct.Strategy).To(Equal("direct"))
+		Expect(direct.Enabled).To(BeTrue())
+		Expect(direct.Active).To(BeTrue())
+		Expect(direct.PreparedURI).To(Equal(server.URL + "/cmdr"))
+
+		probes := probesOf(direct)
+		Expect(probes[ProbeDNS].Error).To(BeEmpty())
+		Expect(probes[ProbeTCP].Error).To(BeEmpty())
+		Expect(probes[ProbeTLS].Skipped).To(BeTrue())
+		Expect(probes[ProbeHEAD].Detail).To(Equal("200 OK"))
+
+		for _, result := range results[1:] {
+			Expect(result.Active).To(BeFalse())
+			Expect(result.Probes).To(BeEmpty())
+		}
+	})
+
+	It("should classify bad status code", func() {
+		status = http.StatusNotFound
+
+		results := diagnoser.Diagnose(context.Background(), server.URL+"/cmdr")
+		head := probesOf(results[0])[ProbeHEAD]
+		Expect(head.Class).To(Equal(ErrorClassHTTP))
+	})
+
+	Context("proxy", func() {
+		BeforeEach(func() {
+			cfg.Set(core.CfgKeyDownloadProxyEnabled, true)
+			cfg.Set(core.CfgKeyDownloadProxyType, "http")
+			cfg.Set(core.CfgKeyDownloadProxyAddress, server.URL)
+		})
+
+		It("should connect to the proxy", func() {
+			results := diagnoser.Diagnose(context.Background(), "https://example.com/cmdr")
+			proxy := results[1]
+
+			Expect(proxy.Active).To(BeTrue())
+			Expect(proxy.Proxy).To(Equal(server.URL))
+			Expect(probesOf(proxy)[ProbeTCP].Target).To(Equal(server.Listener.Addr().String()))
+			Expect(probesOf(proxy)[ProbeTLS].Skipped).To(BeTrue())
+		})
+	})
+
+	It("should skip non http uri", func() {
+		results := diagnoser.Diagnose(context.Background(), "git::https://example.com/repo.git")
+
+		Expect(results[0].Probes).To(HaveLen(1))
+		Expect(results[0].Probes[0].Skipped).To(BeTrue())
+	})
+})
+
+var _ = Describe("ClassifyError", func() {
+	DescribeTable("classes", func(err error, class string) {
+		Expect(ClassifyError(err)).To(Equal(class))
+	},
+		Entry("nil", nil, ""),
+		Entry("dns", &net.DNSError{Err: "no such host", Name: "example.com"}, ErrorClassDNS),
+		Entry("timeout", fmt.Errorf("dial tcp: i/o timeout"), ErrorClassTimeout),
+		Entry("refused", fmt.Errorf("dial tcp 127.0.0.1:1: connect: connection refused"), ErrorClassConnectionRefused),
+		Entry("reset", fmt.Errorf("read: connection reset by peer"), ErrorClassConnectionReset),
+		Entry("unreachable", fmt.Errorf("connect: network is unreachable"), ErrorClassNetworkUnreachable),
+		Entry("tls", fmt.Errorf("tls: handshake failure"), ErrorClassTLS),
+		Entry("proxy", fmt.Errorf("proxyconnect tcp: connection refused"), ErrorClassProxy),
+		Entry("http", fmt.Errorf("%w: 404 Not Found", ErrBadStatusCode), ErrorClassHTTP),
+		Entry("unknown", fmt.Errorf("boom"), ErrorClassUnknown),
+	)
+})
@@ -2,6 +2,7 @@ package strategy
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 
 	"github.com/hashicorp/go-getter"
@@ -111,6 +112,17 @@ func (s *ProxyStrategy) GetOptions() []getter.ClientOption {
 	return nil
 }
 
+func (s *ProxyStrategy) Transport() http.RoundTripper {
+	if s.proxyURL == nil {
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(s.proxyURL)
+
+	return transport
+}
+
 func (s *ProxyStrategy) IsEnabled(uri string) bool {
 	if s.config == nil {
 		return s.enabled
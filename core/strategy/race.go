@@ -0,0 +1,143 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mrlyc/cmdr/core"
+)
+
+type RaceConfig struct {
+	Enabled     bool
+	Concurrency int
+	ProbeBytes  int64
+	Timeout     int
+}
+
+func (c *RaceConfig) Validate() error {
+	if c.Concurrency < 2 {
+		return fmt.Errorf("invalid race concurrency: %d", c.Concurrency)
+	}
+	if c.ProbeBytes <= 0 {
+		return fmt.Errorf("invalid race probe bytes: %d", c.ProbeBytes)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("invalid race timeout: %d", c.Timeout)
+	}
+	return nil
+}
+
+// Prober downloads at most limit bytes of uri with strategy and returns the number of bytes read
+type Prober func(ctx context.Context, strategy DownloadStrategy, uri string, limit int64) (int64, error)
+
+func probeByRange(ctx context.Context, strategy DownloadStrategy, uri string, limit int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", limit-1))
+
+	client := &http.Client{Transport: GetTransport(strategy, http.DefaultTransport)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("bad response code: %d", resp.StatusCode)
+	}
+
+	// the server may ignore the range, never read more than the limit
+	return io.Copy(io.Discard, io.LimitReader(resp.Body, limit))
+}
+
+type raceResult struct {
+	index      int
+	throughput float64
+	err        error
+}
+
+func isRaceable(uri string) bool {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+
+	return parsed.Scheme == "http" || parsed.Scheme == "https"
+}
+
+// race probes the first strategies concurrently and moves the fastest one to the front,
+// the remaining probes are cancelled as soon as the first one finished
+func (c *StrategyChain) race(ctx context.Context, uri string, strategies []DownloadStrategy) []DownloadStrategy {
+	logger := core.GetLogger()
+	count := c.raceConfig.Concurrency
+	if count > len(strategies) {
+		count = len(strategies)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.raceConfig.Timeout)*time.Second)
+	defer cancel()
+
+	results := make(chan raceResult, count)
+	started := 0
+	for idx, strategy := range strategies[:count] {
+		preparedURI, err := strategy.Prepare(uri)
+		if err != nil || !isRaceable(preparedURI) {
+			continue
+		}
+
+		started++
+		go func(idx int, strategy DownloadStrategy, preparedURI string) {
+			startedAt := time.Now()
+			size, err := c.prober(WithStrategy(ctx, strategy), strategy, preparedURI, c.raceConfig.ProbeBytes)
+			elapsed := time.Since(startedAt).Seconds()
+			if err == nil && size == 0 {
+				err = fmt.Errorf("nothing received")
+			}
+
+			result := raceResult{index: idx, err: err}
+			if err == nil {
+				result.throughput = float64(size) / elapsed
+			}
+
+			results <- result
+		}(idx, strategy, preparedURI)
+	}
+
+	winner := -1
+	for i := 0; i < started; i++ {
+		result := <-results
+		strategyName := strategies[result.index].Name()
+
+		if result.err != nil {
+			logger.Debug("strategy probe failed", map[string]interface{}{
+				"strategy": strategyName,
+				"error":    result.err.Error(),
+			})
+			continue
+		}
+
+		winner = result.index
+		logger.Info("strategy won the race", map[string]interface{}{
+			"strategy":   strategyName,
+			"throughput": fmt.Sprintf("%.0fB/s", result.throughput),
+		})
+		break
+	}
+
+	if winner <= 0 {
+		return strategies
+	}
+
+	reordered := make([]DownloadStrategy, 0, len(strategies))
+	reordered = append(reordered, strategies[winner])
+	reordered = append(reordered, strategies[:winner]...)
+	reordered = append(reordered, strategies[winner+1:]...)
+
+	return reordered
+}
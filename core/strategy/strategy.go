@@ -12,6 +12,7 @@ import (
 
 var (
 	ErrAllStrategiesFailed = errors.New("all download strategies failed")
+	ErrNoStrategyEnabled   = errors.New("no download strategy enabled")
 )
 
 type DownloadStrategy interface {
@@ -51,7 +52,6 @@ type StrategyConfig struct {
 	EnableProxy bool
 	ProxyType   string // "http" or "socks5"
 	ProxyAddr   string
-	RewriteRule string
 	Condition   *StrategyCondition
 }
 
@@ -1,7 +1,12 @@
 package strategy
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -153,70 +158,6 @@ var _ = Describe("ProxyStrategy", func() {
 	})
 })
 
-var _ = Describe("RewriteStrategy", func() {
-	var (
-		cfg      core.Configuration
-		strategy *RewriteStrategy
-	)
-
-	BeforeEach(func() {
-		cfg = viper.New()
-		strategy = NewRewriteStrategy()
-	})
-
-	It("should have correct name", func() {
-		Expect(strategy.Name()).To(Equal("rewrite"))
-	})
-
-	It("should not modify URI when not configured", func() {
-		uri := "https://github.com/user/repo/archive/v1.0.tar.gz"
-		result, err := strategy.Prepare(uri)
-		Expect(err).To(BeNil())
-		Expect(result).To(Equal(uri))
-	})
-
-	It("should be disabled by default", func() {
-		err := strategy.Configure(cfg)
-		Expect(err).To(BeNil())
-		Expect(strategy.IsEnabled("https://example.com/file")).To(BeFalse())
-	})
-
-	It("should rewrite URI with template", func() {
-		cfg.Set("download.rewrite.rule", "https://mirror.example.com/{{.Path}}")
-
-		err := strategy.Configure(cfg)
-		Expect(err).To(BeNil())
-
-		uri := "https://github.com/user/repo/archive/v1.0.tar.gz"
-		result, err := strategy.Prepare(uri)
-		Expect(err).To(BeNil())
-		Expect(result).To(ContainSubstring("mirror.example.com"))
-		Expect(strategy.IsEnabled(uri)).To(BeTrue())
-	})
-
-	It("should be enabled only for configured schemes", func() {
-		cfg.Set("download.rewrite.rule", "https://mirror.com{{.Path}}")
-		cfg.Set("download.rewrite.condition.schemes", []string{"https"})
-
-		err := strategy.Configure(cfg)
-		Expect(err).To(BeNil())
-
-		Expect(strategy.IsEnabled("https://github.com/file")).To(BeTrue())
-		Expect(strategy.IsEnabled("http://github.com/file")).To(BeFalse())
-	})
-
-	It("should be enabled only for configured hosts", func() {
-		cfg.Set("download.rewrite.rule", "https://mirror.com{{.Path}}")
-		cfg.Set("download.rewrite.condition.hosts", []string{"github.com"})
-
-		err := strategy.Configure(cfg)
-		Expect(err).To(BeNil())
-
-		Expect(strategy.IsEnabled("https://github.com/file")).To(BeTrue())
-		Expect(strategy.IsEnabled("https://gitlab.com/file")).To(BeFalse())
-	})
-})
-
 var _ = Describe("StrategyChain", func() {
 	It("should execute strategies in order", func() {
 		executedOrder := []string{}
@@ -279,3 +220,161 @@ var _ = Describe("StrategyChain", func() {
 		Expect(enabledCount).To(Equal(1))
 	})
 })
+
+var _ = Describe("StrategyChain offline", func() {
+	var (
+		cfg   core.Configuration
+		chain *StrategyChain
+	)
+
+	BeforeEach(func() {
+		cfg = viper.New()
+		cfg.Set(core.CfgKeyCmdrOffline, true)
+		cfg.Set("download.proxy.enabled", true)
+		cfg.Set("download.proxy.address", "http://proxy:8080")
+
+		var err error
+		chain, err = NewStrategyChainByConfiguration(cfg)
+		Expect(err).To(BeNil())
+	})
+
+	It("should try the first strategy only once", func() {
+		attemptCount := 0
+
+		err := chain.Execute("https://example.com/file", func(uri string) error {
+			attemptCount++
+			return ErrNetworkError
+		})
+
+		Expect(err).NotTo(BeNil())
+		Expect(attemptCount).To(Equal(1))
+	})
+
+	It("should fail fast with offline error", func() {
+		err := chain.Execute("https://example.com/file", func(uri string) error {
+			return fmt.Errorf("%w: testing", core.ErrOffline)
+		})
+
+		Expect(errors.Is(err, core.ErrOffline)).To(BeTrue())
+		Expect(errors.Is(err, ErrAllStrategiesFailed)).To(BeFalse())
+	})
+
+	It("should fail without enabled strategies", func() {
+		chain = NewStrategyChain()
+		Expect(chain.Configure(cfg)).To(Succeed())
+
+		called := false
+		err := chain.Execute("https://example.com/file", func(uri string) error {
+			called = true
+			return nil
+		})
+
+		Expect(errors.Is(err, ErrNoStrategyEnabled)).To(BeTrue())
+		Expect(called).To(BeFalse())
+	})
+})
+
+var _ = Describe("StrategyChain race", func() {
+	var (
+		cfg    core.Configuration
+		direct *DirectStrategy
+		proxy  *ProxyStrategy
+		chain  *StrategyChain
+		delays map[string]time.Duration
+	)
+
+	BeforeEach(func() {
+		cfg = viper.New()
+		cfg.Set("download.proxy.enabled", true)
+		cfg.Set("download.proxy.type", "http")
+		cfg.Set("download.proxy.address", "http://proxy:8080")
+		cfg.Set(core.CfgKeyDownloadRaceEnabled, true)
+
+		direct = NewDirectStrategy()
+		proxy = NewProxyStrategy()
+		chain = NewStrategyChain(direct, proxy)
+		Expect(chain.Configure(cfg)).To(Succeed())
+
+		delays = map[string]time.Duration{}
+		chain.SetProber(func(ctx context.Context, strategy DownloadStrategy, uri string, limit int64) (int64, error) {
+			select {
+			case <-time.After(delays[strategy.Name()]):
+				return limit, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		})
+	})
+
+	execute := func(uri string) []string {
+		var used []string
+		err := chain.ExecuteContext(context.Background(), uri, func(ctx context.Context, uri string) error {
+			strategy, ok := StrategyFromContext(ctx)
+			Expect(ok).To(BeTrue())
+			used = append(used, strategy.Name())
+			return nil
+		})
+		Expect(err).To(BeNil())
+
+		return used
+	}
+
+	It("should use default race config", func() {
+		Expect(chain.raceConfig.Concurrency).To(Equal(2))
+		Expect(chain.raceConfig.ProbeBytes).To(Equal(int64(256 * 1024)))
+	})
+
+	It("should prefer the fastest strategy", func() {
+		delays["direct"] = time.Second
+		delays["proxy"] = 0
+
+		Expect(execute("https://example.com/file")).To(Equal([]string{"proxy"}))
+	})
+
+	It("should keep the order when the first strategy is the fastest", func() {
+		delays["direct"] = 0
+		delays["proxy"] = time.Second
+
+		Expect(execute("https://example.com/file")).To(Equal([]string{"direct"}))
+	})
+
+	It("should keep the order when all probes failed", func() {
+		chain.SetProber(func(ctx context.Context, strategy DownloadStrategy, uri string, limit int64) (int64, error) {
+			return 0, ErrNetworkError
+		})
+
+		Expect(execute("https://example.com/file")).To(Equal([]string{"direct"}))
+	})
+
+	It("should not race for non http uri", func() {
+		delays["direct"] = time.Second
+
+		Expect(execute("git::https://example.com/repo.git")).To(Equal([]string{"direct"}))
+	})
+
+	It("should reject invalid race config", func() {
+		cfg.Set(core.CfgKeyDownloadRaceConcurrency, 1)
+		Expect(chain.Configure(cfg)).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("Transport", func() {
+	It("should use the transport of the strategy in context", func() {
+		cfg := viper.New()
+		cfg.Set("download.proxy.enabled", true)
+		cfg.Set("download.proxy.type", "http")
+		cfg.Set("download.proxy.address", "http://proxy:8080")
+		proxy := NewProxyStrategy()
+		Expect(proxy.Configure(cfg)).To(Succeed())
+
+		transport := GetTransport(proxy, http.DefaultTransport).(*http.Transport)
+		req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+		Expect(err).To(BeNil())
+
+		proxyURL, err := transport.Proxy(req)
+		Expect(err).To(BeNil())
+		Expect(proxyURL.Host).To(Equal("proxy:8080"))
+
+		Expect(GetTransport(NewDirectStrategy(), http.DefaultTransport)).To(Equal(http.DefaultTransport))
+	})
+})
@@ -0,0 +1,55 @@
+package strategy
+
+import (
+	"context"
+	"net/http"
+)
+
+type strategyContextKey struct{}
+
+// TransportProvider is implemented by strategies which route HTTP requests differently from the default transport
+type TransportProvider interface {
+	Transport() http.RoundTripper
+}
+
+func WithStrategy(ctx context.Context, strategy DownloadStrategy) context.Context {
+	return context.WithValue(ctx, strategyContextKey{}, strategy)
+}
+
+func StrategyFromContext(ctx context.Context) (DownloadStrategy, bool) {
+	strategy, ok := ctx.Value(strategyContextKey{}).(DownloadStrategy)
+	return strategy, ok
+}
+
+// GetTransport returns the transport of strategy, or base when strategy does not provide one
+func GetTransport(strategy DownloadStrategy, base http.RoundTripper) http.RoundTripper {
+	provider, ok := strategy.(TransportProvider)
+	if !ok {
+		return base
+	}
+
+	transport := provider.Transport()
+	if transport == nil {
+		return base
+	}
+
+	return transport
+}
+
+// Transport dispatches requests to the transport of the strategy carried by the request context
+type Transport struct {
+	base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	strategy, ok := StrategyFromContext(req.Context())
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	return GetTransport(strategy, t.base).RoundTrip(req)
+}
+
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{base: base}
+}
@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrAssetNotMatched = errors.New("no asset matches the platform")
+)
+
+const (
+	LibcGNU  = "gnu"
+	LibcMusl = "musl"
+)
+
+// AssetPlatform is the platform which assets are matched for, Libc is only used on linux
+type AssetPlatform struct {
+	OS   string
+	Arch string
+	Libc string
+}
+
+func (p AssetPlatform) String() string {
+	if p.Libc == "" {
+		return fmt.Sprintf("%s/%s", p.OS, p.Arch)
+	}
+
+	return fmt.Sprintf("%s/%s-%s", p.OS, p.Arch, p.Libc)
+}
+
+var muslLoaderPatterns = []string{"/lib/ld-musl-*.so.1", "/lib64/ld-musl-*.so.1", "/usr/lib/ld-musl-*.so.1"}
+
+// DetectLibc returns musl when the musl loader exists, like on alpine, it is empty on other systems than linux
+func DetectLibc() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+
+	for _, pattern := range muslLoaderPatterns {
+		matches, _ := filepath.Glob(pattern)
+		if len(matches) > 0 {
+			return LibcMusl
+		}
+	}
+
+	return LibcGNU
+}
+
+func CurrentAssetPlatform() AssetPlatform {
+	return AssetPlatform{OS: runtime.GOOS, Arch: runtime.GOARCH, Libc: DetectLibc()}
+}
+
+// the aliases are matched as whole words, the longest one wins, so x86_64 is not taken as x86
+var (
+	assetOSAliases = map[string][]string{
+		"darwin":  {"darwin", "macos", "macosx", "osx", "mac", "apple"},
+		"linux":   {"linux"},
+		"windows": {"windows", "win", "win64", "win32"},
+		"freebsd": {"freebsd"},
+		"openbsd": {"openbsd"},
+		"netbsd":  {"netbsd"},
+	}
+	assetArchAliases = map[string][]string{
+		"amd64":   {"amd64", "x86_64", "x86-64", "x64"},
+		"arm64":   {"arm64", "aarch64", "armv8"},
+		"386":     {"386", "i386", "i686", "x86", "ia32"},
+		"arm":     {"arm", "armv7", "armv7l", "armhf", "armv6", "armv6l", "armel"},
+		"ppc64le": {"ppc64le", "powerpc64le"},
+		"s390x":   {"s390x"},
+		"riscv64": {"riscv64"},
+	}
+	assetLibcAliases = map[string][]string{
+		LibcGNU:  {"gnu", "glibc"},
+		LibcMusl: {"musl", "alpine"},
+	}
+	// the binaries which run on every arch of an os
+	assetUniversalAliases = []string{"universal", "universal2", "all"}
+)
+
+// the files which are never installed, like checksums, signatures and metadata
+var (
+	assetExcludedSuffixes = []string{
+		".sha1", ".sha256", ".sha256sum", ".sha512", ".sha512sum", ".md5", ".checksum", ".checksums",
+		".sig", ".asc", ".pem", ".crt", ".cert", ".pub", ".minisig", ".bundle",
+		".sbom", ".spdx", ".spdx.json", ".cdx.json", ".sbom.json", ".intoto.jsonl", ".att",
+		".json", ".txt", ".yaml", ".yml", ".md",
+		".deb", ".rpm", ".apk", ".msi", ".pkg", ".dmg",
+	}
+	assetExcludedWords = []string{"checksums", "sha256sums", "sha512sums", "sbom", "provenance", "src", "source"}
+)
+
+// the archive types which are preferred, the earlier the better
+var assetFormatScores = []struct {
+	suffix string
+	score  float64
+}{
+	{".tar.gz", 0.5},
+	{".tgz", 0.5},
+	{".tar.xz", 0.45},
+	{".txz", 0.45},
+	{".tar.zst", 0.4},
+	{".zip", 0.4},
+	{".tar.bz2", 0.35},
+	{".tar", 0.3},
+	{".gz", 0.2},
+	{".xz", 0.2},
+	{".bz2", 0.2},
+}
+
+var assetWordPatterns = make(map[string]*regexp.Regexp)
+
+func init() {
+	for _, table := range []map[string][]string{assetOSAliases, assetArchAliases, assetLibcAliases} {
+		for _, aliases := range table {
+			for _, alias := range aliases {
+				assetWordPatterns[alias] = newAssetWordPattern(alias)
+			}
+		}
+	}
+
+	for _, word := range append(append([]string{}, assetUniversalAliases...), assetExcludedWords...) {
+		assetWordPatterns[word] = newAssetWordPattern(word)
+	}
+}
+
+func newAssetWordPattern(word string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^a-z0-9])` + regexp.QuoteMeta(word) + `(?:[^a-z0-9]|$)`)
+}
+
+func containsAssetWord(name, word string) bool {
+	return assetWordPatterns[word].MatchString(name)
+}
+
+// detectAssetAlias returns the key whose alias is the longest one in the name
+func detectAssetAlias(name string, table map[string][]string) string {
+	var (
+		detected string
+		length   int
+	)
+	for key, aliases := range table {
+		for _, alias := range aliases {
+			if len(alias) > length && containsAssetWord(name, alias) {
+				detected, length = key, len(alias)
+			}
+		}
+	}
+
+	return detected
+}
+
+// AssetCandidate is a scored asset, the reasons explain the score
+type AssetCandidate struct {
+	Name     string
+	Score    float64
+	Excluded bool
+	Reasons  []string
+}
+
+func (c *AssetCandidate) exclude(reason string, args ...interface{}) *AssetCandidate {
+	c.Excluded = true
+	c.Score = 0
+	c.Reasons = append(c.Reasons, fmt.Sprintf(reason, args...))
+	return c
+}
+
+func (c *AssetCandidate) add(score float64, reason string, args ...interface{}) {
+	c.Score += score
+	c.Reasons = append(c.Reasons, fmt.Sprintf(reason, args...))
+}
+
+// AssetMatcher chooses the release asset of a platform
+type AssetMatcher struct {
+	platform AssetPlatform
+	name     string
+}
+
+func (m *AssetMatcher) Platform() AssetPlatform {
+	return m.platform
+}
+
+func (m *AssetMatcher) excludedReason(name string) string {
+	for _, suffix := range assetExcludedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return fmt.Sprintf("%s file", suffix)
+		}
+	}
+
+	for _, word := range assetExcludedWords {
+		if containsAssetWord(name, word) {
+			return fmt.Sprintf("%s file", word)
+		}
+	}
+
+	if strings.HasSuffix(name, ".exe") && m.platform.OS != "windows" {
+		return "windows executable"
+	}
+
+	return ""
+}
+
+// Score scores an asset, the assets without any platform or name hint are scored 0
+func (m *AssetMatcher) Score(asset string) *AssetCandidate {
+	candidate := &AssetCandidate{Name: asset}
+	name := strings.ToLower(asset)
+
+	if reason := m.excludedReason(name); reason != "" {
+		return candidate.exclude("%s", reason)
+	}
+
+	switch os := detectAssetAlias(name, assetOSAliases); os {
+	case "":
+	case m.platform.OS:
+		candidate.add(4, "os %s", os)
+	default:
+		return candidate.exclude("built for os %s", os)
+	}
+
+	switch arch := detectAssetAlias(name, assetArchAliases); arch {
+	case "":
+		for _, alias := range assetUniversalAliases {
+			if containsAssetWord(name, alias) {
+				candidate.add(3, "universal binary")
+				break
+			}
+		}
+	case m.platform.Arch:
+		candidate.add(4, "arch %s", arch)
+	default:
+		return candidate.exclude("built for arch %s", arch)
+	}
+
+	if m.platform.OS == "linux" && m.platform.Libc != "" {
+		switch libc := detectAssetAlias(name, assetLibcAliases); {
+		case libc == "":
+		case libc == m.platform.Libc:
+			candidate.add(1, "libc %s", libc)
+		case libc == LibcGNU:
+			return candidate.exclude("linked with glibc, %s is required", m.platform.Libc)
+		default:
+			// musl binaries are usually static, which also run with glibc
+			candidate.add(0.5, "libc %s", libc)
+		}
+	}
+
+	if m.name != "" && strings.Contains(name, strings.ToLower(m.name)) {
+		candidate.add(1, "name %s", m.name)
+	}
+
+	if candidate.Score == 0 {
+		candidate.Reasons = append(candidate.Reasons, "no platform hint")
+		return candidate
+	}
+
+	for _, format := range assetFormatScores {
+		if strings.HasSuffix(name, format.suffix) {
+			candidate.add(format.score, "archive %s", format.suffix)
+			return candidate
+		}
+	}
+
+	if strings.HasSuffix(name, ".exe") {
+		candidate.add(0.3, "executable .exe")
+	}
+
+	return candidate
+}
+
+// Explain scores the assets, the best comes first and the excluded ones come last
+func (m *AssetMatcher) Explain(assets []string) []*AssetCandidate {
+	candidates := make([]*AssetCandidate, 0, len(assets))
+	for _, asset := range assets {
+		candidates = append(candidates, m.Score(asset))
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Excluded != candidates[j].Excluded {
+			return !candidates[i].Excluded
+		}
+
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	return candidates
+}
+
+// Match returns the best asset, an asset with the same name as the preferred one always wins
+func (m *AssetMatcher) Match(assets []string, preferred string) (string, error) {
+	for _, asset := range assets {
+		if preferred != "" && asset == preferred {
+			return asset, nil
+		}
+	}
+
+	candidates := m.Explain(assets)
+	if len(candidates) == 0 || candidates[0].Excluded || candidates[0].Score <= 0 {
+		return "", errors.Wrapf(ErrAssetNotMatched, "%s", m.platform)
+	}
+
+	return candidates[0].Name, nil
+}
+
+// NewAssetMatcher creates a matcher for a platform, the assets which contain the name are preferred
+func NewAssetMatcher(platform AssetPlatform, name string) *AssetMatcher {
+	return &AssetMatcher{platform: platform, name: name}
+}
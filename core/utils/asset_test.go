@@ -0,0 +1,89 @@
+package utils_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var _ = Describe("Asset", func() {
+	var (
+		linux    = utils.AssetPlatform{OS: "linux", Arch: "amd64", Libc: utils.LibcGNU}
+		alpine   = utils.AssetPlatform{OS: "linux", Arch: "arm64", Libc: utils.LibcMusl}
+		darwin   = utils.AssetPlatform{OS: "darwin", Arch: "arm64"}
+		windows  = utils.AssetPlatform{OS: "windows", Arch: "amd64"}
+		linuxArm = utils.AssetPlatform{OS: "linux", Arch: "arm", Libc: utils.LibcGNU}
+		linux386 = utils.AssetPlatform{OS: "linux", Arch: "386", Libc: utils.LibcGNU}
+		ripgrep  = []string{
+			"ripgrep-14.1.0-aarch64-apple-darwin.tar.gz",
+			"ripgrep-14.1.0-aarch64-apple-darwin.tar.gz.sha256",
+			"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz",
+			"ripgrep-14.1.0-aarch64-unknown-linux-musl.tar.gz",
+			"ripgrep-14.1.0-armv7-unknown-linux-gnueabihf.tar.gz",
+			"ripgrep-14.1.0-i686-unknown-linux-gnu.tar.gz",
+			"ripgrep-14.1.0-x86_64-apple-darwin.tar.gz",
+			"ripgrep-14.1.0-x86_64-pc-windows-msvc.zip",
+			"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz",
+			"ripgrep-14.1.0-x86_64-unknown-linux-gnu.tar.gz",
+			"ripgrep-14.1.0-x86_64-unknown-linux-gnu.tar.gz.sig",
+			"ripgrep-14.1.0.sbom.json",
+			"ripgrep_14.1.0-1_amd64.deb",
+		}
+	)
+
+	DescribeTable("Match", func(platform utils.AssetPlatform, assets []string, expected string) {
+		matcher := utils.NewAssetMatcher(platform, "rg")
+		asset, err := matcher.Match(assets, "")
+		Expect(err).To(BeNil())
+		Expect(asset).To(Equal(expected))
+	},
+		Entry("glibc", linux, ripgrep, "ripgrep-14.1.0-x86_64-unknown-linux-gnu.tar.gz"),
+		Entry("musl", alpine, ripgrep, "ripgrep-14.1.0-aarch64-unknown-linux-musl.tar.gz"),
+		Entry("darwin by aliases", darwin, ripgrep, "ripgrep-14.1.0-aarch64-apple-darwin.tar.gz"),
+		Entry("windows", windows, ripgrep, "ripgrep-14.1.0-x86_64-pc-windows-msvc.zip"),
+		Entry("armv7", linuxArm, ripgrep, "ripgrep-14.1.0-armv7-unknown-linux-gnueabihf.tar.gz"),
+		Entry("i686 is not x86_64", linux386, ripgrep, "ripgrep-14.1.0-i686-unknown-linux-gnu.tar.gz"),
+		Entry("macos", darwin, []string{"jq-linux-arm64", "jq-macos-arm64", "jq-macos-amd64"}, "jq-macos-arm64"),
+		Entry("universal", darwin, []string{"tool_darwin_all.tar.gz", "tool_linux_arm64.tar.gz"}, "tool_darwin_all.tar.gz"),
+		Entry("static musl on glibc", linux, []string{"tool-x86_64-linux-musl", "tool-aarch64-linux-gnu"}, "tool-x86_64-linux-musl"),
+		Entry("prefer archives", linux, []string{"tool-linux-amd64", "tool-linux-amd64.tar.gz"}, "tool-linux-amd64.tar.gz"),
+	)
+
+	It("should prefer the given asset", func() {
+		matcher := utils.NewAssetMatcher(linux, "rg")
+		asset, err := matcher.Match(ripgrep, "ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz")
+		Expect(err).To(BeNil())
+		Expect(asset).To(Equal("ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz"))
+	})
+
+	It("should fail when nothing matches", func() {
+		matcher := utils.NewAssetMatcher(linux, "")
+		_, err := matcher.Match([]string{"tool-darwin-arm64", "checksums.txt", "README"}, "")
+		Expect(errors.Is(err, utils.ErrAssetNotMatched)).To(BeTrue())
+	})
+
+	It("should explain", func() {
+		matcher := utils.NewAssetMatcher(alpine, "rg")
+		candidates := matcher.Explain(ripgrep)
+		Expect(candidates).To(HaveLen(len(ripgrep)))
+		Expect(candidates[0].Name).To(Equal("ripgrep-14.1.0-aarch64-unknown-linux-musl.tar.gz"))
+		Expect(candidates[0].Reasons).To(ContainElements("os linux", "arch arm64", "libc musl", "archive .tar.gz"))
+
+		reasons := make(map[string][]string)
+		for _, candidate := range candidates {
+			if candidate.Excluded {
+				reasons[candidate.Name] = candidate.Reasons
+			}
+		}
+
+		Expect(reasons).To(HaveKeyWithValue("ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz", ContainElement("linked with glibc, musl is required")))
+		Expect(reasons).To(HaveKeyWithValue("ripgrep-14.1.0-x86_64-apple-darwin.tar.gz", ContainElement("built for os darwin")))
+		Expect(reasons).To(HaveKeyWithValue("ripgrep-14.1.0.sbom.json", ContainElement(".sbom.json file")))
+		Expect(reasons).To(HaveKeyWithValue("ripgrep_14.1.0-1_amd64.deb", ContainElement(".deb file")))
+		Expect(candidates[len(candidates)-1].Excluded).To(BeTrue())
+	})
+})
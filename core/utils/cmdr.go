@@ -3,24 +3,25 @@ package utils
 import (
 	"context"
 
-	ver "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
 var (
 	ErrCmdrCommandAlreadyDefined = errors.New("cmdr command already defined")
 	ErrCmdrAlreadyLatestVersion  = errors.New("cmdr already latest version")
+	ErrCmdrRollbackNotFound      = errors.New("cmdr version to rollback not found")
 )
 
 func DefineCmdrCommand(manager core.CommandManager, name string, version string, location string, activate bool) (core.Command, error) {
-	semver, err := ver.NewVersion(version)
+	parsed, err := versioning.Parse(version)
 	if err != nil {
 		return nil, errors.Wrapf(err, "invalid version %s", version)
 	}
 
-	version = semver.String()
+	version = parsed.String()
 	command, err := manager.Define(name, version, location)
 	if err != nil {
 		return nil, err
@@ -61,13 +62,26 @@ func GetCmdrCommand(manager core.CommandManager, name, version string) (core.Com
 	return command, nil
 }
 
-func UpgradeCmdr(ctx context.Context, cfg core.Configuration, url, version string, args []string) error {
-	currentVersion := ver.Must(ver.NewVersion(core.Version))
-	targetVersion := ver.Must(ver.NewVersion(version))
+// UpgradeCmdr downloads the new version then runs it, the asset is verified by the verifier unless it is nil
+func UpgradeCmdr(ctx context.Context, cfg core.Configuration, verifier *CmdrReleaseVerifier, url, version string, args []string) error {
+	_, err := versioning.Parse(version)
+	if err != nil {
+		return errors.Wrapf(err, "invalid version %s", version)
+	}
+
+	// the current version of a dev build may be invalid, it is compared as a string then
+	if versioning.Equal(core.Version, version) {
+		return errors.Wrapf(ErrCmdrAlreadyLatestVersion, "%s", core.Version)
+	}
+
+	if verifier != nil {
+		verified, err := verifier.Verify(ctx, url)
+		if err != nil {
+			return errors.WithMessagef(err, "verify release %s failed", version)
+		}
 
-   if currentVersion.Equal(targetVersion) {
-	   return errors.Wrapf(ErrCmdrAlreadyLatestVersion, "%s", core.Version)
-   }
+		url = verified
+	}
 
 	name := core.Name
 	manager, err := core.NewCommandManager(core.CommandProviderDownload, cfg)
@@ -97,3 +111,81 @@ func UpgradeCmdr(ctx context.Context, cfg core.Configuration, url, version strin
 
 	return nil
 }
+
+// searchCmdrRollbackCommand returns the defined version, the newest one older than the current is chosen when version is empty
+func searchCmdrRollbackCommand(manager core.CommandManager, name, version string) (core.Command, error) {
+	query, err := manager.Query()
+	if err != nil {
+		return nil, errors.Wrapf(err, "query command %v failed", name)
+	}
+
+	commands, err := query.WithName(name).All()
+	if err != nil {
+		return nil, errors.Wrapf(err, "query command %v failed", name)
+	}
+
+	var found core.Command
+	for _, command := range commands {
+		definedVersion := command.GetVersion()
+		if versioning.Equal(definedVersion, core.Version) {
+			continue
+		}
+
+		if version != "" {
+			if versioning.Equal(definedVersion, version) {
+				return command, nil
+			}
+
+			continue
+		}
+
+		if versioning.Compare(definedVersion, core.Version) > 0 {
+			continue
+		}
+
+		if found == nil || versioning.Compare(definedVersion, found.GetVersion()) > 0 {
+			found = command
+		}
+	}
+
+	if found == nil {
+		return nil, errors.Wrapf(ErrCmdrRollbackNotFound, "%v(%s), current %s", name, version, core.Version)
+	}
+
+	return found, nil
+}
+
+// RollbackCmdr activates a kept version then runs it, the newest version older than the current is chosen when version is empty
+func RollbackCmdr(ctx context.Context, cfg core.Configuration, version string, args []string) error {
+	name := core.Name
+	manager, err := core.NewCommandManager(core.CommandProviderDefault, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "create command manager %v failed", core.CommandProviderDefault)
+	}
+
+	command, err := searchCmdrRollbackCommand(manager, name, version)
+	if err != nil {
+		_ = manager.Close()
+		return err
+	}
+
+	// the short form of the version replaces the stored one when activating, like 1 of 1.0.0
+	version = versioning.Normalize(command.GetVersion())
+	err = manager.Activate(name, version)
+	if err != nil {
+		_ = manager.Close()
+		return errors.Wrapf(err, "activate command %v(%s) failed", name, version)
+	}
+
+	err = manager.Close()
+	if err != nil {
+		return errors.Wrapf(err, "close command manager %v failed", core.CommandProviderDefault)
+	}
+
+	err = WaitProcess(ctx, command.GetLocation(), args)
+	if err != nil {
+		return errors.Wrapf(err, "run command %v failed", name)
+	}
+
+	return nil
+}
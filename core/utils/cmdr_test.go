@@ -2,7 +2,14 @@ package utils_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -12,6 +19,7 @@ import (
 	"github.com/mrlyc/cmdr/core"
 	"github.com/mrlyc/cmdr/core/mock"
 	"github.com/mrlyc/cmdr/core/utils"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
 var _ = Describe("Cmdr", func() {
@@ -123,15 +131,109 @@ var _ = Describe("Cmdr", func() {
 			manager.EXPECT().Close().Return(nil)
 			command.EXPECT().GetLocation().Return("echo")
 
-			Expect(utils.UpgradeCmdr(ctx, nil, url, "1.0.0", []string{})).To(Succeed())
+			Expect(utils.UpgradeCmdr(ctx, nil, nil, url, "1.0.0", []string{})).To(Succeed())
 		})
 
 		It("should not upgrade a command ", func() {
 			mockCommand := mock.NewMockCommand(ctrl)
 			query.EXPECT().One().Return(mockCommand, nil)
 
-			err := utils.UpgradeCmdr(ctx, nil, url, "1.0.0", []string{})
+			err := utils.UpgradeCmdr(ctx, nil, nil, url, "1.0.0", []string{})
 			Expect(errors.Cause(err)).To(Equal(utils.ErrCmdrCommandAlreadyDefined))
 		})
+
+		It("should not upgrade to the current version of another scheme", func() {
+			currentVersion := core.Version
+			core.Version = "2024.01.15"
+			defer func() { core.Version = currentVersion }()
+
+			err := utils.UpgradeCmdr(ctx, nil, nil, url, "2024.01.15", []string{})
+			Expect(errors.Cause(err)).To(Equal(utils.ErrCmdrAlreadyLatestVersion))
+		})
+
+		It("should return an error for an invalid version", func() {
+			currentVersion := core.Version
+			core.Version = "dev"
+			defer func() { core.Version = currentVersion }()
+
+			err := utils.UpgradeCmdr(ctx, nil, nil, url, "1.0 beta", []string{})
+			Expect(errors.Cause(err)).To(Equal(versioning.ErrInvalidVersion))
+		})
+
+		It("should not define an unverified release", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			defer server.Close()
+
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).To(BeNil())
+
+			der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+			Expect(err).To(BeNil())
+
+			verifier, err := utils.NewCmdrReleaseVerifier(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+			Expect(err).To(BeNil())
+
+			err = utils.UpgradeCmdr(ctx, nil, verifier, server.URL+"/v1.0.0/cmdr", "1.0.0", []string{})
+			Expect(errors.Cause(err)).To(Equal(utils.ErrCmdrReleaseUnverified))
+		})
+	})
+
+	Context("RollbackCmdr", func() {
+		var (
+			ctx            context.Context
+			factory        func(cfg core.Configuration) (core.CommandManager, error)
+			currentVersion string
+			commands       []core.Command
+		)
+
+		newCommand := func(version, location string) core.Command {
+			command := mock.NewMockCommand(ctrl)
+			command.EXPECT().GetVersion().Return(version).AnyTimes()
+			command.EXPECT().GetLocation().Return(location).AnyTimes()
+			return command
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			currentVersion = core.Version
+			core.Version = "2.0.0"
+			factory = core.GetCommandManagerFactory(core.CommandProviderDefault)
+			core.RegisterCommandManagerFactory(core.CommandProviderDefault, func(cfg core.Configuration) (core.CommandManager, error) {
+				return manager, nil
+			})
+			commands = []core.Command{
+				newCommand("1.0.0", "false"),
+				newCommand("1.1.0", "echo"),
+				newCommand("2.0.0", "false"),
+				newCommand("3.0.0", "echo"),
+			}
+			query.EXPECT().All().Return(commands, nil).AnyTimes()
+		})
+
+		AfterEach(func() {
+			core.Version = currentVersion
+			core.RegisterCommandManagerFactory(core.CommandProviderDefault, factory)
+		})
+
+		It("should rollback to the previous version", func() {
+			manager.EXPECT().Activate(core.Name, "1.1.0")
+			manager.EXPECT().Close()
+
+			Expect(utils.RollbackCmdr(ctx, nil, "", []string{})).To(Succeed())
+		})
+
+		It("should rollback to the given version", func() {
+			manager.EXPECT().Activate(core.Name, "3.0.0")
+			manager.EXPECT().Close()
+
+			Expect(utils.RollbackCmdr(ctx, nil, "v3.0.0", []string{})).To(Succeed())
+		})
+
+		It("should not rollback to the current version", func() {
+			manager.EXPECT().Close()
+
+			err := utils.RollbackCmdr(ctx, nil, "2.0.0", []string{})
+			Expect(errors.Cause(err)).To(Equal(utils.ErrCmdrRollbackNotFound))
+		})
 	})
 })
@@ -3,17 +3,15 @@ package utils
 import (
 	"context"
 	"fmt"
-	"runtime"
 	"sort"
-	"strings"
 
 	"github.com/google/go-github/v39/github"
 	"github.com/hashicorp/go-multierror"
-	ver "github.com/hashicorp/go-version"
 	"github.com/mmcdole/gofeed"
 	"github.com/pkg/errors"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
 var (
@@ -28,44 +26,51 @@ type GithubRepositoryClient interface {
 }
 
 type CmdrApiFetcher struct {
-	client GithubRepositoryClient
+	client  GithubRepositoryClient
+	matcher *AssetMatcher
 }
 
 func (s *CmdrApiFetcher) String() string {
 	return "github-api"
 }
 
+func (s *CmdrApiFetcher) SetMatcher(matcher *AssetMatcher) {
+	s.matcher = matcher
+}
+
 func (s *CmdrApiFetcher) SearchReleaseAsset(ctx context.Context, assetName string, release *github.RepositoryRelease) (*github.ReleaseAsset, error) {
-	assets := NewSortedHeap(len(release.Assets))
+	assets := make(map[string]*github.ReleaseAsset, len(release.Assets))
+	names := make([]string, 0, len(release.Assets))
 	for _, asset := range release.Assets {
 		if asset.BrowserDownloadURL == nil {
 			continue
 		}
 
-		currentAssetName := asset.GetName()
+		assets[asset.GetName()] = asset
+		names = append(names, asset.GetName())
+	}
 
-		if currentAssetName == assetName {
-			return asset, nil
-		}
+	name, err := s.matcher.Match(names, assetName)
+	if err != nil {
+		return nil, errors.Wrapf(ErrGithubReleaseAssetNotFound, "search release asset failed: %v", err)
+	}
 
-		score := 0.0
-		if strings.Contains(currentAssetName, runtime.GOOS) {
-			score += 1
-		}
-		if strings.Contains(currentAssetName, runtime.GOARCH) {
-			score += 1
-		}
-		if score > 0.0 {
-			assets.Add(asset, score)
-		}
+	return assets[name], nil
+}
+
+// ExplainReleaseAsset scores the assets of a release for the platform of the matcher
+func (s *CmdrApiFetcher) ExplainReleaseAsset(ctx context.Context, releaseName string) ([]*AssetCandidate, error) {
+	release, err := s.GetCmdrRelease(ctx, releaseName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "search release failed")
 	}
 
-	item, _ := assets.PopMax()
-	if item == nil {
-		return nil, errors.Wrapf(ErrGithubReleaseAssetNotFound, "search release asset failed")
+	names := make([]string, 0, len(release.Assets))
+	for _, asset := range release.Assets {
+		names = append(names, asset.GetName())
 	}
 
-	return item.(*github.ReleaseAsset), nil
+	return s.matcher.Explain(names), nil
 }
 
 func (s *CmdrApiFetcher) GetCmdrRelease(ctx context.Context, releaseName string) (release *github.RepositoryRelease, err error) {
@@ -96,7 +101,7 @@ func (s *CmdrApiFetcher) GetReleaseAsset(ctx context.Context, releaseName, asset
 	}
 
 	tag := release.GetTagName()
-	version, err := ver.NewVersion(tag)
+	version, err := versioning.Parse(tag)
 	if err != nil {
 		return result, errors.Wrapf(err, "release %s tag %s is not a valid version", releaseName, tag)
 	}
@@ -116,7 +121,8 @@ func (s *CmdrApiFetcher) GetReleaseAsset(ctx context.Context, releaseName, asset
 
 func NewCmdrApiFetcher(client GithubRepositoryClient) *CmdrApiFetcher {
 	return &CmdrApiFetcher{
-		client: client,
+		client:  client,
+		matcher: NewAssetMatcher(CurrentAssetPlatform(), core.Name),
 	}
 }
 
@@ -178,7 +184,7 @@ func (s *CmdrFeedFetcher) GetReleaseAsset(ctx context.Context, releaseName, asse
 		return result, err
 	}
 
-	releaseVersion, err := ver.NewVersion(item.Title)
+	releaseVersion, err := versioning.Parse(item.Title)
 	if err != nil {
 		return result, errors.Wrapf(err, "parse release %s version failed", item.Title)
 	}
@@ -258,16 +264,43 @@ func NewCmdrReleaseSearcher(searchers ...core.CmdrSearcher) *CmdrReleaseSearcher
 	}
 }
 
+// CmdrOfflineSearcher fails fast instead of searching releases from the network
+type CmdrOfflineSearcher struct{}
+
+func (s *CmdrOfflineSearcher) String() string {
+	return "offline"
+}
+
+func (s *CmdrOfflineSearcher) GetReleaseAsset(ctx context.Context, releaseName, assetName string) (result core.CmdrReleaseAsset, err error) {
+	return result, errors.Wrapf(core.ErrOffline, "search release %s", releaseName)
+}
+
+func NewCmdrOfflineSearcher() *CmdrOfflineSearcher {
+	return &CmdrOfflineSearcher{}
+}
+
 func init() {
 	core.RegisterCmdrSearcherFactory(core.CmdrSearcherProviderApi, func(cfg core.Configuration) (core.CmdrSearcher, error) {
+		if cfg.GetBool(core.CfgKeyCmdrOffline) {
+			return NewCmdrOfflineSearcher(), nil
+		}
+
 		return NewCmdrApiFetcher(github.NewClient(nil).Repositories), nil
 	})
 
 	core.RegisterCmdrSearcherFactory(core.CmdrSearcherProviderAtom, func(cfg core.Configuration) (core.CmdrSearcher, error) {
+		if cfg.GetBool(core.CfgKeyCmdrOffline) {
+			return NewCmdrOfflineSearcher(), nil
+		}
+
 		return NewCmdrAtomFetcher(), nil
 	})
 
 	core.RegisterCmdrSearcherFactory(core.CmdrSearcherProviderDefault, func(cfg core.Configuration) (core.CmdrSearcher, error) {
+		if cfg.GetBool(core.CfgKeyCmdrOffline) {
+			return NewCmdrOfflineSearcher(), nil
+		}
+
 		apiSearcher, err := core.NewCmdrSearcher(core.CmdrSearcherProviderApi, cfg)
 		if err != nil {
 			return nil, err
@@ -2,6 +2,7 @@ package utils_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
 
 	"github.com/mrlyc/cmdr/core"
 	coremock "github.com/mrlyc/cmdr/core/mock"
@@ -231,4 +233,23 @@ var _ = Describe("Github", func() {
 			Expect(info).To(Equal(release2))
 		})
 	})
+
+	Context("Offline", func() {
+		It("should fail fast", func() {
+			cfg := viper.New()
+			cfg.Set(core.CfgKeyCmdrOffline, true)
+
+			for _, provider := range []core.CmdrSearcherProvider{
+				core.CmdrSearcherProviderDefault,
+				core.CmdrSearcherProviderApi,
+				core.CmdrSearcherProviderAtom,
+			} {
+				searcher, err := core.NewCmdrSearcher(provider, cfg)
+				Expect(err).To(BeNil())
+
+				_, err = searcher.GetReleaseAsset(ctx, "latest", "cmdr-goos-goarch")
+				Expect(errors.Is(err, core.ErrOffline)).To(BeTrue())
+			}
+		})
+	})
 })
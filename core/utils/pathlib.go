@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"os"
 	"path/filepath"
+	"syscall"
 
 	"github.com/homedepot/flop"
 	"github.com/pkg/errors"
@@ -33,6 +37,45 @@ func ensureNotExists(path string) error {
 	return nil
 }
 
+// MoveFile renames src to dst, falling back to copy and remove when they are on different devices
+func MoveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if linkErr, ok := err.(*os.LinkError); !ok || linkErr.Err != syscall.EXDEV {
+		return errors.Wrapf(err, "rename %s to %s failed", src, dst)
+	}
+
+	// Cross-device rename. Copy then remove.
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "open %s failed", src)
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "stat %s failed", src)
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return errors.Wrapf(err, "create %s failed", dst)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return errors.Wrapf(err, "copy %s to %s failed", src, dst)
+	}
+
+	if err := os.Remove(src); err != nil {
+		return errors.Wrapf(err, "remove %s failed", src)
+	}
+
+	return nil
+}
+
 type PathHelper struct {
 	path string
 }
@@ -122,6 +165,58 @@ func (p *PathHelper) CopyFile(name, source string, mode os.FileMode) error {
 	return os.Chmod(path, mode)
 }
 
+// HardLink links source as name, the mode is shared with source,
+// it falls back to copy when they are on different devices
+func (p *PathHelper) HardLink(name, source string, mode os.FileMode) error {
+	err := p.EnsureNotExists(name)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(p.path, name)
+	err = os.Link(source, path)
+	if linkErr, ok := err.(*os.LinkError); ok && linkErr.Err == syscall.EXDEV {
+		return p.CopyFile(name, source, mode)
+	} else if err != nil {
+		return errors.Wrapf(err, "create hard link failed")
+	}
+
+	return os.Chmod(path, mode)
+}
+
+// Reflink clones source as name, which shares the blocks until either of them is changed,
+// it falls back to copy when the filesystem does not support it
+func (p *PathHelper) Reflink(name, source string, mode os.FileMode) error {
+	err := p.EnsureNotExists(name)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(p.path, name)
+	err = reflinkFile(path, source)
+	if err != nil {
+		return p.CopyFile(name, source, mode)
+	}
+
+	return os.Chmod(path, mode)
+}
+
+// WriteFile replaces the file, a link is removed instead of writing to its target
+func (p *PathHelper) WriteFile(name string, content []byte, mode os.FileMode) error {
+	err := p.EnsureNotExists(name)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(p.path, name)
+	err = os.WriteFile(path, content, mode)
+	if err != nil {
+		return errors.Wrapf(err, "write file %s failed", path)
+	}
+
+	return os.Chmod(path, mode)
+}
+
 func (p *PathHelper) RealPath(name string) (string, error) {
 	path, err := p.AbsPath(name)
 	if err != nil {
@@ -175,3 +270,20 @@ func NewPathHelper(path string) *PathHelper {
 		path: path,
 	}
 }
+
+// SHA256File returns the hex encoded sha256 digest of the file
+func SHA256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "open %s failed", path)
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", errors.Wrapf(err, "read %s failed", path)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -45,8 +45,14 @@ type ProgressBarTracker struct {
 }
 
 func (t *ProgressBarTracker) TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) (body io.ReadCloser) {
+	// a resumed download starts from currentSize, show the percentage only when the total is known
+	max := int64(-1)
+	if totalSize > currentSize {
+		max = totalSize
+	}
+
 	bar := progressbar.NewOptions64(
-		-1,
+		max,
 		progressbar.OptionSetDescription(t.description),
 		progressbar.OptionSetWriter(t.stream),
 		progressbar.OptionShowBytes(true),
@@ -61,7 +67,7 @@ func (t *ProgressBarTracker) TrackProgress(src string, currentSize, totalSize in
 	)
 
 	_ = bar.RenderBlank()
-	_ = bar.Set(int(currentSize))
+	_ = bar.Set64(currentSize)
 
 	return NewProgressBar(stream, bar)
 }
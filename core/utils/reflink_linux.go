@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// reflinkFile clones the extents of source by FICLONE, which is supported by CoW filesystems like btrfs and xfs
+func reflinkFile(path, source string) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return errors.Wrapf(err, "open %s failed", source)
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "create %s failed", path)
+	}
+
+	err = unix.IoctlFileClone(int(dst.Fd()), int(src.Fd()))
+	closeErr := dst.Close()
+	if err != nil {
+		_ = os.Remove(path)
+		return errors.Wrapf(err, "clone %s to %s failed", source, path)
+	}
+
+	if closeErr != nil {
+		return errors.Wrapf(closeErr, "close %s failed", path)
+	}
+
+	return nil
+}
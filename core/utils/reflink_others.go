@@ -0,0 +1,11 @@
+//go:build !linux
+
+package utils
+
+import (
+	"github.com/pkg/errors"
+)
+
+func reflinkFile(path, source string) error {
+	return errors.Errorf("reflink is not supported on this platform")
+}
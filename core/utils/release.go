@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/registry"
+	"github.com/mrlyc/cmdr/core/signature"
+	"github.com/mrlyc/cmdr/core/strategy"
+)
+
+// the checksums file is published beside the assets of a release, the pipeline signs it by the release key
+const (
+	CmdrReleaseChecksums          = "checksums.txt"
+	CmdrReleaseChecksumsSignature = CmdrReleaseChecksums + ".sig"
+	cmdrReleaseKeyPath            = "embed/keys/release.pub"
+)
+
+var (
+	ErrCmdrReleaseUnverified = errors.New("cmdr release unverified")
+	ErrCmdrReleaseKeyMissing = errors.New("cmdr release key missing")
+)
+
+// CmdrReleaseVerifier checks the signed checksums of a release, then pins the checksum to the asset url,
+// so the asset is verified once downloaded, before it is defined or executed
+type CmdrReleaseVerifier struct {
+	verifier signature.Verifier
+	client   *http.Client
+	strategy *strategy.StrategyChain
+}
+
+func (v *CmdrReleaseVerifier) SetHTTPClient(client *http.Client) {
+	v.client = client
+}
+
+// SetStrategyChain makes the verifier fetch like the downloads do, by the strategies and in the offline mode
+func (v *CmdrReleaseVerifier) SetStrategyChain(chain *strategy.StrategyChain) {
+	v.strategy = chain
+}
+
+func (v *CmdrReleaseVerifier) get(ctx context.Context, uri string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create request of %s failed", uri)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch %s failed", req.URL.Redacted())
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrapf(ErrCmdrReleaseUnverified, "fetch %s failed: bad response code %d", req.URL.Redacted(), resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", req.URL.Redacted())
+	}
+
+	return data, nil
+}
+
+func (v *CmdrReleaseVerifier) fetch(ctx context.Context, location *url.URL) ([]byte, error) {
+	if v.strategy == nil {
+		return v.get(ctx, location.String())
+	}
+
+	if v.strategy.IsOffline() {
+		return nil, errors.Wrapf(core.ErrOffline, "fetch %s", location.Redacted())
+	}
+
+	var data []byte
+	err := v.strategy.ExecuteContext(ctx, location.String(), func(ctx context.Context, uri string) error {
+		var err error
+		data, err = v.get(ctx, uri)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(ErrCmdrReleaseUnverified, "fetch %s failed: %v", location.Redacted(), err)
+	}
+
+	return data, nil
+}
+
+// Verify returns the asset url with the checksum which is signed by the release key
+func (v *CmdrReleaseVerifier) Verify(ctx context.Context, assetUrl string) (string, error) {
+	parsed, err := url.Parse(assetUrl)
+	if err != nil || parsed.Host == "" {
+		return "", errors.Wrapf(ErrCmdrReleaseUnverified, "invalid asset url %s", assetUrl)
+	}
+
+	asset := path.Base(parsed.Path)
+	sibling := func(name string) *url.URL {
+		location := *parsed
+		location.Path = path.Join(path.Dir(parsed.Path), name)
+		location.RawPath = ""
+		location.RawQuery = ""
+		return &location
+	}
+
+	checksums, err := v.fetch(ctx, sibling(CmdrReleaseChecksums))
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := v.fetch(ctx, sibling(CmdrReleaseChecksumsSignature))
+	if err != nil {
+		return "", err
+	}
+
+	err = v.verifier.Verify(bytes.NewReader(checksums), sig)
+	if err != nil {
+		return "", errors.Wrapf(ErrCmdrReleaseUnverified, "checksums of %s: %v", asset, err)
+	}
+
+	digest, err := registry.ParseChecksum(checksums, asset)
+	if err != nil {
+		return "", errors.Wrapf(ErrCmdrReleaseUnverified, "checksum of %s: %v", asset, err)
+	}
+
+	core.GetLogger().Info("release checksums verified", map[string]interface{}{
+		"asset":  asset,
+		"sha256": digest,
+	})
+
+	// go-getter verifies the checksum of the downloaded file
+	query := parsed.Query()
+	query.Set("checksum", "sha256:"+digest)
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String(), nil
+}
+
+func NewCmdrReleaseVerifier(key []byte) (*CmdrReleaseVerifier, error) {
+	if len(bytes.TrimSpace(key)) == 0 {
+		return nil, errors.Wrapf(ErrCmdrReleaseKeyMissing, "empty release key")
+	}
+
+	verifier, err := signature.NewVerifier(signature.KindCosign, key)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "load release key failed")
+	}
+
+	return &CmdrReleaseVerifier{
+		verifier: verifier,
+		// the context of each request carries the strategy, which decides the transport
+		client: &http.Client{Transport: strategy.NewTransport(http.DefaultTransport)},
+	}, nil
+}
+
+// NewDefaultCmdrReleaseVerifier verifies the releases by the embedded release key
+func NewDefaultCmdrReleaseVerifier() (*CmdrReleaseVerifier, error) {
+	key, err := core.EmbedFS.ReadFile(cmdrReleaseKeyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read release key failed")
+	}
+
+	return NewCmdrReleaseVerifier(key)
+}
+
+// NewCmdrReleaseVerifierByConfiguration verifies the releases by the embedded release key,
+// the checksums are fetched by the download strategies
+func NewCmdrReleaseVerifierByConfiguration(cfg core.Configuration) (*CmdrReleaseVerifier, error) {
+	verifier, err := NewDefaultCmdrReleaseVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := strategy.NewStrategyChainByConfiguration(cfg)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "configure download strategies failed")
+	}
+
+	verifier.SetStrategyChain(chain)
+
+	return verifier, nil
+}
@@ -0,0 +1,156 @@
+package utils_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/signature"
+	"github.com/mrlyc/cmdr/core/strategy"
+	"github.com/mrlyc/cmdr/core/utils"
+)
+
+var _ = Describe("Release", func() {
+	var (
+		ctx       context.Context
+		key       *ecdsa.PrivateKey
+		server    *httptest.Server
+		files     map[string][]byte
+		verifier  *utils.CmdrReleaseVerifier
+		assetUrl  string
+		digest    = "4f3c9e1a8d1f0b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4"
+		checksums = fmt.Sprintf("%s  cmdr_linux_amd64\n%s  cmdr_darwin_arm64\n", digest, "0000000000000000000000000000000000000000000000000000000000000000")
+	)
+
+	sign := func(data []byte) []byte {
+		hashed := sha256.Sum256(data)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, hashed[:])
+		Expect(err).To(BeNil())
+
+		return []byte(base64.StdEncoding.EncodeToString(sig))
+	}
+
+	BeforeEach(func() {
+		var err error
+		ctx = context.Background()
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).To(BeNil())
+
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		Expect(err).To(BeNil())
+
+		verifier, err = utils.NewCmdrReleaseVerifier(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		Expect(err).To(BeNil())
+
+		files = map[string][]byte{
+			"/download/v1.0.0/checksums.txt":     []byte(checksums),
+			"/download/v1.0.0/checksums.txt.sig": sign([]byte(checksums)),
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, ok := files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			_, _ = w.Write(data)
+		}))
+		verifier.SetHTTPClient(server.Client())
+		assetUrl = server.URL + "/download/v1.0.0/cmdr_linux_amd64"
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should pin the signed checksum to the asset url", func() {
+		verified, err := verifier.Verify(ctx, assetUrl)
+		Expect(err).To(BeNil())
+		Expect(verified).To(Equal(assetUrl + "?checksum=sha256%3A" + digest))
+	})
+
+	It("should reject the tampered checksums", func() {
+		files["/download/v1.0.0/checksums.txt"] = []byte(fmt.Sprintf("%s  cmdr_linux_amd64\n", "1111111111111111111111111111111111111111111111111111111111111111"))
+
+		_, err := verifier.Verify(ctx, assetUrl)
+		Expect(errors.Cause(err)).To(Equal(utils.ErrCmdrReleaseUnverified))
+		Expect(err.Error()).To(ContainSubstring(signature.ErrSignatureMismatch.Error()))
+	})
+
+	It("should reject the release without signature", func() {
+		delete(files, "/download/v1.0.0/checksums.txt.sig")
+
+		_, err := verifier.Verify(ctx, assetUrl)
+		Expect(errors.Cause(err)).To(Equal(utils.ErrCmdrReleaseUnverified))
+	})
+
+	It("should reject the asset which is not signed", func() {
+		_, err := verifier.Verify(ctx, server.URL+"/download/v1.0.0/cmdr_windows_amd64")
+		Expect(errors.Cause(err)).To(Equal(utils.ErrCmdrReleaseUnverified))
+	})
+
+	Context("with download strategies", func() {
+		var cfg core.Configuration
+
+		BeforeEach(func() {
+			cfg = viper.New()
+			cfg.Set("download.proxy.enabled", true)
+			cfg.Set("download.proxy.type", "http")
+			cfg.Set("download.proxy.address", server.URL)
+		})
+
+		verifyByStrategies := func(assetUrl string) (string, error) {
+			chain := strategy.NewStrategyChain(strategy.NewProxyStrategy())
+			Expect(chain.Configure(cfg)).To(Succeed())
+
+			der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+			Expect(err).To(BeNil())
+
+			verifier, err := utils.NewCmdrReleaseVerifier(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+			Expect(err).To(BeNil())
+			verifier.SetStrategyChain(chain)
+
+			return verifier.Verify(ctx, assetUrl)
+		}
+
+		It("should fetch the checksums through the proxy", func() {
+			// the host is only reachable by the proxy, which is the test server
+			assetUrl := "http://release.invalid/download/v1.0.0/cmdr_linux_amd64"
+
+			verified, err := verifyByStrategies(assetUrl)
+			Expect(err).To(BeNil())
+			Expect(verified).To(Equal(assetUrl + "?checksum=sha256%3A" + digest))
+		})
+
+		It("should not fetch in offline mode", func() {
+			cfg.Set(core.CfgKeyCmdrOffline, true)
+
+			_, err := verifyByStrategies(assetUrl)
+			Expect(errors.Cause(err)).To(Equal(core.ErrOffline))
+		})
+	})
+
+	It("should load the embedded release key", func() {
+		_, err := utils.NewDefaultCmdrReleaseVerifier()
+		Expect(err).To(BeNil())
+	})
+
+	It("should refuse an empty release key", func() {
+		_, err := utils.NewCmdrReleaseVerifier(nil)
+		Expect(errors.Cause(err)).To(Equal(utils.ErrCmdrReleaseKeyMissing))
+	})
+})
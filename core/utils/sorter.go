@@ -4,6 +4,7 @@ import (
 	"sort"
 
 	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
 )
 
 // sort.Interface implementation for sorting a slice of maps by a given key
@@ -33,7 +34,7 @@ func (c sortedCommands) Less(i, j int) bool {
 		return activated1
 	}
 
-	return c[i].GetVersion() < c[j].GetVersion()
+	return versioning.Compare(c[i].GetVersion(), c[j].GetVersion()) < 0
 }
 
 func SortCommands(commands []core.Command) {
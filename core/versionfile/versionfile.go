@@ -0,0 +1,280 @@
+package versionfile
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mrlyc/cmdr/core"
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+const (
+	// FormatPlain is a file which contains a version only, like .nvmrc
+	FormatPlain = "plain"
+	// FormatToolVersions is the .tool-versions of asdf, the tools are mapped to commands by Tools
+	FormatToolVersions = "tool-versions"
+	// FormatGoMod reads the toolchain directive of go.mod
+	FormatGoMod = "go.mod"
+)
+
+var goToolchainPattern = regexp.MustCompile(`^toolchain\s+go(\S+)`)
+
+var (
+	ErrVersionNotRequested = errors.New("version not requested by any version file")
+)
+
+// Rule tells which command a version file is for
+type Rule struct {
+	File    string            `mapstructure:"file" yaml:"file"`
+	Command string            `mapstructure:"command" yaml:"command"`
+	Format  string            `mapstructure:"format" yaml:"format"`
+	Tools   map[string]string `mapstructure:"tools" yaml:"tools"`
+}
+
+func (r *Rule) format() string {
+	if r.Format != "" {
+		return r.Format
+	}
+
+	switch r.File {
+	case ".tool-versions":
+		return FormatToolVersions
+	case "go.mod":
+		return FormatGoMod
+	default:
+		return FormatPlain
+	}
+}
+
+// read returns the versions by command name
+func (r *Rule) read(path string) (map[string]string, error) {
+	switch r.format() {
+	case FormatToolVersions:
+		tools, err := ReadToolVersions(path)
+		if err != nil {
+			return nil, err
+		}
+
+		versions := make(map[string]string, len(tools))
+		for tool, version := range tools {
+			command, ok := r.Tools[tool]
+			if !ok {
+				command = tool
+			}
+
+			versions[command] = version
+		}
+
+		return versions, nil
+	case FormatGoMod:
+		version, err := ReadGoToolchain(path)
+		if err != nil || version == "" {
+			return nil, err
+		}
+
+		return map[string]string{r.Command: version}, nil
+	default:
+		version, err := ReadPlain(path)
+		if err != nil || version == "" {
+			return nil, err
+		}
+
+		return map[string]string{r.Command: version}, nil
+	}
+}
+
+// Resolution is the version of a command and the file which decided it
+type Resolution struct {
+	Command string
+	Version string
+	Source  string
+}
+
+// Resolver reads version files from a dir up to the root, the nearest file wins
+type Resolver struct {
+	rules []*Rule
+}
+
+func (r *Resolver) Rules() []*Rule {
+	return r.rules
+}
+
+func (r *Resolver) Resolve(dir string) (map[string]*Resolution, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get absolute path of %s failed", dir)
+	}
+
+	resolutions := make(map[string]*Resolution)
+	for {
+		for _, rule := range r.rules {
+			path := filepath.Join(dir, rule.File)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+
+			versions, err := rule.read(path)
+			if err != nil {
+				return nil, err
+			}
+
+			for command, version := range versions {
+				if _, ok := resolutions[command]; ok || command == "" {
+					continue
+				}
+
+				resolutions[command] = &Resolution{
+					Command: command,
+					Version: version,
+					Source:  path,
+				}
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return resolutions, nil
+		}
+
+		dir = parent
+	}
+}
+
+func NewResolver(rules ...*Rule) *Resolver {
+	return &Resolver{rules: rules}
+}
+
+// DefaultRules returns the rules of the common version files
+func DefaultRules() []*Rule {
+	return []*Rule{
+		{File: ".tool-versions", Tools: map[string]string{
+			"nodejs": "node",
+			"golang": "go",
+		}},
+		{File: ".nvmrc", Command: "node"},
+		{File: ".node-version", Command: "node"},
+		{File: ".go-version", Command: "go"},
+		{File: "go.mod", Command: "go"},
+		{File: ".python-version", Command: "python"},
+		{File: ".terraform-version", Command: "terraform"},
+	}
+}
+
+// NewResolverByConfiguration creates a resolver with version_files, the default rules are used when it is not set
+func NewResolverByConfiguration(cfg core.Configuration) (*Resolver, error) {
+	var rules []*Rule
+	err := cfg.UnmarshalKey(core.CfgKeyVersionFiles, &rules)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", core.CfgKeyVersionFiles)
+	}
+
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+
+	for _, rule := range rules {
+		if rule.File == "" {
+			return nil, errors.Errorf("file of %s is required", core.CfgKeyVersionFiles)
+		}
+
+		if rule.Command == "" && rule.format() != FormatToolVersions {
+			return nil, errors.Errorf("command of version file %s is required", rule.File)
+		}
+	}
+
+	return NewResolver(rules...), nil
+}
+
+func readLines(path string, fn func(line string) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "open %s failed", path)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		line = strings.TrimSpace(line)
+		if line != "" && !fn(line) {
+			break
+		}
+	}
+
+	return errors.Wrapf(scanner.Err(), "read %s failed", path)
+}
+
+// ReadPlain returns the first version of a file like .nvmrc, the v prefix is removed
+func ReadPlain(path string) (string, error) {
+	var version string
+	err := readLines(path, func(line string) bool {
+		version = strings.Fields(line)[0]
+		return false
+	})
+
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		version = version[1:]
+	}
+
+	return version, err
+}
+
+// ReadToolVersions reads the lines like "nodejs 18.17.0 16.20.0", the first version is used
+func ReadToolVersions(path string) (map[string]string, error) {
+	versions := make(map[string]string)
+	err := readLines(path, func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			versions[fields[0]] = fields[1]
+		}
+
+		return true
+	})
+
+	return versions, err
+}
+
+// ReadGoToolchain returns the version of the toolchain directive of go.mod
+func ReadGoToolchain(path string) (string, error) {
+	var version string
+	err := readLines(path, func(line string) bool {
+		matches := goToolchainPattern.FindStringSubmatch(line)
+		if matches != nil {
+			version = matches[1]
+			return false
+		}
+
+		return true
+	})
+
+	return version, err
+}
+
+// MatchVersion returns the installed version of a wanted one, a prefix like 18 matches the latest 18.x.y
+func MatchVersion(wanted string, versions []string) (string, bool) {
+	matched := ""
+	for _, version := range versions {
+		if versioning.Equal(version, wanted) {
+			return version, true
+		}
+
+		if !strings.HasPrefix(version, wanted+".") {
+			continue
+		}
+
+		if matched == "" || versioning.Compare(version, matched) > 0 {
+			matched = version
+		}
+	}
+
+	return matched, matched != ""
+}
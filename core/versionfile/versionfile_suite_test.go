@@ -0,0 +1,13 @@
+package versionfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVersionfile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Versionfile Suite")
+}
@@ -0,0 +1,139 @@
+package versionfile_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/mrlyc/cmdr/core"
+	. "github.com/mrlyc/cmdr/core/versionfile"
+)
+
+var _ = Describe("Versionfile", func() {
+	var rootDir string
+
+	writeFile := func(path string, content string) string {
+		path = filepath.Join(rootDir, path)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		var err error
+		rootDir, err = os.MkdirTemp("", "")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(rootDir)).To(Succeed())
+	})
+
+	DescribeTable("ReadPlain", func(content, expected string) {
+		Expect(ReadPlain(writeFile(".nvmrc", content))).To(Equal(expected))
+	},
+		Entry("version", "18.17.0\n", "18.17.0"),
+		Entry("v prefix", "v18\n", "18"),
+		Entry("alias", "lts/hydrogen\n", "lts/hydrogen"),
+		Entry("comments and multiple lines", "# comment\n\n3.11.4\n3.10.0\n", "3.11.4"),
+		Entry("empty", "", ""),
+	)
+
+	It("should read the toolchain of go.mod", func() {
+		path := writeFile("go.mod", "module example.com/x\n\ngo 1.21\n\ntoolchain go1.22.1\n")
+		Expect(ReadGoToolchain(path)).To(Equal("1.22.1"))
+
+		path = writeFile("go.mod", "module example.com/x\n\ngo 1.21\n")
+		Expect(ReadGoToolchain(path)).To(Equal(""))
+	})
+
+	It("should resolve the nearest files", func() {
+		writeFile(".tool-versions", "nodejs 16.20.0\nterraform 1.5.0\nruby 3.2.0\n")
+		writeFile("project/.nvmrc", "v18\n")
+		writeFile("project/go.mod", "module x\n\ntoolchain go1.22.1\n")
+		writeFile("project/sub/.go-version", "1.21.0\n")
+		writeFile("project/sub/.python-version", "3.11.4\n")
+
+		resolver := NewResolver(DefaultRules()...)
+		resolutions, err := resolver.Resolve(filepath.Join(rootDir, "project", "sub"))
+		Expect(err).To(BeNil())
+
+		versions := make(map[string]string)
+		sources := make(map[string]string)
+		for name, resolution := range resolutions {
+			versions[name] = resolution.Version
+			sources[name] = resolution.Source
+		}
+
+		Expect(versions).To(Equal(map[string]string{
+			"node":      "18",
+			"go":        "1.21.0",
+			"python":    "3.11.4",
+			"terraform": "1.5.0",
+			"ruby":      "3.2.0",
+		}))
+		Expect(sources["node"]).To(Equal(filepath.Join(rootDir, "project", ".nvmrc")))
+		Expect(sources["terraform"]).To(Equal(filepath.Join(rootDir, ".tool-versions")))
+	})
+
+	It("should prefer the former rules in the same dir", func() {
+		writeFile(".tool-versions", "nodejs 16.20.0\n")
+		writeFile(".nvmrc", "18\n")
+
+		resolutions, err := NewResolver(DefaultRules()...).Resolve(rootDir)
+		Expect(err).To(BeNil())
+		Expect(resolutions["node"].Version).To(Equal("16.20.0"))
+	})
+
+	DescribeTable("MatchVersion", func(wanted string, expected string, ok bool) {
+		version, found := MatchVersion(wanted, []string{"16.20.0", "18.9.0", "18.17.0", "1.8"})
+		Expect(found).To(Equal(ok))
+		Expect(version).To(Equal(expected))
+	},
+		Entry("exact", "18.9.0", "18.9.0", true),
+		Entry("equal", "1.8.0", "1.8", true),
+		Entry("prefix", "18", "18.17.0", true),
+		Entry("not installed", "20", "", false),
+		Entry("alias", "lts/*", "", false),
+	)
+
+	Context("configuration", func() {
+		It("should use default rules", func() {
+			resolver, err := NewResolverByConfiguration(viper.New())
+			Expect(err).To(BeNil())
+			Expect(resolver.Rules()).To(Equal(DefaultRules()))
+		})
+
+		It("should use configured rules", func() {
+			cfg := viper.New()
+			cfg.Set(core.CfgKeyVersionFiles, []map[string]interface{}{
+				{"file": ".kubectl-version", "command": "kubectl"},
+				{"file": ".tool-versions", "tools": map[string]string{"nodejs": "nodejs"}},
+			})
+
+			resolver, err := NewResolverByConfiguration(cfg)
+			Expect(err).To(BeNil())
+
+			writeFile(".kubectl-version", "1.28.0\n")
+			writeFile(".tool-versions", "nodejs 18.17.0\n")
+
+			resolutions, err := resolver.Resolve(rootDir)
+			Expect(err).To(BeNil())
+			Expect(resolutions).To(HaveKey("kubectl"))
+			Expect(resolutions).To(HaveKey("nodejs"))
+			Expect(resolutions).NotTo(HaveKey("node"))
+		})
+
+		It("should reject rules without command", func() {
+			cfg := viper.New()
+			cfg.Set(core.CfgKeyVersionFiles, []map[string]interface{}{{"file": ".kubectl-version"}})
+
+			_, err := NewResolverByConfiguration(cfg)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,165 @@
+package versioning
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ver "github.com/hashicorp/go-version"
+)
+
+// SemverVersion is a semantic version like 1.2.0-rc.1+build.5
+type SemverVersion struct {
+	*ver.Version
+}
+
+func (v SemverVersion) Scheme() string {
+	return SemverScheme{}.Name()
+}
+
+func (v SemverVersion) Compare(other Version) int {
+	return v.Version.Compare(other.(SemverVersion).Version)
+}
+
+// Short returns the version without trailing zeros, like 1.1 for 1.1.0,
+// it is the same as String when there is a prerelease or build metadata
+func (v SemverVersion) Short() string {
+	if v.Prerelease() != "" || v.Metadata() != "" {
+		return v.String()
+	}
+
+	segments := v.Segments()
+	if segments[1] == 0 && segments[2] == 0 {
+		return strconv.Itoa(segments[0])
+	}
+
+	if segments[2] == 0 {
+		return fmt.Sprintf("%d.%d", segments[0], segments[1])
+	}
+
+	return fmt.Sprintf("%d.%d.%d", segments[0], segments[1], segments[2])
+}
+
+type SemverScheme struct{}
+
+func (s SemverScheme) Name() string {
+	return "semver"
+}
+
+func (s SemverScheme) Parse(raw string) (Version, bool) {
+	version, err := ver.NewVersion(raw)
+	if err != nil {
+		return nil, false
+	}
+
+	return SemverVersion{version}, true
+}
+
+var calverPattern = regexp.MustCompile(`^(\d{4})([-.])(\d{1,2})(?:[-.](\d{1,2}))?(?:[-.+_](.+))?$`)
+
+// CalverVersion is a calendar version like 2024-05-01 or 2024.05
+type CalverVersion struct {
+	segments [3]int
+	suffix   string
+}
+
+func (v CalverVersion) Scheme() string {
+	return CalverScheme{}.Name()
+}
+
+// String returns the same form for the equal versions, like 2024-05-01 for 2024.5.1,
+// the suffix follows a dash, or an underscore when there is no day so it is not parsed as the day
+func (v CalverVersion) String() string {
+	if v.segments[2] == 0 {
+		version := fmt.Sprintf("%04d-%02d", v.segments[0], v.segments[1])
+		if v.suffix != "" {
+			version += "_" + v.suffix
+		}
+
+		return version
+	}
+
+	version := fmt.Sprintf("%04d-%02d-%02d", v.segments[0], v.segments[1], v.segments[2])
+	if v.suffix != "" {
+		version += "-" + v.suffix
+	}
+
+	return version
+}
+
+func (v CalverVersion) Compare(other Version) int {
+	otherCalver := other.(CalverVersion)
+	for index, segment := range v.segments {
+		if segment != otherCalver.segments[index] {
+			if segment < otherCalver.segments[index] {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	return strings.Compare(v.suffix, otherCalver.suffix)
+}
+
+type CalverScheme struct{}
+
+func (s CalverScheme) Name() string {
+	return "calver"
+}
+
+func (s CalverScheme) Parse(raw string) (Version, bool) {
+	matches := calverPattern.FindStringSubmatch(raw)
+	if matches == nil {
+		return nil, false
+	}
+
+	version := CalverVersion{suffix: matches[5]}
+	for index, group := range []string{matches[1], matches[3], matches[4]} {
+		if group == "" {
+			continue
+		}
+
+		version.segments[index], _ = strconv.Atoi(group)
+	}
+
+	// a month must be valid, otherwise it is more likely a semver like 2024.13.0
+	if version.segments[1] < 1 || version.segments[1] > 12 || version.segments[2] > 31 {
+		return nil, false
+	}
+
+	return version, true
+}
+
+// OpaqueVersion is an unordered version like nightly or a git sha
+type OpaqueVersion string
+
+func (v OpaqueVersion) Scheme() string {
+	return OpaqueScheme{}.Name()
+}
+
+func (v OpaqueVersion) String() string {
+	return string(v)
+}
+
+func (v OpaqueVersion) Compare(other Version) int {
+	return strings.Compare(string(v), string(other.(OpaqueVersion)))
+}
+
+// OpaqueScheme accepts every version
+type OpaqueScheme struct{}
+
+func (s OpaqueScheme) Name() string {
+	return "opaque"
+}
+
+func (s OpaqueScheme) Parse(raw string) (Version, bool) {
+	return OpaqueVersion(raw), true
+}
+
+func init() {
+	// the later ones take precedence
+	RegisterScheme(SemverScheme{})
+	RegisterScheme(CalverScheme{})
+}
@@ -0,0 +1,124 @@
+package versioning
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidVersion = errors.New("invalid version")
+)
+
+// Version is a parsed version of a scheme
+type Version interface {
+	// Scheme returns the name of the scheme which parsed the version
+	Scheme() string
+	// String returns the normalized version, which is safe to be stored and used in file names,
+	// the prerelease and build metadata are kept
+	String() string
+	// Compare compares versions of the same scheme
+	Compare(other Version) int
+}
+
+// Scheme parses versions of a kind, like semver
+type Scheme interface {
+	Name() string
+	// Parse returns false when the version does not belong to the scheme
+	Parse(raw string) (Version, bool)
+}
+
+var (
+	schemesLock sync.RWMutex
+	schemes     []Scheme
+	// the opaque scheme accepts everything, so it is always the last one
+	fallbackScheme Scheme = OpaqueScheme{}
+)
+
+// RegisterScheme registers a scheme which is tried before the registered ones
+func RegisterScheme(scheme Scheme) {
+	schemesLock.Lock()
+	defer schemesLock.Unlock()
+
+	schemes = append([]Scheme{scheme}, schemes...)
+}
+
+func getSchemes() []Scheme {
+	schemesLock.RLock()
+	defer schemesLock.RUnlock()
+
+	return append(append([]Scheme{}, schemes...), fallbackScheme)
+}
+
+func getSchemeRank(name string) int {
+	for index, scheme := range getSchemes() {
+		if scheme.Name() == name {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// Parse parses the version by the first scheme which accepts it
+func Parse(raw string) (Version, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.Wrapf(ErrInvalidVersion, "empty version")
+	}
+
+	// the version is a part of shim file names
+	if strings.ContainsAny(raw, "/\\ \t\n") {
+		return nil, errors.Wrapf(ErrInvalidVersion, "%q contains path separators or spaces", raw)
+	}
+
+	for _, scheme := range getSchemes() {
+		version, ok := scheme.Parse(raw)
+		if ok {
+			return version, nil
+		}
+	}
+
+	return nil, errors.Wrapf(ErrInvalidVersion, "%q", raw)
+}
+
+// Normalize returns the normalized version, or the raw one when it is invalid
+func Normalize(raw string) string {
+	version, err := Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	return version.String()
+}
+
+// CompareVersions orders versions by scheme priority first, then by the scheme itself
+func CompareVersions(a, b Version) int {
+	if a.Scheme() != b.Scheme() {
+		rankA, rankB := getSchemeRank(a.Scheme()), getSchemeRank(b.Scheme())
+		if rankA < rankB {
+			return -1
+		}
+
+		return 1
+	}
+
+	return a.Compare(b)
+}
+
+// Compare compares raw versions, invalid versions are compared as strings
+func Compare(a, b string) int {
+	versionA, errA := Parse(a)
+	versionB, errB := Parse(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+
+	return CompareVersions(versionA, versionB)
+}
+
+// Equal returns true when the versions are the same, like 1.0 and 1.0.0
+func Equal(a, b string) bool {
+	return Compare(a, b) == 0
+}
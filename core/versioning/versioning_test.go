@@ -0,0 +1,100 @@
+package versioning_test
+
+import (
+	"errors"
+	"sort"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/mrlyc/cmdr/core/versioning"
+)
+
+var _ = Describe("Versioning", func() {
+	DescribeTable("Parse", func(raw, scheme, normalized string) {
+		version, err := versioning.Parse(raw)
+		Expect(err).To(BeNil())
+		Expect(version.Scheme()).To(Equal(scheme))
+		Expect(version.String()).To(Equal(normalized))
+	},
+		Entry("semver", "1.2.3", "semver", "1.2.3"),
+		Entry("short semver", "1.2", "semver", "1.2.0"),
+		Entry("v prefix", "v1.2.3", "semver", "1.2.3"),
+		Entry("prerelease", "1.2.0-rc.1", "semver", "1.2.0-rc.1"),
+		Entry("build metadata", "1.2.0-rc.1+build.5", "semver", "1.2.0-rc.1+build.5"),
+		Entry("calver date", "2024-05-01", "calver", "2024-05-01"),
+		Entry("calver dots", "2024.05.01", "calver", "2024-05-01"),
+		Entry("calver unpadded", "2024.5.1", "calver", "2024-05-01"),
+		Entry("calver month", "2024.05", "calver", "2024-05"),
+		Entry("calver suffix", "2024.05.01-1", "calver", "2024-05-01-1"),
+		Entry("calver suffix separator", "2024.05.01+1", "calver", "2024-05-01-1"),
+		Entry("calver month suffix", "2024.05_1", "calver", "2024-05_1"),
+		Entry("nightly", "nightly", "opaque", "nightly"),
+		Entry("git sha", "a1b2c3d", "opaque", "a1b2c3d"),
+	)
+
+	DescribeTable("invalid versions", func(raw string) {
+		_, err := versioning.Parse(raw)
+		Expect(errors.Is(err, versioning.ErrInvalidVersion)).To(BeTrue())
+	},
+		Entry("empty", ""),
+		Entry("path", "../1.0"),
+		Entry("space", "1.0 beta"),
+	)
+
+	DescribeTable("Compare", func(a, b string, expected int) {
+		Expect(versioning.Compare(a, b)).To(Equal(expected))
+	},
+		Entry("semver", "1.10.0", "1.9.0", 1),
+		Entry("short semver", "1.0", "1.0.0", 0),
+		Entry("prerelease", "1.2.0-rc.1", "1.2.0", -1),
+		Entry("calver", "2024-05-01", "2024-12-01", -1),
+		Entry("calver formats", "2024.5.1", "2024-05-01", 0),
+		Entry("opaque", "nightly", "nightly", 0),
+		Entry("semver before calver", "1.0.0", "2024-05-01", 1),
+		Entry("opaque last", "nightly", "99.0.0", 1),
+	)
+
+	It("should sort mixed versions", func() {
+		versions := []string{"nightly", "1.10.0", "2024-05-01", "1.2.0-rc.1", "1.2.0", "2023-01-01"}
+		sort.Slice(versions, func(i, j int) bool {
+			return versioning.Compare(versions[i], versions[j]) < 0
+		})
+
+		Expect(versions).To(Equal([]string{
+			"2023-01-01", "2024-05-01", "1.2.0-rc.1", "1.2.0", "1.10.0", "nightly",
+		}))
+	})
+
+	DescribeTable("Normalize the equal versions", func(a, b string) {
+		Expect(versioning.Equal(a, b)).To(BeTrue())
+		Expect(versioning.Normalize(a)).To(Equal(versioning.Normalize(b)))
+		Expect(versioning.Normalize(versioning.Normalize(a))).To(Equal(versioning.Normalize(a)))
+	},
+		Entry("calver formats", "2024.5.1", "2024-05-01"),
+		Entry("calver suffix", "2024.05.01+rc1", "2024-5-1-rc1"),
+		Entry("calver month", "2024.5", "2024-05"),
+		Entry("calver month suffix", "2024.05+1", "2024-5_1"),
+		Entry("short semver", "1.0", "v1.0.0"),
+	)
+
+	It("should keep invalid version when normalizing", func() {
+		Expect(versioning.Normalize("1.0")).To(Equal("1.0.0"))
+		Expect(versioning.Normalize("a b")).To(Equal("a b"))
+	})
+
+	Context("SemverVersion", func() {
+		DescribeTable("Short", func(raw, expected string) {
+			version, err := versioning.Parse(raw)
+			Expect(err).To(BeNil())
+			Expect(version.(versioning.SemverVersion).Short()).To(Equal(expected))
+		},
+			Entry("major", "1.0.0", "1"),
+			Entry("minor", "1.1.0", "1.1"),
+			Entry("patch", "1.1.1", "1.1.1"),
+			Entry("prerelease", "1.2.0-rc.1", "1.2.0-rc.1"),
+			Entry("metadata", "1.0.0+build", "1.0.0+build"),
+		)
+	})
+})